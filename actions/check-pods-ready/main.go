@@ -5,6 +5,7 @@ package main
 
 import (
 	"github.com/kubearmor/kubearmor-action/pkg/controller/client"
+	"github.com/kubearmor/kubearmor-action/pkg/github"
 
 	"github.com/sethvargo/go-githubactions"
 )
@@ -18,12 +19,31 @@ func main() {
 		action.Fatalf("failed to create k8s client: %v", err)
 		return
 	}
+	// Group the events and logs of the not ready pods
+	client.Grouper = action
 
 	// Wait for all pods to be running
 	action.Infof("Wait for all pods to be running...")
-	err = client.WaitAllPodRunning()
+	waitErr := client.WaitAllPodRunning()
+
+	// Write the readiness of the pods to the job summary, and annotate the not ready ones
+	pods, err := client.ListPodReadiness()
 	if err != nil {
-		action.Fatalf("failed to wait for all pods to be running: %v", err)
+		action.Warningf("failed to list the pods: %v", err)
+	} else {
+		github.AddStepSummary(action, github.ReadinessSummary(pods))
+		if waitErr != nil {
+			for _, pod := range pods {
+				if !pod.Ready && pod.Phase != "Succeeded" {
+					action.WithFieldsMap(map[string]string{"title": "Pod not ready"}).
+						Errorf("pod %s/%s is %s and not ready: %s", pod.Namespace, pod.Name, pod.Phase, pod.Reason)
+				}
+			}
+		}
+	}
+
+	if waitErr != nil {
+		action.Fatalf("failed to wait for all pods to be running: %v", waitErr)
 		return
 	}
 }
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package main
+
+import (
+	"context"
+
+	"github.com/kubearmor/kubearmor-action/pkg/runner"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+func main() {
+	action := githubactions.New()
+
+	// Read the inputs of the action
+	in, err := runner.InputsFrom(action)
+	if err != nil {
+		action.Fatalf("%v", err)
+		return
+	}
+
+	// Collect, diff, render and report, the outputs of the phases that ran are set even if one failed
+	out, err := runner.Run(context.Background(), action, in)
+	out.Set(action)
+	if err != nil {
+		action.Fatalf("%v", err)
+		return
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var attackCatalog bool
+
+var attackCmd = &cobra.Command{
+	Use:     "attack",
+	Short:   "attack subcommand is a command to map the behaviors of a karmor summary to MITRE ATT&CK techniques.",
+	Example: "visual attack -f [json file name]\nvisual attack --catalog",
+	Run: func(cmd *cobra.Command, args []string) {
+		catalog := visual.BuiltinAttackCatalog()
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if attackCatalog {
+			if err := enc.Encode(catalog); err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			return
+		}
+		if !cmd.Flags().Changed("file") {
+			klog.Fatalf("Error: 'file' flag is not set")
+		}
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		sd, err := p.ParseSummaryData(jsonFile)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		observations := catalog.MapSummaryData(sd, f, visual.BuiltinClassifier())
+		if err := enc.Encode(observations); err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d technique observation(s)\n", catalog, len(observations))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(attackCmd)
+
+	flags := attackCmd.PersistentFlags()
+	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name, URL or - for stdin, may be gzip compressed")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
+	flags.BoolVarP(&attackCatalog, "catalog", "", false, "print the embedded ATT&CK technique catalog instead of mapping a summary")
+}
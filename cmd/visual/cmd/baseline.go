@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/kubearmor/kubearmor-action/pkg/baseline"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	baselineStore  string
+	baselineToken  string
+	baselineKey    baseline.Key
+	baselineFile   string
+	baselineOutput string
+	baselineJSON   bool
+	baselineDryRun bool
+	baselinePolicy baseline.RetentionPolicy
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "baseline subcommand is a command to store, fetch, list and prune the baseline summaries by branch, workload and commit.",
+	Example: "visual baseline put --store git:https://github.com/[owner]/[repo] --branch main --commit [sha] -f [json file name]\n" +
+		"visual baseline get --store git:https://github.com/[owner]/[repo] --branch main -o [json file name]\n" +
+		"visual baseline list --store dir:baselines\n" +
+		"visual baseline prune --store git:https://github.com/[owner]/[repo] --keep-last 10 --max-age 720h --keep-branch main",
+}
+
+var baselineGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "get writes the baseline of the branch, workload and commit, the latest of the branch and workload without commit.",
+	Run: func(cmd *cobra.Command, args []string) {
+		var data []byte
+		var b baseline.Baseline
+		withBaselineStore(func(s baseline.Store) (err error) {
+			data, b, err = s.Get(context.Background(), baselineKey)
+			return err
+		})
+		if baselineOutput == "" || baselineOutput == "-" {
+			os.Stdout.Write(data) // #nosec
+			return
+		}
+		if err := os.WriteFile(baselineOutput, data, 0600); err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		klog.Infof("wrote baseline %s created at %s to %s", b.Key, b.Created.Format(time.RFC3339), baselineOutput)
+	},
+}
+
+var baselinePutCmd = &cobra.Command{
+	Use:   "put",
+	Short: "put stores the summary as the baseline of the branch, workload and commit.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("file") {
+			klog.Fatalf("Error: 'file' flag is not set")
+		}
+		var data []byte
+		var err error
+		if baselineFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(baselineFile) // #nosec
+		}
+		if err != nil {
+			klog.Fatalf("Error: reading baseline file: %v", err)
+		}
+		var b baseline.Baseline
+		withBaselineStore(func(s baseline.Store) (err error) {
+			b, err = s.Put(context.Background(), baselineKey, data)
+			return err
+		})
+		fmt.Println(b.Path)
+	},
+}
+
+var baselineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list lists the baselines of the branch and workload, oldest first, of every branch and workload if not set.",
+	Run: func(cmd *cobra.Command, args []string) {
+		var baselines []baseline.Baseline
+		withBaselineStore(func(s baseline.Store) (err error) {
+			baselines, err = s.List(context.Background(), baselineKey.Branch, baselineKey.Workload)
+			return err
+		})
+		printBaselines(baselines)
+	},
+}
+
+var baselinePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "prune deletes the baselines expired by the retention policy and lists them.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if baselinePolicy.KeepLast <= 0 && baselinePolicy.MaxAge <= 0 {
+			klog.Fatalf("Error: set --keep-last or --max-age")
+		}
+		var expired []baseline.Baseline
+		withBaselineStore(func(s baseline.Store) error {
+			if !baselineDryRun {
+				var err error
+				expired, err = baseline.Prune(context.Background(), s, baselinePolicy, time.Now())
+				return err
+			}
+			baselines, err := s.List(context.Background(), "", "")
+			expired = baselinePolicy.Expired(baselines, time.Now())
+			return err
+		})
+		printBaselines(expired)
+	},
+}
+
+// withBaselineStore runs fn with the store of the --store flag and closes the store, it exits if
+// fn fails
+func withBaselineStore(fn func(s baseline.Store) error) {
+	s := openBaselineStore()
+	err := fn(s)
+	if closeErr := baseline.Close(s); closeErr != nil {
+		klog.Warningf("closing the baseline store: %v", closeErr)
+	}
+	if err != nil {
+		klog.Fatalf("Error: %v", err)
+	}
+}
+
+// openBaselineStore opens the store of the --store flag
+func openBaselineStore() baseline.Store {
+	token := baselineToken
+	if token == "" {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		token = baseline.GitHubToken(baselineStore, server, os.Getenv("GITHUB_TOKEN"))
+	}
+	s, err := baseline.Open(baselineStore, token)
+	if err != nil {
+		klog.Fatalf("Error: %v", err)
+	}
+	return s
+}
+
+// printBaselines prints the baselines as a table or as JSON
+func printBaselines(baselines []baseline.Baseline) {
+	if baselineJSON {
+		if baselines == nil {
+			baselines = []baseline.Baseline{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(baselines); err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		return
+	}
+	for _, b := range baselines {
+		fmt.Printf("%s\t%s\n", b.Created.Format(time.RFC3339), b.Key)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineGetCmd, baselinePutCmd, baselineListCmd, baselinePruneCmd)
+
+	flags := baselineCmd.PersistentFlags()
+	flags.StringVarP(&baselineStore, "store", "s", "", "baseline store: dir:<path> or a path, git:<repository>[#branch] (kubearmor-baselines by default) or an http(s) URL")
+	flags.StringVarP(&baselineToken, "token", "", "", "token of the git remote or the HTTP server, defaults to $GITHUB_TOKEN for the repositories of $GITHUB_SERVER_URL (https://github.com) only")
+	flags.BoolVarP(&baselineJSON, "json", "", false, "list the baselines as JSON")
+
+	for _, c := range []*cobra.Command{baselineGetCmd, baselinePutCmd, baselineListCmd} {
+		c.Flags().StringVarP(&baselineKey.Branch, "branch", "b", "", "branch of the baseline, eg.: main")
+		c.Flags().StringVarP(&baselineKey.Workload, "workload", "w", "", "workload of the baseline, eg.: namespace/deployment, all the workloads if not set")
+	}
+	for _, c := range []*cobra.Command{baselineGetCmd, baselinePutCmd} {
+		c.Flags().StringVarP(&baselineKey.Commit, "commit", "c", "", "commit of the baseline, the latest baseline if not set in get")
+	}
+	baselineGetCmd.Flags().StringVarP(&baselineOutput, "output", "o", "", "file to write the baseline to, stdout if not set")
+	baselinePutCmd.Flags().StringVarP(&baselineFile, "file", "f", "", "summary JSON file to store, - for stdin")
+
+	prune := baselinePruneCmd.Flags()
+	prune.IntVarP(&baselinePolicy.KeepLast, "keep-last", "", 0, "keep the latest baselines of every branch and workload")
+	prune.DurationVarP(&baselinePolicy.MaxAge, "max-age", "", 0, "prune the baselines older than the duration, eg.: 720h")
+	prune.StringSliceVarP(&baselinePolicy.KeepBranches, "keep-branch", "", nil, "protected branches, their latest baseline is always kept")
+	prune.BoolVarP(&baselineDryRun, "dry-run", "", false, "list the baselines that would be pruned without deleting them")
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kubearmor/kubearmor-action/pkg/github"
+	"github.com/sethvargo/go-githubactions"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	commentFile   string
+	commentMarker string
+	commentRepo   string
+	commentPR     int
+	commentAPIURL string
+	commentLogin  string
+)
+
+var commentCmd = &cobra.Command{
+	Use:     "comment",
+	Short:   "comment subcommand is a command to create or update in place the kubearmor-action comment of a pull request.",
+	Example: "visual comment -f [markdown file name]\nvisual comment -f [markdown file name] --repo [owner/repo] --pr [number]",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("file") {
+			klog.Fatalf("Error: 'file' flag is not set")
+		}
+		var body []byte
+		var err error
+		if commentFile == "-" {
+			body, err = io.ReadAll(os.Stdin)
+		} else {
+			body, err = os.ReadFile(commentFile) // #nosec
+		}
+		if err != nil {
+			klog.Fatalf("Error: reading comment file: %v", err)
+		}
+
+		// the pull request, the API URL and the token come from the GitHub Actions environment, unless set
+		pr, apiURL, token, err := github.PullRequestFromEnv(githubactions.New())
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		if commentRepo != "" {
+			pr, err = github.ParsePullRequest(commentRepo, pr.Number)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+		}
+		if commentPR != 0 {
+			pr.Number = commentPR
+		}
+		if commentAPIURL != "" {
+			apiURL = commentAPIURL
+		}
+		if pr.Owner == "" || pr.Repo == "" || pr.Number == 0 {
+			klog.Fatalf("Error: unknown pull request %s, set --repo and --pr outside of a pull_request workflow", pr)
+		}
+		if token == "" {
+			klog.Fatalf("Error: GITHUB_TOKEN is not set")
+		}
+
+		client := github.NewClient(apiURL, token)
+		client.Login = commentLogin
+		comment, created, err := client.UpsertComment(context.Background(), pr, commentMarker, string(body))
+		if err != nil {
+			klog.Fatalf("Error: commenting on %s: %v", pr, err)
+		}
+		if created {
+			fmt.Printf("created comment %s\n", comment.HTMLURL)
+		} else {
+			fmt.Printf("updated comment %s\n", comment.HTMLURL)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commentCmd)
+
+	flags := commentCmd.PersistentFlags()
+	flags.StringVarP(&commentFile, "file", "f", "", "Markdown file of the comment, eg.: the output of visual report, - for stdin")
+	flags.StringVarP(&commentMarker, "marker", "", github.DefaultCommentMarker, "hidden marker identifying the comment to update, use different markers for several comments")
+	flags.StringVarP(&commentRepo, "repo", "", "", "repository as owner/repo or owner/repo#number, defaults to $GITHUB_REPOSITORY")
+	flags.IntVarP(&commentPR, "pr", "", 0, "pull request number, defaults to the number of the workflow event")
+	flags.StringVarP(&commentAPIURL, "api-url", "", "", "GitHub API URL, defaults to $GITHUB_API_URL")
+	flags.StringVarP(&commentLogin, "login", "", "", "login of the token user, only its comments are updated, defaults to the login of the token or "+github.DefaultCommentLogin)
+}
@@ -3,6 +3,12 @@
 
 package cmd
 
+import (
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/pflag"
+)
+
 var (
 	jsonFile  string
 	oldFile   string
@@ -10,4 +16,118 @@ var (
 	appName   string
 	sysOutput string
 	netOutput string
+
+	perWorkload  bool
+	fileTreeOpts visual.FileTreeOptions
+
+	filterOpts filter.Options
+
+	normalizeConfig string
+	noNormalize     bool
+
+	volumeRatio    float64
+	volumeMinDelta int64
+
+	windowSince string
+	windowUntil string
+	phaseName   string
+	phasesFile  string
 )
+
+// addFilterFlags adds the flags shared by every visualisation to select workloads and behaviors
+func addFilterFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&appName, "app", "", "", "filter app name, if you want to visualize specific app (matches the app label or the deployment name exactly)")
+	flags.StringVarP(&filterOpts.Selector, "selector", "l", "", "filter workloads by Kubernetes label selector, eg.: 'app in (mysql,wordpress),tier!=cache'")
+	flags.StringSliceVarP(&filterOpts.Namespaces, "namespace", "n", nil, "filter workloads by namespaces")
+	flags.StringSliceVarP(&filterOpts.Deployments, "deployment", "", nil, "filter workloads by deployment names")
+	flags.StringSliceVarP(&filterOpts.Containers, "container", "", nil, "filter container names, if you want to visualize specific containers of multi-container pods")
+	flags.StringVarP(&filterOpts.PodRegex, "pod-regex", "", "", "filter pods by regular expression")
+	flags.StringVarP(&filterOpts.ProcessRegex, "process-regex", "", "", "filter processes by regular expression")
+	flags.StringVarP(&filterOpts.FileRegex, "file-regex", "", "", "filter files by regular expression")
+	flags.StringSliceVarP(&filterOpts.Excludes, "exclude", "", nil, "exclude pods, processes or files matching the glob patterns, '**' matches across '/'")
+	flags.StringSliceVarP(&filterOpts.Statuses, "status", "", nil, "filter behaviors by policy outcome: Allow, Audit or Block, connections are Allow")
+}
+
+// newFilter builds the filter from the flags
+func newFilter() (*filter.Filter, error) {
+	opts := filterOpts
+	opts.App = appName
+	return filter.New(opts)
+}
+
+// addNormalizeFlags adds the flags shared by every visualisation to normalize the summary data
+func addNormalizeFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&normalizeConfig, "normalize-config", "", "", "YAML or JSON file of normalization rules (regex -> replacement) applied after the built-in rules")
+	flags.BoolVarP(&noNormalize, "no-normalize", "", false, "do not normalize temp files, pids, hashes and pod names")
+}
+
+// newNormalizer builds the normalizer from the flags, nil if normalization is disabled
+func newNormalizer() (*visual.Normalizer, error) {
+	if noNormalize {
+		return nil, nil
+	}
+	return visual.LoadNormalizer(normalizeConfig)
+}
+
+// addWindowFlags adds the flags shared by every command reading summaries to select the entries by time
+func addWindowFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&windowSince, "since", "", "", "only keep the entries updated since this time (RFC 3339 or karmor layout), or this duration before the newest entry, eg.: 10m")
+	flags.StringVarP(&windowUntil, "until", "", "", "only keep the entries updated until this time (RFC 3339 or karmor layout), or this duration before the newest entry, eg.: 2m")
+	flags.StringVarP(&phaseName, "phase", "", "", "only keep the entries updated during this phase of the phases file, --since and --until override its start and end")
+	flags.StringVarP(&phasesFile, "phases-file", "", visual.DefaultPhasesFile, "file the phases of the run are recorded in, see visual phase")
+}
+
+// newTimeWindow builds the time window from the flags
+func newTimeWindow() (visual.TimeWindow, error) {
+	var w visual.TimeWindow
+	if phaseName != "" {
+		phases, err := visual.ReadPhases(phasesFile)
+		if err != nil {
+			return w, err
+		}
+		phase, err := phases.Get(phaseName)
+		if err != nil {
+			return w, err
+		}
+		w = phase.Window()
+	}
+	since, err := visual.ParseTimeBound(windowSince)
+	if err != nil {
+		return w, err
+	}
+	if since != nil {
+		w.Since = since
+	}
+	until, err := visual.ParseTimeBound(windowUntil)
+	if err != nil {
+		return w, err
+	}
+	if until != nil {
+		w.Until = until
+	}
+	return w, nil
+}
+
+// newParseOptions builds the parse options from the normalization and time window flags
+func newParseOptions() (visual.ParseOptions, error) {
+	n, err := newNormalizer()
+	if err != nil {
+		return visual.ParseOptions{}, err
+	}
+	w, err := newTimeWindow()
+	if err != nil {
+		return visual.ParseOptions{}, err
+	}
+	return visual.ParseOptions{Normalizer: n, Window: w}, nil
+}
+
+// addVolumeFlags adds the flags shared by the diffs to classify the count changes as volume spikes or drops
+func addVolumeFlags(flags *pflag.FlagSet) {
+	flags.Float64VarP(&volumeRatio, "volume-ratio", "", visual.DefaultVolumeRatio, "factor a count must grow or shrink by to be a volume spike or drop, negative to disable")
+	flags.Int64VarP(&volumeMinDelta, "volume-min-delta", "", visual.DefaultVolumeMinDelta, "difference of counts below which no volume spike or drop is reported, 0 to only check the ratio")
+}
+
+// newDiffOptions builds the diff options from the flags
+func newDiffOptions() visual.DiffOptions {
+	return visual.DiffOptions{Volume: visual.NewVolumeThresholds(volumeRatio, visual.Count(volumeMinDelta))}
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/github"
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/sethvargo/go-githubactions"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	diffOutput   string
+	diffMaxGrade string
+	sarifOutput  string
+	sarifMinRisk int
+	manifests    []string
+
+	githubOutput   bool
+	maxAnnotations int
+
+	ignoreTrend string
+)
+
+var diffCmd = &cobra.Command{
+	Use:     "diff",
+	Short:   "diff subcommand is a command to report the behaviors added, removed or changed between two karmor summaries, with the sensitive ones.",
+	Example: "visual diff --old [old json file name] --new [new json file name] -o [report json file name]",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("old") {
+			klog.Fatalf("Error: 'old' flag is not set")
+		}
+		if !cmd.Flags().Changed("new") {
+			klog.Fatalf("Error: 'new' flag is not set")
+		}
+		maxGrade := ""
+		if diffMaxGrade != "" {
+			g, err := visual.ParseGrade(diffMaxGrade)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			maxGrade = g
+		}
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		// in GitHub Actions, the parsing logs are grouped and written to stderr with the workflow commands,
+		// so that stdout only has the report
+		action := githubactions.New(githubactions.WithWriter(os.Stderr))
+		if githubOutput {
+			action.Group("Parsing the summaries")
+		}
+		report, err := newDiffOptions().DiffJSONFiles(oldFile, newFile, f, p)
+		klog.Flush()
+		if githubOutput {
+			action.EndGroup()
+		}
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		if ignoreTrend != "" {
+			trend, err := visual.ReadTrendReport(ignoreTrend)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			ignored := report.Ignore(trend.Nondeterministic(), visual.BuiltinClassifier())
+			fmt.Fprintf(os.Stderr, "ignored %d changes of nondeterministic behaviors\n", ignored)
+		}
+		index, err := visual.IndexManifests(manifests...)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		// the results of unknown workloads are located in the new summary, if it is a file of the
+		// repository, or in the workflow file
+		fallback := workflowFile()
+		if newFile != "-" && !strings.Contains(newFile, "://") {
+			fallback = newFile
+		}
+
+		out := os.Stdout
+		if diffOutput != "-" {
+			file, err := os.Create(diffOutput) // #nosec
+			if err != nil {
+				klog.Fatalf("Error: creating output file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			klog.Fatalf("Error: writing diff report: %v", err)
+		}
+
+		if sarifOutput != "" {
+			sarif := report.SARIF(visual.SARIFOptions{MinRisk: sarifMinRisk, Manifests: index, Fallback: fallback, MaxGrade: maxGrade})
+			file, err := os.Create(sarifOutput) // #nosec
+			if err != nil {
+				klog.Fatalf("Error: creating SARIF file: %v", err)
+			}
+			defer file.Close()
+			if err := visual.WriteSARIF(file, sarif); err != nil {
+				klog.Fatalf("Error: writing SARIF file: %v", err)
+			}
+		}
+
+		if githubOutput {
+			github.AddStepSummary(action, visual.MarkdownReport(report, nil, visual.ReportOptions{Title: "KubeArmor Behavior Diff"}))
+			github.AnnotateDiff(action, report, github.AnnotationOptions{Manifests: index, Fallback: fallback, Max: maxAnnotations})
+		}
+
+		fmt.Fprintf(os.Stderr, "risk: %s\n", report.Risk)
+		if top := report.Risk.Top; top != nil {
+			fmt.Fprintf(os.Stderr, "riskiest change: %s %s: %s, risk %d\n", top.Workload, top.Change, top.Behavior, top.Behavior.Risk)
+		}
+		for _, finding := range report.Findings() {
+			if finding.New {
+				fmt.Fprintf(os.Stderr, "new finding: %s: %s\n", finding.Workload, finding)
+			}
+		}
+
+		if maxGrade != "" && visual.GradeWorse(report.Risk.Grade, maxGrade) {
+			fmt.Fprintf(os.Stderr, "Error: risk grade %s is worse than the maximum grade %s\n", report.Risk.Grade, maxGrade)
+			os.Exit(1) // #nosec
+		}
+	},
+}
+
+// workflowFile returns the path of the workflow file in the repository, from $GITHUB_WORKFLOW_REF,
+// eg.: owner/repo/.github/workflows/test.yml@refs/heads/main, empty outside of GitHub Actions
+func workflowFile() string {
+	ref := os.Getenv("GITHUB_WORKFLOW_REF")
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	flags := diffCmd.PersistentFlags()
+	flags.StringVarP(&oldFile, "old", "", "", "old karmor summary JSON file name, URL or - for stdin")
+	flags.StringVarP(&newFile, "new", "", "", "new karmor summary JSON file name, URL or - for stdin")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
+	addVolumeFlags(flags)
+	flags.StringVarP(&diffOutput, "output", "o", "-", "diff report JSON file name, - for stdout")
+	flags.StringVarP(&sarifOutput, "sarif", "", "", "also write the new findings and risky behaviors as SARIF 2.1.0 to this file, for GitHub code scanning")
+	flags.IntVarP(&sarifMinRisk, "sarif-min-risk", "", 40, "lowest risk score of the added behaviors without finding written to the SARIF file, 0 for none")
+	flags.StringSliceVarP(&manifests, "manifests", "", nil, "Kubernetes manifest files or directories the SARIF results and the annotations are located in")
+	flags.BoolVarP(&githubOutput, "github", "", github.Enabled(), "write the report to the GitHub job summary and annotate the new behaviors, the default in GitHub Actions")
+	flags.IntVarP(&maxAnnotations, "max-annotations", "", github.DefaultMaxAnnotations, "number of new behaviors annotated, the riskiest first")
+	flags.StringVarP(&diffMaxGrade, "max-grade", "", "", "fail if the risk grade of the changes is worse than this grade: A, B, C, D or F")
+	flags.StringVarP(&ignoreTrend, "ignore-trend", "", "", "JSON trend report of visual trend, the changes of the nondeterministic behaviors it flags are ignored")
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	mergeFiles  []string
+	mergeOutput string
+)
+
+var mergeCmd = &cobra.Command{
+	Use:     "merge",
+	Short:   "merge subcommand is a command to merge several karmor summaries into one deduplicated summary.",
+	Example: "visual merge -f [json file name] -f [json file name or URL] -o [merged json file name]",
+	Run: func(cmd *cobra.Command, args []string) {
+		files := append(mergeFiles, args...)
+		if len(files) == 0 {
+			klog.Fatalf("Error: no summary file to merge, set the 'file' flag")
+		}
+
+		w, err := newTimeWindow()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		m := visual.NewMerger()
+		for _, file := range files {
+			klog.V(2).Infof("merging %s", file)
+			if err := mergeSummaryFile(m, file); err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+		}
+		// the relative bounds select the entries of the merged summary, relative to its newest entry
+		merged := w.Apply(m.Result())
+
+		if mergeOutput == "-" {
+			if err := visual.WriteSummaryData(os.Stdout, merged); err != nil {
+				klog.Fatalf("Error: writing merged summary: %v", err)
+			}
+		} else {
+			f, err := os.Create(mergeOutput) // #nosec
+			if err != nil {
+				klog.Fatalf("Error: creating output file: %v", err)
+			}
+			err = visual.WriteSummaryData(f, merged)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				klog.Fatalf("Error: writing merged summary: %v", err)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "merged %d summaries into %d workloads\n", len(files), len(merged))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	flags := mergeCmd.PersistentFlags()
+	flags.StringSliceVarP(&mergeFiles, "file", "f", nil, "karmor summary JSON file names or URLs, can be repeated")
+	addWindowFlags(flags)
+	flags.StringVarP(&mergeOutput, "output", "o", "-", "merged summary JSON file name, - for stdout")
+}
+
+// mergeSummaryFile adds the entries of the summary file or URL to the merger, the file is closed
+// before returning
+func mergeSummaryFile(m *visual.Merger, file string) error {
+	rc, err := visual.OpenSummaryData(file)
+	if err != nil {
+		return err
+	}
+	err = visual.DecodeSummaryData(rc, file, func(sd *visual.SummaryData) error {
+		m.Add(sd)
+		return nil
+	})
+	if cerr := rc.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("closing %s: %w", file, cerr)
+	}
+	return err
+}
@@ -5,68 +5,138 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
 	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
 	"github.com/kubearmor/kubearmor-action/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/klog"
 )
 
+var netFormat string
+
 var networkCmd = &cobra.Command{
 	Use:     "network",
-	Short:   "network subcommand is a command to visualization network connection behaviors differences.",
-	Example: "visual network --old [old json file name] --new [new json file name] -app [app name] -o [png file name]",
+	Short:   "network subcommand is a command to visualization network connection behaviors differences, or the network connections of a single summary.",
+	Example: "visual network --old [old json file name] --new [new json file name] -app [app name] -o [png file name]\nvisual network -f [json file name] -o [png file name]\nvisual network -f [json file name] --format mermaid -o [mmd file name]",
 	Run: func(cmd *cobra.Command, args []string) {
-		a := cmd.Flags().Changed("old")
-		if a == false {
-			klog.Fatalf("Error: 'old' flag is not set")
+		snapshot := cmd.Flags().Changed("file")
+		if snapshot && (cmd.Flags().Changed("old") || cmd.Flags().Changed("new")) {
+			klog.Fatalf("Error: 'file' flag cannot be set with 'old' and 'new' flags")
 		}
-		b := cmd.Flags().Changed("new")
-		if b == false {
-			klog.Fatalf("Error: 'new' flag is not set")
+		if !snapshot {
+			a := cmd.Flags().Changed("old")
+			if a == false {
+				klog.Fatalf("Error: 'old' flag is not set")
+			}
+			b := cmd.Flags().Changed("new")
+			if b == false {
+				klog.Fatalf("Error: 'new' flag is not set")
+			}
+		}
+		if netFormat != formatPNG && netFormat != formatMermaid {
+			klog.Fatalf("Error: invalid format %q, must be %s or %s", netFormat, formatPNG, formatMermaid)
 		}
 
-		fmt.Println("old file:", oldFile)
-		// Check is URL
 		var err error
-		if !utils.CheckIsURL(oldFile) {
-			oldFile, err = filepath.Abs(oldFile)
+		if snapshot {
+			fmt.Println("file:", jsonFile)
+			jsonFile, err = absPath(jsonFile)
+			if err != nil {
+				klog.Fatalf("Error: getting absolute path of 'file' flag: %v", err)
+			}
+		} else {
+			fmt.Println("old file:", oldFile)
+			oldFile, err = absPath(oldFile)
 			if err != nil {
 				klog.Fatalf("Error: getting absolute path of 'oldFile' flag: %v", err)
 			}
-		}
-
-		fmt.Println("new file:", newFile)
-		// Check is URL
-		if !utils.CheckIsURL(newFile) {
-			newFile, err = filepath.Abs(newFile)
+			fmt.Println("new file:", newFile)
+			newFile, err = absPath(newFile)
 			if err != nil {
 				klog.Fatalf("Error: getting absolute path of 'newFile' flag: %v", err)
 			}
 		}
 
 		fmt.Println("app name:", appName)
-		err = visual.ConvertNetworkJSONToImage(oldFile, newFile, netOutput, appName)
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		if netFormat == formatMermaid {
+			if err := writeNetworkMermaid(snapshot, f, p); err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			return
+		}
+		if snapshot {
+			err = visual.ConvertNetworkSnapshotToImage(jsonFile, netOutput, f, p)
+		} else {
+			err = newDiffOptions().ConvertNetworkJSONToImage(oldFile, newFile, netOutput, f, p)
+		}
 		if err != nil {
 			fmt.Println("Error:", err)
 		}
 	},
 }
 
+// Output formats of the network view
+const (
+	formatPNG     = "png"
+	formatMermaid = "mermaid"
+)
+
+// writeNetworkMermaid writes the network view as a Mermaid flowchart to the output file
+func writeNetworkMermaid(snapshot bool, f *filter.Filter, p visual.ParseOptions) error {
+	var vnd *visual.VisualNetworkData
+	if snapshot {
+		sds, err := p.ParseSummaryData(jsonFile)
+		if err != nil {
+			return err
+		}
+		vnd = visual.ParseNetworkSnapshot(sds, f)
+	} else {
+		sdOlds, err := p.Older().ParseSummaryData(oldFile)
+		if err != nil {
+			return err
+		}
+		sdNews, err := p.ParseSummaryData(newFile)
+		if err != nil {
+			return err
+		}
+		vnd = newDiffOptions().ParseNetworkData(sdOlds, sdNews, f)
+	}
+	if vnd == nil {
+		return fmt.Errorf("Error: no summary data")
+	}
+	return os.WriteFile(netOutput, []byte(visual.ConvertVndToMermaid(vnd, 0)), 0600)
+}
+
+// absPath returns the absolute path of a local file, URLs and "-" are returned as is
+func absPath(file string) (string, error) {
+	if utils.CheckIsURL(file) || file == "-" {
+		return file, nil
+	}
+	return filepath.Abs(file)
+}
+
 func init() {
 	rootCmd.AddCommand(networkCmd)
 
 	flags := networkCmd.PersistentFlags()
 	flags.StringVarP(&oldFile, "old", "", "", "old karmor summary JSON file name")
 	flags.StringVarP(&newFile, "new", "", "", "new karmor summary JSON file name")
-	flags.StringVarP(&appName, "app", "", "", "filter app name, if you want to visualize specific app")
+	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name, to visualize its network connections without changes instead of --old and --new")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
+	addVolumeFlags(flags)
 	flags.StringVarP(&netOutput, "output", "o", "net.png", "output image file name")
-
-	if err := networkCmd.MarkPersistentFlagRequired("old"); err != nil {
-		klog.Fatalf("Error: marking 'old' flag as required: %v", err)
-	}
-	if err := networkCmd.MarkPersistentFlagRequired("new"); err != nil {
-		klog.Fatalf("Error: marking 'new' flag as required: %v", err)
-	}
+	flags.StringVarP(&netFormat, "format", "", formatPNG, "output format: png, or mermaid for a Mermaid flowchart that needs no Java")
 }
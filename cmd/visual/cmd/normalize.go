@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	normalizeOutput string
+	normalizeTrace  bool
+)
+
+var normalizeCmd = &cobra.Command{
+	Use:     "normalize",
+	Short:   "normalize subcommand is a command to remove the run-to-run noise of a karmor summary, eg.: temp files, pids or pod names.",
+	Example: "visual normalize -f [json file name] --normalize-config [rules file name] -o [normalized json file name] --trace",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("file") {
+			klog.Fatalf("Error: 'file' flag is not set")
+		}
+		n, err := visual.LoadNormalizer(normalizeConfig)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		w, err := newTimeWindow()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		sd, err := visual.ParseOptions{Normalizer: n, Window: w}.ParseSummaryData(jsonFile)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+
+		out := os.Stdout
+		if normalizeOutput != "-" {
+			f, err := os.Create(normalizeOutput) // #nosec
+			if err != nil {
+				klog.Fatalf("Error: creating output file: %v", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := visual.WriteSummaryData(out, sd); err != nil {
+			klog.Fatalf("Error: writing normalized summary: %v", err)
+		}
+
+		subs := n.Substitutions()
+		if normalizeTrace {
+			enc := json.NewEncoder(os.Stderr)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(subs); err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "normalized %d values\n", len(subs))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(normalizeCmd)
+
+	flags := normalizeCmd.PersistentFlags()
+	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name, URL or - for stdin, may be gzip compressed")
+	flags.StringVarP(&normalizeConfig, "normalize-config", "", "", "YAML or JSON file of normalization rules (regex -> replacement) applied after the built-in rules")
+	addWindowFlags(flags)
+	flags.StringVarP(&normalizeOutput, "output", "o", "-", "normalized summary JSON file name, - for stdout")
+	flags.BoolVarP(&normalizeTrace, "trace", "", false, "print the normalized values with the rule that matched them to stderr")
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var phaseAt string
+
+var phaseCmd = &cobra.Command{
+	Use:   "phase",
+	Short: "phase subcommand is a command to record the start and end of the phases of a run, eg.: a load test, to select the entries of the summary updated during a phase with --phase.",
+	Example: "visual phase start load-test\n" +
+		"visual phase end load-test\n" +
+		"visual diff --old [old json file name] --new [new json file name] --phase load-test",
+}
+
+// newPhaseMarkCmd returns the command recording the mark of a phase
+func newPhaseMarkCmd(mark string) *cobra.Command {
+	return &cobra.Command{
+		Use:   mark + " [phase name]",
+		Short: fmt.Sprintf("%s records the %s of the phase in the phases file, now or at --at.", mark, mark),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			at := time.Now()
+			if phaseAt != "" {
+				t, err := visual.ParseTimestamp(phaseAt)
+				if err != nil {
+					klog.Fatalf("Error: %v", err)
+				}
+				at = t.Time
+			}
+			if err := visual.RecordPhase(phasesFile, args[0], mark, at); err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+		},
+	}
+}
+
+var phaseListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list lists the phases of the phases file with their start and end.",
+	Run: func(cmd *cobra.Command, args []string) {
+		phases, err := visual.ReadPhases(phasesFile)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		names := make([]string, 0, len(phases))
+		for name := range phases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s\t%s\n", name, phases[name].Window())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(phaseCmd)
+	start, end := newPhaseMarkCmd(visual.PhaseStart), newPhaseMarkCmd(visual.PhaseEnd)
+	phaseCmd.AddCommand(start, end, phaseListCmd)
+
+	phaseCmd.PersistentFlags().StringVarP(&phasesFile, "phases-file", "", visual.DefaultPhasesFile, "file the phases of the run are recorded in")
+	for _, c := range []*cobra.Command{start, end} {
+		c.Flags().StringVarP(&phaseAt, "at", "", "", "time of the mark, RFC 3339 or karmor layout, now if not set")
+	}
+}
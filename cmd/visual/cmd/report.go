@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	reportFormat    string
+	reportOutput    string
+	reportArtifacts []string
+	reportOpts      visual.ReportOptions
+)
+
+var reportCmd = &cobra.Command{
+	Use:     "report",
+	Short:   "report subcommand is a command to render the behavior changes between two karmor summaries as a pull request comment.",
+	Example: "visual report --old [old json file name] --new [new json file name] --format markdown --artifact 'network=https://.../net.png' -o report.md",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("old") {
+			klog.Fatalf("Error: 'old' flag is not set")
+		}
+		if !cmd.Flags().Changed("new") {
+			klog.Fatalf("Error: 'new' flag is not set")
+		}
+		opts := reportOpts
+		opts.Diff = newDiffOptions()
+		for _, a := range reportArtifacts {
+			link, err := visual.ParseReportLink(a)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			opts.Artifacts = append(opts.Artifacts, link)
+		}
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		report, err := visual.ReportJSONFiles(oldFile, newFile, reportFormat, f, p, opts)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+
+		out := os.Stdout
+		if reportOutput != "-" {
+			file, err := os.Create(reportOutput) // #nosec
+			if err != nil {
+				klog.Fatalf("Error: creating output file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if _, err := fmt.Fprint(out, report); err != nil {
+			klog.Fatalf("Error: writing report: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	flags := reportCmd.PersistentFlags()
+	flags.StringVarP(&oldFile, "old", "", "", "old karmor summary JSON file name, URL or - for stdin")
+	flags.StringVarP(&newFile, "new", "", "", "new karmor summary JSON file name, URL or - for stdin")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
+	addVolumeFlags(flags)
+	flags.StringVarP(&reportFormat, "format", "", visual.FormatMarkdown, "report format: markdown")
+	flags.StringVarP(&reportOutput, "output", "o", "-", "report file name, - for stdout")
+	flags.StringVarP(&reportOpts.Title, "title", "", visual.DefaultReportTitle, "report heading")
+	flags.StringArrayVarP(&reportArtifacts, "artifact", "", nil, "artifact linked in the report as [name=]url, images are shown inline, can be repeated")
+	flags.IntVarP(&reportOpts.MaxRows, "max-rows", "", visual.DefaultReportMaxRows, "rows of every table, the rest is only counted")
+	flags.IntVarP(&reportOpts.MaxEdges, "max-edges", "", visual.DefaultReportMaxEdges, "connections of the network diagram, unchanged ones are left out first")
+	flags.IntVarP(&reportOpts.MaxLength, "max-length", "", visual.DefaultReportMaxLength, "characters of the report, truncated beyond with a link to the artifacts, a GitHub comment has at most 65536")
+}
@@ -10,7 +10,7 @@ import (
 var rootCmd = &cobra.Command{
 	Use:     "visual",
 	Short:   "visual is a command to visualization system or network behaviors.",
-	Example: "visual system -f [json file name] --app [app name] -o [png file name]\nvisual network --old [old json file name] --new [new json file name] -app [app name] -o [png file name]",
+	Example: "visual system -f [json file name] --app [app name] -o [png file name]\nvisual network --old [old json file name] --new [new json file name] -app [app name] -o [png file name]\nvisual network -f [json file name] -o [png file name]\nvisual validate -f [json file name]\nvisual normalize -f [json file name] --trace\nvisual diff --old [old json file name] --new [new json file name]\nvisual report --old [old json file name] --new [new json file name] --format markdown\nvisual comment -f [markdown file name]\nvisual trend -f [json file name] -f [json file name] -f [json file name]\nvisual baseline get --store [store] --branch [branch] -o [json file name]\nvisual phase start [phase name]",
 }
 
 // Execute executes the root command.
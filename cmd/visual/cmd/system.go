@@ -25,7 +25,7 @@ var systemCmd = &cobra.Command{
 		fmt.Println("file:", jsonFile)
 		// Check is URL
 		var err error
-		if !utils.CheckIsURL(jsonFile) {
+		if !utils.CheckIsURL(jsonFile) && jsonFile != "-" {
 			jsonFile, err = filepath.Abs(jsonFile)
 			if err != nil {
 				klog.Fatalf("Error: getting absolute path of 'file' flag: %v", err)
@@ -33,7 +33,25 @@ var systemCmd = &cobra.Command{
 		}
 
 		fmt.Println("app name:", appName)
-		err = visual.ConvertSysJSONToImage(jsonFile, sysOutput, appName)
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		if perWorkload {
+			images, err := visual.ConvertSysJSONToImages(jsonFile, sysOutput, f, p, fileTreeOpts)
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+			for _, image := range images {
+				fmt.Println("image:", image)
+			}
+			return
+		}
+		err = visual.ConvertSysJSONToImage(jsonFile, sysOutput, f, p, fileTreeOpts)
 		if err != nil {
 			fmt.Println("Error:", err)
 		}
@@ -44,9 +62,15 @@ func init() {
 	rootCmd.AddCommand(systemCmd)
 
 	flags := systemCmd.PersistentFlags()
-	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name")
-	flags.StringVarP(&appName, "app", "", "", "filter app name, if you want to visualize specific app")
+	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name, URL or - for stdin, may be gzip compressed")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
 	flags.StringVarP(&sysOutput, "output", "o", "sys.png", "output image file name")
+	flags.IntVarP(&fileTreeOpts.CollapseDepth, "collapse-depth", "", 0, "collapse the accessed directories deeper than this depth into dir/**, 0 never collapses")
+	flags.IntVarP(&fileTreeOpts.MaxFanout, "max-fanout", "", 0, "collapse the accessed directories with more children than this into dir/**, 0 never collapses")
+	flags.StringSliceVarP(&fileTreeOpts.KeepExpanded, "keep-expanded", "", visual.DefaultKeepExpanded, "glob patterns of sensitive paths that are never collapsed")
+	flags.BoolVarP(&perWorkload, "per-workload", "", false, "render one image per pod and container, named after the output, with a Markdown index linking them")
 
 	if err := systemCmd.MarkPersistentFlagRequired("file"); err != nil {
 		klog.Fatalf("Error: marking 'file' flag as required: %v", err)
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/kubearmor/kubearmor-action/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	treeOutput string
+	treeJSON   bool
+)
+
+var treeCmd = &cobra.Command{
+	Use:     "tree",
+	Short:   "tree subcommand is a command to visualization process execution trees.",
+	Example: "visual tree -f [json file name] --app [app name] -o [png file name]\nvisual tree -f [json file name] --json",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("file") {
+			klog.Fatalf("Error: 'file' flag is not set")
+		}
+		var err error
+		if !utils.CheckIsURL(jsonFile) && jsonFile != "-" {
+			jsonFile, err = filepath.Abs(jsonFile)
+			if err != nil {
+				klog.Fatalf("Error: getting absolute path of 'file' flag: %v", err)
+			}
+		}
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+
+		if treeJSON {
+			sd, err := p.ParseSummaryData(jsonFile)
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			data, err := json.MarshalIndent(visual.BuildProcessTrees(sd, f), "", "  ")
+			if err != nil {
+				klog.Fatalf("Error: %v", err)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		err = visual.ConvertProcessTreeJSONToImage(jsonFile, treeOutput, f, p)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+
+	flags := treeCmd.PersistentFlags()
+	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name, URL or - for stdin, may be gzip compressed")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
+	flags.StringVarP(&treeOutput, "output", "o", "proc.png", "output image file name")
+	flags.BoolVarP(&treeJSON, "json", "", false, "print the process trees as JSON instead of rendering an image")
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kubearmor/kubearmor-action/pkg/baseline"
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+// formatJSON is the JSON format of the trend report
+const formatJSON = "json"
+
+var (
+	trendFiles    []string
+	trendStore    string
+	trendBranch   string
+	trendWorkload string
+	trendLast     int
+	trendFormat   string
+	trendOutput   string
+	trendMaxRows  int
+)
+
+var trendCmd = &cobra.Command{
+	Use:   "trend",
+	Short: "trend subcommand is a command to report the presence of the behaviors across ordered karmor summaries, flagging the nondeterministic ones.",
+	Example: "visual trend -f [oldest json file name] -f [json file name] -f [newest json file name] -o trend.md\n" +
+		"visual trend --store [store] --branch main --last 10 --format json -o trend.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(trendFiles) == 0 && trendStore == "" {
+			klog.Fatalf("Error: 'file' or 'store' flag is not set")
+		}
+		if len(trendFiles) > 0 && trendStore != "" {
+			klog.Fatalf("Error: 'file' and 'store' flags cannot both be set")
+		}
+		if trendFormat != visual.FormatMarkdown && trendFormat != formatJSON {
+			klog.Fatalf("Error: invalid trend format %q, must be %s or %s", trendFormat, visual.FormatMarkdown, formatJSON)
+		}
+		f, err := newFilter()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		p, err := newParseOptions()
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+
+		var summaries []visual.TrendSummary
+		if trendStore != "" {
+			summaries, err = trendSummariesFromStore(p)
+		} else {
+			for i, file := range trendFiles {
+				klog.Infof("Parsing %s...", file)
+				sds, err := trendParseOptions(p, i, len(trendFiles)).ParseSummaryData(file)
+				if err != nil {
+					klog.Fatalf("Error: %v", err)
+				}
+				summaries = append(summaries, visual.TrendSummary{Name: file, Data: sds})
+			}
+		}
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		if len(summaries) < 2 {
+			klog.Fatalf("Error: a trend needs at least 2 summaries, got %d", len(summaries))
+		}
+		trend := visual.Trend(summaries, f)
+
+		out := os.Stdout
+		if trendOutput != "-" {
+			file, err := os.Create(trendOutput) // #nosec
+			if err != nil {
+				klog.Fatalf("Error: creating output file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if trendFormat == formatJSON {
+			enc := json.NewEncoder(out)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(trend)
+		} else {
+			_, err = fmt.Fprint(out, trend.Markdown(visual.ReportOptions{MaxRows: trendMaxRows}))
+		}
+		if err != nil {
+			klog.Fatalf("Error: writing trend report: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "%d runs, %d behaviors, %d nondeterministic\n", len(trend.Runs), len(trend.Behaviors), len(trend.Nondeterministic()))
+	},
+}
+
+// trendSummariesFromStore reads the last baselines of the branch and workload from the store, oldest first
+func trendSummariesFromStore(p visual.ParseOptions) ([]visual.TrendSummary, error) {
+	ctx := context.Background()
+	s, err := baseline.Open(trendStore, os.Getenv("GITHUB_TOKEN"))
+	if err != nil {
+		return nil, err
+	}
+	baselines, err := s.List(ctx, trendBranch, trendWorkload)
+	if err != nil {
+		return nil, err
+	}
+	if trendLast > 0 && len(baselines) > trendLast {
+		baselines = baselines[len(baselines)-trendLast:]
+	}
+	var summaries []visual.TrendSummary
+	for i, b := range baselines {
+		data, err := s.Read(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		sds, err := trendParseOptions(p, i, len(baselines)).ReadSummaryData(bytes.NewReader(data), b.Path)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, visual.TrendSummary{Name: b.Key.String(), Time: visual.Timestamp{Time: b.Created}, Data: sds})
+	}
+	return summaries, nil
+}
+
+// trendParseOptions returns the options to parse the i-th of n summaries, the absolute time bounds
+// and the phases only select the entries of the newest one
+func trendParseOptions(p visual.ParseOptions, i, n int) visual.ParseOptions {
+	if i < n-1 {
+		return p.Older()
+	}
+	return p
+}
+
+func init() {
+	rootCmd.AddCommand(trendCmd)
+
+	flags := trendCmd.PersistentFlags()
+	flags.StringSliceVarP(&trendFiles, "file", "f", nil, "karmor summary JSON file names or URLs, oldest first, repeatable")
+	flags.StringVarP(&trendStore, "store", "s", "", "baseline store to read the summaries from instead, see visual baseline")
+	flags.StringVarP(&trendBranch, "branch", "b", "", "branch of the baselines of the store, every branch if not set")
+	flags.StringVarP(&trendWorkload, "workload", "w", "", "workload of the baselines of the store, any workload if not set")
+	flags.IntVarP(&trendLast, "last", "", 10, "number of latest baselines of the store, 0 for all")
+	addFilterFlags(flags)
+	addNormalizeFlags(flags)
+	addWindowFlags(flags)
+	flags.StringVarP(&trendFormat, "format", "", visual.FormatMarkdown, "trend report format: markdown or json")
+	flags.StringVarP(&trendOutput, "output", "o", "-", "trend report file name, - for stdout")
+	flags.IntVarP(&trendMaxRows, "max-rows", "", visual.DefaultReportMaxRows, "number of unstable behaviors listed in the markdown report")
+}
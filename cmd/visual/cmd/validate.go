@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+)
+
+var (
+	printSchema    bool
+	validateStrict bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:     "validate",
+	Short:   "validate subcommand is a command to validate a karmor summary against the summary JSON Schema.",
+	Example: "visual validate -f [json file name]\nvisual validate -f [json file name] --strict\nvisual validate --schema > summary.schema.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		if printSchema {
+			data, err := json.MarshalIndent(visual.SummarySchema(), "", "  ")
+			if err != nil {
+				klog.Fatalf("Error: marshalling the summary schema: %v", err)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		if !cmd.Flags().Changed("file") {
+			klog.Fatalf("Error: 'file' flag is not set")
+		}
+
+		rc, err := visual.OpenSummaryData(jsonFile)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		defer rc.Close()
+		violations, err := visual.ParseOptions{Strict: validateStrict}.ValidateSummaryData(rc, jsonFile)
+		if err != nil {
+			klog.Fatalf("Error: %v", err)
+		}
+		for _, v := range violations {
+			fmt.Println(v)
+		}
+		if len(violations) > 0 {
+			fmt.Printf("%s: %d schema violation(s)\n", jsonFile, len(violations))
+			os.Exit(1) // #nosec
+		}
+		fmt.Printf("%s: valid\n", jsonFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	flags := validateCmd.PersistentFlags()
+	flags.StringVarP(&jsonFile, "file", "f", "", "karmor summary JSON file name, URL or - for stdin, may be gzip compressed")
+	flags.BoolVarP(&printSchema, "schema", "", false, "print the summary JSON Schema instead of validating")
+	flags.BoolVarP(&validateStrict, "strict", "", false, "also report the fields that are not described by the schema")
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/kubearmor/kubearmor-action/common"
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
 	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
 )
 
@@ -18,12 +19,21 @@ func main() {
 	// sd := visual.ParseSummaryData(jsonFile)
 	// vnd := visual.ParseNetworkData(sd)
 	// fmt.Println(vnd)
-	appName := "wordpress"
-	err := visual.ConvertNetworkJSONToImage(oldJSONFile, newJSONFile, "net.png", appName)
+	f, err := filter.New(filter.Options{App: "wordpress"})
+	if err != nil {
+		fmt.Println("Filter Error:", err)
+		return
+	}
+	n, err := visual.LoadNormalizer("")
+	if err != nil {
+		fmt.Println("Normalizer Error:", err)
+		return
+	}
+	err = visual.ConvertNetworkJSONToImage(oldJSONFile, newJSONFile, "net.png", f, visual.ParseOptions{Normalizer: n})
 	if err != nil {
 		fmt.Println("Network-Visualisation Error:", err)
 	}
-	err = visual.ConvertSysJSONToImage(newJSONFile, "sys.png", appName)
+	err = visual.ConvertSysJSONToImage(newJSONFile, "sys.png", f, visual.ParseOptions{Normalizer: n}, visual.FileTreeOptions{KeepExpanded: visual.DefaultKeepExpanded})
 	if err != nil {
 		fmt.Println("System-Visualisation Error:", err)
 	}
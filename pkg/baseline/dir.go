@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirStore stores the baselines in a local directory, eg.: a cache restored by actions/cache
+type DirStore struct {
+	Root string
+}
+
+// NewDirStore returns the store of the directory, it is created on the first Put
+func NewDirStore(root string) *DirStore {
+	return &DirStore{Root: root}
+}
+
+// Get implements Store
+func (s *DirStore) Get(ctx context.Context, key Key) ([]byte, Baseline, error) {
+	if err := key.Validate(); err != nil {
+		return nil, Baseline{}, err
+	}
+	baselines, err := s.List(ctx, key.Branch, key.Workload)
+	if err != nil {
+		return nil, Baseline{}, err
+	}
+	b, err := match(baselines, key)
+	if err != nil {
+		return nil, Baseline{}, err
+	}
+	data, err := s.Read(ctx, b)
+	return data, b, err
+}
+
+// Read implements Store
+func (s *DirStore) Read(ctx context.Context, b Baseline) ([]byte, error) {
+	return os.ReadFile(s.file(b.Path))
+}
+
+// Put implements Store
+func (s *DirStore) Put(ctx context.Context, key Key, data []byte) (Baseline, error) {
+	if err := key.Validate(); err != nil {
+		return Baseline{}, err
+	}
+	now := time.Now().UTC()
+	b := Baseline{Key: key, Created: now, Path: pathOf(key, now)}
+	file := s.file(b.Path)
+	if err := os.MkdirAll(filepath.Dir(file), 0750); err != nil {
+		return Baseline{}, err
+	}
+	// write to a temporary file first, so that readers never see a partial baseline
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return Baseline{}, err
+	}
+	return b, os.Rename(tmp, file)
+}
+
+// List implements Store
+func (s *DirStore) List(ctx context.Context, branch, workload string) ([]Baseline, error) {
+	var paths []string
+	err := filepath.Walk(s.Root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filterPaths(paths, branch, workload), nil
+}
+
+// Delete implements Store, the directories left empty are removed
+func (s *DirStore) Delete(ctx context.Context, baselines ...Baseline) error {
+	for _, b := range baselines {
+		file := s.file(b.Path)
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		for dir := filepath.Dir(file); dir != filepath.Clean(s.Root) && strings.HasPrefix(dir, filepath.Clean(s.Root)); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// file returns the file of a baseline path
+func (s *DirStore) file(p string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(p))
+}
@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// DefaultGitBranch is the branch the baselines are committed to
+const DefaultGitBranch = "kubearmor-baselines"
+
+// GitStore stores the baselines in a branch of a local or remote git repository, every Put or
+// Delete is a commit pushed to the branch. The branch is created as an orphan branch on the
+// first Put. It uses go-git, the git CLI is not needed.
+type GitStore struct {
+	// Repository is a local path or a remote URL, eg.: https://github.com/owner/repo
+	Repository string
+	// Branch is the branch of the baselines, DefaultGitBranch if empty
+	Branch string
+	// Dir is the working copy, a temporary directory if empty
+	Dir string
+	// Token authenticates to a remote HTTPS repository, eg.: $GITHUB_TOKEN
+	Token string
+	// AuthorName and AuthorEmail are the author of the commits
+	AuthorName  string
+	AuthorEmail string
+
+	// repo is the working copy, once cloned, and worktree its store
+	repo     *git.Repository
+	worktree *DirStore
+	// tempDir is the working copy created by open, removed by Close
+	tempDir string
+}
+
+// NewGitStore returns the store of the branch of the repository, DefaultGitBranch if branch is empty
+func NewGitStore(repository, branch string) *GitStore {
+	if branch == "" {
+		branch = DefaultGitBranch
+	}
+	return &GitStore{
+		Repository:  repository,
+		Branch:      branch,
+		AuthorName:  "github-actions[bot]",
+		AuthorEmail: "41898282+github-actions[bot]@users.noreply.github.com",
+	}
+}
+
+// Get implements Store
+func (s *GitStore) Get(ctx context.Context, key Key) ([]byte, Baseline, error) {
+	if err := s.open(ctx); err != nil {
+		return nil, Baseline{}, err
+	}
+	return s.worktree.Get(ctx, key)
+}
+
+// Read implements Store
+func (s *GitStore) Read(ctx context.Context, b Baseline) ([]byte, error) {
+	if err := s.open(ctx); err != nil {
+		return nil, err
+	}
+	return s.worktree.Read(ctx, b)
+}
+
+// Put implements Store
+func (s *GitStore) Put(ctx context.Context, key Key, data []byte) (Baseline, error) {
+	if err := s.open(ctx); err != nil {
+		return Baseline{}, err
+	}
+	b, err := s.worktree.Put(ctx, key, data)
+	if err != nil {
+		return Baseline{}, err
+	}
+	return b, s.commit(ctx, "Add baseline "+key.String())
+}
+
+// List implements Store
+func (s *GitStore) List(ctx context.Context, branch, workload string) ([]Baseline, error) {
+	if err := s.open(ctx); err != nil {
+		return nil, err
+	}
+	return s.worktree.List(ctx, branch, workload)
+}
+
+// Delete implements Store, the baselines are removed in a single commit
+func (s *GitStore) Delete(ctx context.Context, baselines ...Baseline) error {
+	if len(baselines) == 0 {
+		return nil
+	}
+	if err := s.open(ctx); err != nil {
+		return err
+	}
+	if err := s.worktree.Delete(ctx, baselines...); err != nil {
+		return err
+	}
+	return s.commit(ctx, fmt.Sprintf("Remove %d baselines", len(baselines)))
+}
+
+// open clones the branch into the working copy, or initializes it if the branch does not exist yet
+func (s *GitStore) open(ctx context.Context) error {
+	if s.worktree != nil {
+		return nil
+	}
+	dir := s.Dir
+	if dir == "" {
+		var err error
+		dir, err = os.MkdirTemp("", "kubearmor-baselines-")
+		if err != nil {
+			return err
+		}
+		s.tempDir = dir
+	}
+
+	exists, err := s.branchExists(ctx)
+	if err != nil {
+		return err
+	}
+	var repo *git.Repository
+	if exists {
+		repo, err = git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+			URL:           s.Repository,
+			Auth:          s.auth(),
+			ReferenceName: s.branchRef(),
+			SingleBranch:  true,
+		})
+		if err != nil {
+			return fmt.Errorf("cloning %s: %w", s.Repository, err)
+		}
+	} else {
+		// the branch does not exist, it is created by the first commit
+		repo, err = git.PlainInit(dir, false)
+		if err != nil {
+			return fmt.Errorf("initializing %s: %w", dir, err)
+		}
+		if _, err := repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{s.Repository}}); err != nil {
+			return err
+		}
+		if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, s.branchRef())); err != nil {
+			return err
+		}
+	}
+	s.repo = repo
+	s.worktree = NewDirStore(dir)
+	return nil
+}
+
+// branchExists reports whether the branch exists in the repository
+func (s *GitStore) branchExists(ctx context.Context) (bool, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{s.Repository}})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: s.auth()})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("listing the branches of %s: %w", s.Repository, err)
+	}
+	for _, ref := range refs {
+		if ref.Name() == s.branchRef() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Close removes the temporary working copy, if any
+func (s *GitStore) Close() error {
+	if s.tempDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(s.tempDir)
+	s.tempDir, s.repo, s.worktree = "", nil, nil
+	return err
+}
+
+// commit commits the changes of the working copy and pushes them, if the push is rejected
+// because the branch moved, the changes are applied again on top of the branch and pushed once more
+func (s *GitStore) commit(ctx context.Context, msg string) error {
+	changes, err := s.changes()
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+	if err := s.commitChanges(changes, msg); err != nil {
+		return err
+	}
+	if err := s.push(ctx); err == nil {
+		return nil
+	}
+	if err := s.reset(ctx); err != nil {
+		return err
+	}
+	if err := s.applyChanges(changes); err != nil {
+		return err
+	}
+	if err := s.commitChanges(changes, msg); err != nil {
+		return err
+	}
+	return s.push(ctx)
+}
+
+// changes returns the content of the files of the working copy that changed since the last
+// commit, nil for the deleted files
+func (s *GitStore) changes() (map[string][]byte, error) {
+	w, err := s.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string][]byte, len(status))
+	for file, fs := range status {
+		if fs.Worktree == git.Unmodified {
+			continue
+		}
+		if fs.Worktree == git.Deleted {
+			changes[file] = nil
+			continue
+		}
+		// #nosec
+		data, err := os.ReadFile(filepath.Join(s.worktree.Root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		changes[file] = data
+	}
+	return changes, nil
+}
+
+// applyChanges writes the changed files to the working copy and removes the deleted ones
+func (s *GitStore) applyChanges(changes map[string][]byte) error {
+	for file, data := range changes {
+		path := filepath.Join(s.worktree.Root, filepath.FromSlash(file))
+		if data == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitChanges stages the changed files and commits them
+func (s *GitStore) commitChanges(changes map[string][]byte, msg string) error {
+	w, err := s.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	for file, data := range changes {
+		if data == nil {
+			_, err = w.Remove(file)
+		} else {
+			_, err = w.Add(file)
+		}
+		if err != nil {
+			return fmt.Errorf("staging %s: %w", file, err)
+		}
+	}
+	author := &object.Signature{Name: s.AuthorName, Email: s.AuthorEmail, When: time.Now()}
+	if _, err := w.Commit(msg, &git.CommitOptions{Author: author}); err != nil {
+		return fmt.Errorf("committing %q: %w", msg, err)
+	}
+	return nil
+}
+
+// push pushes the branch to the repository
+func (s *GitStore) push(ctx context.Context) error {
+	ref := s.branchRef()
+	err := s.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(ref + ":" + ref)},
+		Auth:       s.auth(),
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("pushing to %s: %w", s.Repository, err)
+	}
+	return nil
+}
+
+// reset fetches the branch and resets the working copy to it
+func (s *GitStore) reset(ctx context.Context) error {
+	remoteRef := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, s.Branch)
+	err := s.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec("+" + s.branchRef() + ":" + remoteRef)},
+		Auth:       s.auth(),
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetching %s: %w", s.Repository, err)
+	}
+	ref, err := s.repo.Reference(remoteRef, true)
+	if err != nil {
+		return err
+	}
+	w, err := s.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return w.Reset(&git.ResetOptions{Commit: ref.Hash(), Mode: git.HardReset})
+}
+
+// branchRef returns the reference of the branch, eg.: refs/heads/kubearmor-baselines
+func (s *GitStore) branchRef() plumbing.ReferenceName {
+	return plumbing.NewBranchReferenceName(s.Branch)
+}
+
+// auth returns the authentication to a remote HTTPS repository, nil without a token
+func (s *GitStore) auth() transport.AuthMethod {
+	if s.Token == "" || !strings.HasPrefix(s.Repository, "https://") && !strings.HasPrefix(s.Repository, "http://") {
+		return nil
+	}
+	return &http.BasicAuth{Username: "x-access-token", Password: s.Token}
+}
@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexFile is the file listing the baselines of an HTTP store, HTTP has no portable directory listing
+const IndexFile = "index.json"
+
+// HTTPStore stores the baselines on an HTTP server accepting GET, PUT and DELETE, eg.: a WebDAV
+// server or an object storage bucket. The baselines are listed in IndexFile, which is rewritten
+// by every Put and Delete, so concurrent writers may lose index entries but never baselines.
+type HTTPStore struct {
+	// BaseURL is the URL of the store, the baselines are stored under it
+	BaseURL string
+	// Token authenticates the requests as a bearer token, if set
+	Token string
+	// HTTPClient sends the requests
+	HTTPClient *http.Client
+}
+
+// NewHTTPStore returns the store at baseURL
+func NewHTTPStore(baseURL, token string) *HTTPStore {
+	return &HTTPStore{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Get implements Store
+func (s *HTTPStore) Get(ctx context.Context, key Key) ([]byte, Baseline, error) {
+	if err := key.Validate(); err != nil {
+		return nil, Baseline{}, err
+	}
+	baselines, err := s.List(ctx, key.Branch, key.Workload)
+	if err != nil {
+		return nil, Baseline{}, err
+	}
+	b, err := match(baselines, key)
+	if err != nil {
+		return nil, Baseline{}, err
+	}
+	data, err := s.Read(ctx, b)
+	if err != nil {
+		return nil, Baseline{}, err
+	}
+	return data, b, nil
+}
+
+// Read implements Store
+func (s *HTTPStore) Read(ctx context.Context, b Baseline) ([]byte, error) {
+	return s.do(ctx, http.MethodGet, b.Path, nil)
+}
+
+// Put implements Store
+func (s *HTTPStore) Put(ctx context.Context, key Key, data []byte) (Baseline, error) {
+	if err := key.Validate(); err != nil {
+		return Baseline{}, err
+	}
+	now := time.Now().UTC()
+	b := Baseline{Key: key, Created: now, Path: pathOf(key, now)}
+	if _, err := s.do(ctx, http.MethodPut, b.Path, data); err != nil {
+		return Baseline{}, err
+	}
+	paths, err := s.index(ctx)
+	if err != nil {
+		return Baseline{}, err
+	}
+	return b, s.writeIndex(ctx, append(paths, b.Path))
+}
+
+// List implements Store
+func (s *HTTPStore) List(ctx context.Context, branch, workload string) ([]Baseline, error) {
+	paths, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return filterPaths(paths, branch, workload), nil
+}
+
+// Delete implements Store, the index is rewritten once
+func (s *HTTPStore) Delete(ctx context.Context, baselines ...Baseline) error {
+	if len(baselines) == 0 {
+		return nil
+	}
+	deleted := map[string]bool{}
+	for _, b := range baselines {
+		if _, err := s.do(ctx, http.MethodDelete, b.Path, nil); err != nil && !isNotFound(err) {
+			return err
+		}
+		deleted[b.Path] = true
+	}
+	paths, err := s.index(ctx)
+	if err != nil {
+		return err
+	}
+	kept := paths[:0]
+	for _, p := range paths {
+		if !deleted[p] {
+			kept = append(kept, p)
+		}
+	}
+	return s.writeIndex(ctx, kept)
+}
+
+// index returns the paths of the index, none if there is no index yet
+func (s *HTTPStore) index(ctx context.Context) ([]string, error) {
+	data, err := s.do(ctx, http.MethodGet, IndexFile, nil)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, fmt.Errorf("parsing %s/%s: %w", s.BaseURL, IndexFile, err)
+	}
+	return paths, nil
+}
+
+// writeIndex writes the sorted, deduplicated paths to the index
+func (s *HTTPStore) writeIndex(ctx context.Context, paths []string) error {
+	sort.Strings(paths)
+	unique := []string{}
+	for i, p := range paths {
+		if i == 0 || p != paths[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	data, err := json.MarshalIndent(unique, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = s.do(ctx, http.MethodPut, IndexFile, data)
+	return err
+}
+
+// statusError is returned when the server responds with an error status code
+type statusError struct {
+	method     string
+	url        string
+	statusCode int
+}
+
+// Error implements the error interface
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: server reported %d", e.method, e.url, e.statusCode)
+}
+
+// isNotFound reports whether err is a 404 response
+func isNotFound(err error) bool {
+	e, ok := err.(*statusError)
+	return ok && e.statusCode == http.StatusNotFound
+}
+
+// do sends a request for the path under BaseURL and returns the response body
+func (s *HTTPStore) do(ctx context.Context, method, p string, body []byte) ([]byte, error) {
+	u := s.BaseURL + "/" + p
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, &statusError{method: method, url: u, statusCode: resp.StatusCode}
+	}
+	return data, nil
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy decides which baselines are pruned. The limits apply per branch and workload,
+// and the latest baseline of a protected branch is always kept.
+type RetentionPolicy struct {
+	// KeepLast is the number of latest baselines kept, no limit if 0
+	KeepLast int
+	// MaxAge is the age after which the baselines are pruned, no limit if 0
+	MaxAge time.Duration
+	// KeepBranches are the protected branches, eg.: main
+	KeepBranches []string
+}
+
+// ParseRetentionPolicy parses a policy given as comma separated keep-last=<n>, max-age=<duration>
+// and keep-branch=<branch> settings, eg.: "keep-last=10,max-age=720h,keep-branch=main"
+func ParseRetentionPolicy(s string) (RetentionPolicy, error) {
+	var p RetentionPolicy
+	for _, setting := range strings.Split(s, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(setting, "=")
+		var err error
+		switch strings.TrimSpace(name) {
+		case "keep-last":
+			p.KeepLast, err = strconv.Atoi(strings.TrimSpace(value))
+		case "max-age":
+			p.MaxAge, err = time.ParseDuration(strings.TrimSpace(value))
+		case "keep-branch":
+			p.KeepBranches = append(p.KeepBranches, strings.TrimSpace(value))
+		default:
+			err = fmt.Errorf("unknown setting")
+		}
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("invalid retention policy setting %q, must be keep-last=<n>, max-age=<duration> or keep-branch=<branch>: %w", setting, err)
+		}
+	}
+	return p, nil
+}
+
+// IsZero reports whether the policy prunes nothing
+func (p RetentionPolicy) IsZero() bool {
+	return p.KeepLast <= 0 && p.MaxAge <= 0
+}
+
+// Expired returns the baselines the policy prunes at now
+func (p RetentionPolicy) Expired(baselines []Baseline, now time.Time) []Baseline {
+	protected := map[string]bool{}
+	for _, b := range p.KeepBranches {
+		protected[b] = true
+	}
+
+	// group by branch and workload, keeping the order of the baselines
+	groups := map[Key][]Baseline{}
+	var order []Key
+	for _, b := range baselines {
+		k := Key{Branch: b.Key.Branch, Workload: b.Key.Workload}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], b)
+	}
+
+	var expired []Baseline
+	for _, k := range order {
+		group := groups[k]
+		sortByCreated(group)
+		for i, b := range group {
+			newer := len(group) - 1 - i
+			if protected[k.Branch] && newer == 0 {
+				continue
+			}
+			if (p.KeepLast > 0 && newer >= p.KeepLast) || (p.MaxAge > 0 && now.Sub(b.Created) > p.MaxAge) {
+				expired = append(expired, b)
+			}
+		}
+	}
+	return expired
+}
+
+// Prune deletes the baselines of the store the policy prunes at now, and returns them
+func Prune(ctx context.Context, s Store, p RetentionPolicy, now time.Time) ([]Baseline, error) {
+	baselines, err := s.List(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	expired := p.Expired(baselines, now)
+	if err := s.Delete(ctx, expired...); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
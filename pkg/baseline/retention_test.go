@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    RetentionPolicy
+		wantErr bool
+	}{
+		{s: ""},
+		{s: "keep-last=10", want: RetentionPolicy{KeepLast: 10}},
+		{s: "keep-last=10, max-age=720h,keep-branch=main,keep-branch=release", want: RetentionPolicy{KeepLast: 10, MaxAge: 720 * time.Hour, KeepBranches: []string{"main", "release"}}},
+		{s: "keep-last=ten", wantErr: true},
+		{s: "max-age=30d", wantErr: true},
+		{s: "keep=10", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetentionPolicy(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRetentionPolicy(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRetentionPolicy(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRetentionPolicyExpired(t *testing.T) {
+	now := time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC)
+	baseline := func(branch string, age time.Duration) Baseline {
+		return Baseline{Key: Key{Branch: branch, Commit: age.String()}, Created: now.Add(-age)}
+	}
+	baselines := []Baseline{
+		baseline("main", 1000*time.Hour),
+		baseline("main", 100*time.Hour),
+		baseline("main", time.Hour),
+		baseline("feature", 1000*time.Hour),
+	}
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{name: "keep last", policy: RetentionPolicy{KeepLast: 2}, want: []string{"main/_all@1000h0m0s"}},
+		{name: "max age", policy: RetentionPolicy{MaxAge: 500 * time.Hour}, want: []string{"main/_all@1000h0m0s", "feature/_all@1000h0m0s"}},
+		{name: "protected branch", policy: RetentionPolicy{MaxAge: 500 * time.Hour, KeepBranches: []string{"feature"}}, want: []string{"main/_all@1000h0m0s"}},
+		{name: "nothing", policy: RetentionPolicy{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, b := range tt.policy.Expired(baselines, now) {
+				got = append(got, b.Key.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sources of a selected baseline, in the order they are tried
+const (
+	// SourceBaseRef is the latest baseline of the base branch of the pull request
+	SourceBaseRef = "base-ref"
+	// SourceMergeBase is the baseline of the merge-base commit of the pull request
+	SourceMergeBase = "merge-base"
+	// SourceDefaultBranch is the latest baseline of the default branch of the repository
+	SourceDefaultBranch = "default-branch"
+)
+
+// Context is the pull request context a baseline is selected from, the empty fields are skipped
+type Context struct {
+	// BaseRef is the base branch of the pull request, eg.: $GITHUB_BASE_REF
+	BaseRef string
+	// MergeBase is the merge-base commit of the pull request head and its base branch
+	MergeBase string
+	// DefaultBranch is the default branch of the repository, eg.: main
+	DefaultBranch string
+	// Workload is the workload of the baseline, all the workloads if empty
+	Workload string
+}
+
+// Selection is the baseline selected for a context
+type Selection struct {
+	Baseline Baseline
+	// Source is how the baseline was selected, eg.: SourceBaseRef
+	Source string
+	Data   []byte
+}
+
+// Select returns the latest baseline of the base branch, else the baseline of the merge-base
+// commit on any branch, else the latest baseline of the default branch. It returns ErrNotFound,
+// naming the candidates tried, if there is none: the caller bootstraps a first run.
+func Select(ctx context.Context, s Store, c Context) (*Selection, error) {
+	var tried []string
+
+	try := func(source string, key Key) (*Selection, error) {
+		tried = append(tried, fmt.Sprintf("%s %s", source, key))
+		data, b, err := s.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &Selection{Baseline: b, Source: source, Data: data}, nil
+	}
+
+	if c.BaseRef != "" {
+		if sel, err := try(SourceBaseRef, Key{Branch: c.BaseRef, Workload: c.Workload}); sel != nil || err != nil {
+			return sel, err
+		}
+	}
+	if c.MergeBase != "" {
+		// the merge-base commit may have been recorded on any branch, the base branch first
+		baselines, err := s.List(ctx, "", c.Workload)
+		if err != nil {
+			return nil, err
+		}
+		var found *Baseline
+		for i := range baselines {
+			b := &baselines[i]
+			if b.Key.Commit == c.MergeBase && (found == nil || b.Key.Branch == c.BaseRef || found.Key.Branch != c.BaseRef) {
+				found = b
+			}
+		}
+		key := Key{Branch: c.BaseRef, Workload: c.Workload, Commit: c.MergeBase}
+		if found != nil {
+			key = found.Key
+		}
+		if key.Branch != "" {
+			if sel, err := try(SourceMergeBase, key); sel != nil || err != nil {
+				return sel, err
+			}
+		} else {
+			tried = append(tried, fmt.Sprintf("%s %s", SourceMergeBase, c.MergeBase))
+		}
+	}
+	if c.DefaultBranch != "" && c.DefaultBranch != c.BaseRef {
+		if sel, err := try(SourceDefaultBranch, Key{Branch: c.DefaultBranch, Workload: c.Workload}); sel != nil || err != nil {
+			return sel, err
+		}
+	}
+	if len(tried) == 0 {
+		return nil, fmt.Errorf("%w: no base branch, merge-base or default branch", ErrNotFound)
+	}
+	return nil, fmt.Errorf("%w: tried %s", ErrNotFound, strings.Join(tried, ", "))
+}
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when no baseline matches a key
+var ErrNotFound = errors.New("baseline not found")
+
+// AllWorkloads is the path segment of the baselines of all the workloads of a summary
+const AllWorkloads = "_all"
+
+// timeLayout is the layout of the creation time in the baseline file names, with nanoseconds so
+// that the baselines stored in the same second have different names
+const timeLayout = "20060102T150405.000000000Z"
+
+// parseTimeLayout parses the creation times with and without fraction of seconds, the baselines
+// of the previous versions have none
+const parseTimeLayout = "20060102T150405Z"
+
+// Key identifies a baseline: the summary of a workload, or of all the workloads, at a commit of a branch
+type Key struct {
+	Branch string `json:"Branch"`
+	// Workload is eg.: namespace/deployment, all the workloads if empty
+	Workload string `json:"Workload,omitempty"`
+	// Commit is the commit the summary was collected at, the latest baseline if empty in Get
+	Commit string `json:"Commit,omitempty"`
+}
+
+// String returns the key as branch/workload@commit
+func (k Key) String() string {
+	s := k.Branch + "/" + k.workload()
+	if k.Commit != "" {
+		s += "@" + k.Commit
+	}
+	return s
+}
+
+// workload returns the workload path segment
+func (k Key) workload() string {
+	if k.Workload == "" {
+		return AllWorkloads
+	}
+	return k.Workload
+}
+
+// Validate checks that the key has a branch and that the commit is a plain name
+func (k Key) Validate() error {
+	if k.Branch == "" {
+		return fmt.Errorf("baseline key %s: branch is empty", k)
+	}
+	if k.Commit != "" && !commitName.MatchString(k.Commit) {
+		return fmt.Errorf("baseline key %s: invalid commit %q", k, k.Commit)
+	}
+	return nil
+}
+
+// commitName matches the commits, eg.: a SHA or a tag-like name
+var commitName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Baseline is a stored summary
+type Baseline struct {
+	Key Key `json:"Key"`
+	// Created is when the baseline was stored
+	Created time.Time `json:"Created"`
+	// Path is the path of the baseline in the store, branch/workload/created-commit.json
+	Path string `json:"Path"`
+}
+
+// pathOf returns the path of the baseline of the key created at t, the branch and the
+// workload are escaped so that they are single path segments
+func pathOf(k Key, t time.Time) string {
+	name := t.UTC().Format(timeLayout)
+	if k.Commit != "" {
+		name += "-" + k.Commit
+	}
+	return url.PathEscape(k.Branch) + "/" + url.PathEscape(k.workload()) + "/" + name + ".json"
+}
+
+// parsePath returns the baseline stored at the path, false if the path is not a baseline
+func parsePath(p string) (Baseline, bool) {
+	parts := strings.Split(p, "/")
+	if len(parts) != 3 || !strings.HasSuffix(parts[2], ".json") {
+		return Baseline{}, false
+	}
+	branch, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return Baseline{}, false
+	}
+	workload, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return Baseline{}, false
+	}
+	if workload == AllWorkloads {
+		workload = ""
+	}
+	name := strings.TrimSuffix(parts[2], ".json")
+	ts, commit := name, ""
+	if i := strings.Index(name, "-"); i >= 0 {
+		ts, commit = name[:i], name[i+1:]
+	}
+	created, err := time.Parse(parseTimeLayout, ts)
+	if err != nil {
+		return Baseline{}, false
+	}
+	return Baseline{Key: Key{Branch: branch, Workload: workload, Commit: commit}, Created: created, Path: p}, true
+}
+
+// Store stores baselines by branch, workload and commit
+type Store interface {
+	// Get returns the baseline of the key, the latest of the branch and workload if the key has no commit.
+	// It returns ErrNotFound if there is none.
+	Get(ctx context.Context, key Key) ([]byte, Baseline, error)
+	// Read returns the summary of a listed baseline
+	Read(ctx context.Context, b Baseline) ([]byte, error)
+	// Put stores the summary as the baseline of the key, created now
+	Put(ctx context.Context, key Key, data []byte) (Baseline, error)
+	// List returns the baselines of the branch and the workload, oldest first, any branch or workload if empty
+	List(ctx context.Context, branch, workload string) ([]Baseline, error)
+	// Delete removes the baselines
+	Delete(ctx context.Context, baselines ...Baseline) error
+}
+
+// Close releases the resources of the store, eg.: the working copy of a GitStore
+func Close(s Store) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// match returns the baseline of the key among the baselines of its branch and workload, the
+// latest one if the key has no commit
+func match(baselines []Baseline, key Key) (Baseline, error) {
+	for i := len(baselines) - 1; i >= 0; i-- {
+		b := baselines[i]
+		if key.Commit == "" || b.Key.Commit == key.Commit {
+			return b, nil
+		}
+	}
+	return Baseline{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+}
+
+// filterPaths returns the baselines stored at the paths of the branch and the workload, oldest first
+func filterPaths(paths []string, branch, workload string) []Baseline {
+	var baselines []Baseline
+	for _, p := range paths {
+		b, ok := parsePath(path.Clean(p))
+		if !ok {
+			continue
+		}
+		if (branch != "" && b.Key.Branch != branch) || (workload != "" && b.Key.Workload != workload) {
+			continue
+		}
+		baselines = append(baselines, b)
+	}
+	sortByCreated(baselines)
+	return baselines
+}
+
+// sortByCreated sorts the baselines oldest first
+func sortByCreated(baselines []Baseline) {
+	sort.SliceStable(baselines, func(i, j int) bool { return baselines[i].Created.Before(baselines[j].Created) })
+}
+
+// Open opens the store of a spec:
+//   - dir:<path> or a path, a local directory
+//   - git:<repository>[#branch], a branch of a local or remote git repository, kubearmor-baselines by default
+//   - http(s)://<url>, an HTTP server accepting GET, PUT and DELETE
+//
+// The token, if set, authenticates to the git remote or the HTTP server.
+func Open(spec, token string) (Store, error) {
+	switch {
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		return NewHTTPStore(spec, token), nil
+	case strings.HasPrefix(spec, "git:"):
+		repo, branch := strings.TrimPrefix(spec, "git:"), ""
+		if i := strings.LastIndex(repo, "#"); i >= 0 {
+			repo, branch = repo[:i], repo[i+1:]
+		}
+		s := NewGitStore(repo, branch)
+		s.Token = token
+		return s, nil
+	case strings.HasPrefix(spec, "dir:"):
+		return NewDirStore(strings.TrimPrefix(spec, "dir:")), nil
+	case spec == "":
+		return nil, fmt.Errorf("baseline store is empty")
+	}
+	return NewDirStore(spec), nil
+}
+
+// GitHubToken returns the GitHub token for the store of a spec if the store is a git repository of
+// the GitHub server, eg.: https://github.com, and an empty string otherwise. The token of the
+// repository of a workflow is never sent to the HTTP stores and the remotes of other servers.
+func GitHubToken(spec, serverURL, token string) string {
+	if !strings.HasPrefix(spec, "git:") || serverURL == "" {
+		return ""
+	}
+	repo, err := url.Parse(strings.TrimPrefix(spec, "git:"))
+	if err != nil || repo.Scheme != "https" {
+		return ""
+	}
+	server, err := url.Parse(serverURL)
+	if err != nil || !strings.EqualFold(repo.Host, server.Host) {
+		return ""
+	}
+	return token
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package baseline
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestGitHubToken(t *testing.T) {
+	tests := []struct {
+		spec   string
+		server string
+		want   string
+	}{
+		{spec: "git:https://github.com/owner/repo", server: "https://github.com", want: "token"},
+		{spec: "git:https://GitHub.com/owner/repo#baselines", server: "https://github.com", want: "token"},
+		{spec: "git:https://ghe.example.com/owner/repo", server: "https://ghe.example.com", want: "token"},
+		{spec: "git:https://gitlab.com/owner/repo", server: "https://github.com"},
+		{spec: "git:http://github.com/owner/repo", server: "https://github.com"},
+		{spec: "git:/tmp/baselines", server: "https://github.com"},
+		{spec: "https://github.com/owner/repo", server: "https://github.com"},
+		{spec: "https://baselines.example.com", server: "https://github.com"},
+		{spec: "dir:baselines", server: "https://github.com"},
+		{spec: "git:https://github.com/owner/repo"},
+	}
+	for _, tt := range tests {
+		if got := GitHubToken(tt.spec, tt.server, "token"); got != tt.want {
+			t.Errorf("GitHubToken(%q, %q) = %q, want %q", tt.spec, tt.server, got, tt.want)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    Key
+		created string
+		ok      bool
+	}{
+		{path: "main/_all/20230703T082640Z-abc123.json", want: Key{Branch: "main", Commit: "abc123"}, created: "2023-07-03T08:26:40Z", ok: true},
+		{path: "main/sock-shop%2Fcarts/20230703T082640.123456789Z.json", want: Key{Branch: "main", Workload: "sock-shop/carts"}, created: "2023-07-03T08:26:40.123456789Z", ok: true},
+		{path: "feature%2Fx/_all/20230703T082640.000000001Z-abc.json", want: Key{Branch: "feature/x", Commit: "abc"}, created: "2023-07-03T08:26:40.000000001Z", ok: true},
+		{path: "main/_all/latest.json"},
+		{path: "main/20230703T082640Z.json"},
+		{path: "index.json"},
+	}
+	for _, tt := range tests {
+		b, ok := parsePath(tt.path)
+		if ok != tt.ok {
+			t.Errorf("parsePath(%q) ok = %v, want %v", tt.path, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339Nano, tt.created)
+		if b.Key != tt.want || !b.Created.Equal(created) {
+			t.Errorf("parsePath(%q) = %s at %s, want %s at %s", tt.path, b.Key, b.Created, tt.want, created)
+		}
+		// the paths are written with nanoseconds
+		rb, ok := parsePath(pathOf(b.Key, b.Created))
+		if !ok || rb.Key != b.Key || !rb.Created.Equal(b.Created) {
+			t.Errorf("parsePath(pathOf(%s, %s)) = %s at %s", b.Key, b.Created, rb.Key, rb.Created)
+		}
+	}
+}
+
+func TestDirStoreSameSecond(t *testing.T) {
+	s := NewDirStore(t.TempDir())
+	ctx := context.Background()
+	key := Key{Branch: "main", Commit: "abc"}
+	for _, data := range []string{"first", "second"} {
+		if _, err := s.Put(ctx, key, []byte(data)); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	baselines, err := s.List(ctx, "main", "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(baselines) != 2 {
+		t.Fatalf("List() = %d baselines, want 2", len(baselines))
+	}
+	data, _, err := s.Get(ctx, Key{Branch: "main"})
+	if err != nil || string(data) != "second" {
+		t.Errorf("Get() = %q, %v, want the latest baseline", data, err)
+	}
+}
+
+func TestGitStore(t *testing.T) {
+	remote := t.TempDir()
+	if _, err := git.PlainInit(remote, true); err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	ctx := context.Background()
+	s := NewGitStore(remote, "")
+	if _, err := s.Put(ctx, Key{Branch: "main", Commit: "abc"}, []byte("first")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	dir := s.tempDir
+	if err := Close(s); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("the working copy %s is not removed", dir)
+	}
+
+	// a new clone sees the pushed baseline
+	s = NewGitStore(remote, "")
+	defer Close(s)
+	if _, err := s.Put(ctx, Key{Branch: "main", Commit: "def"}, []byte("second")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	baselines, err := s.List(ctx, "main", "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(baselines) != 2 || baselines[0].Key.Commit != "abc" || baselines[1].Key.Commit != "def" {
+		t.Errorf("List() = %+v, want the baselines abc and def", baselines)
+	}
+
+	// another clone pushes first, the put is applied on top of its commit
+	other := NewGitStore(remote, "")
+	defer Close(other)
+	if _, err := other.List(ctx, "main", ""); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if _, err := s.Put(ctx, Key{Branch: "dev", Commit: "123"}, []byte("third")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := other.Delete(ctx, baselines[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	last := NewGitStore(remote, "")
+	defer Close(last)
+	var keys []string
+	for _, branch := range []string{"main", "dev"} {
+		baselines, err := last.List(ctx, branch, "")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		for _, b := range baselines {
+			keys = append(keys, b.Key.Branch+"@"+b.Key.Commit)
+		}
+	}
+	if strings.Join(keys, ",") != "main@def,dev@123" {
+		t.Errorf("List() = %v, want main@def and dev@123", keys)
+	}
+}
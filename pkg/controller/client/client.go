@@ -38,6 +38,32 @@ type Client struct {
 	ClientSet *kubernetes.Clientset
 	// DynamicClient is a dynamic client.
 	DynamicClient *dynamic.DynamicClient
+	// Grouper groups the verbose output, eg.: the events and logs of a not ready pod, if set.
+	Grouper Grouper
+}
+
+// Grouper groups output lines into collapsible regions, eg.: a GitHub Actions log group.
+type Grouper interface {
+	// Group starts a region with the title.
+	Group(title string)
+	// EndGroup ends the region.
+	EndGroup()
+}
+
+// PodReadiness is the readiness of a pod.
+type PodReadiness struct {
+	// Namespace of the pod.
+	Namespace string
+	// Name of the pod.
+	Name string
+	// Phase of the pod, eg.: Running.
+	Phase string
+	// Ready is true if the pod is ready.
+	Ready bool
+	// Restarts is the number of restarts of its containers.
+	Restarts int32
+	// Reason is why a container is waiting or terminated, eg.: CrashLoopBackOff.
+	Reason string
 }
 
 // NamespacePod is a namespace and its pods.
@@ -255,6 +281,9 @@ func (c *Client) OutputNotReadyPodInfo() error {
 		return err
 	}
 	for podName, events := range podEvents {
+		if c.Grouper != nil {
+			c.Grouper.Group("Pod " + podName + " is not ready")
+		}
 		fmt.Println("=========================================================================================================================================")
 		fmt.Println("PodName: " + podName)
 		fmt.Println("******************************************************Events*****************************************************************************")
@@ -275,10 +304,46 @@ func (c *Client) OutputNotReadyPodInfo() error {
 		fmt.Println("********************************************************Log*****************************************************************************")
 		fmt.Println(log)
 		fmt.Println("=========================================================================================================================================")
+		if c.Grouper != nil {
+			c.Grouper.EndGroup()
+		}
 	}
 	return nil
 }
 
+// ListPodReadiness returns the readiness of the pods of all namespaces.
+func (c *Client) ListPodReadiness() ([]PodReadiness, error) {
+	namespacePodList, err := c.ListAllNamespacesPods()
+	if err != nil {
+		return nil, err
+	}
+	var pods []PodReadiness
+	for _, namespacePod := range namespacePodList {
+		if namespacePod.PodList == nil {
+			continue
+		}
+		for _, pod := range namespacePod.PodList.Items {
+			readiness := PodReadiness{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Phase:     string(pod.Status.Phase),
+				Ready:     c.getPodReadyStatus(pod),
+				Reason:    pod.Status.Reason,
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				readiness.Restarts += status.RestartCount
+				if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+					readiness.Reason = status.State.Waiting.Reason
+				} else if status.State.Terminated != nil && status.State.Terminated.Reason != "" && readiness.Reason == "" {
+					readiness.Reason = status.State.Terminated.Reason
+				}
+			}
+			pods = append(pods, readiness)
+		}
+	}
+	return pods, nil
+}
+
 // WaitAllPodRunning waits for all pods to be ready
 func (c *Client) WaitAllPodRunning() error {
 	time.Sleep(30 * time.Second)
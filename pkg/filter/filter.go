@@ -0,0 +1,312 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Workload is the identity of a workload as seen in a karmor summary
+type Workload struct {
+	Namespace  string
+	Deployment string
+	Pod        string
+	Container  string
+	// Labels are the labels in karmor format, eg.: "app=mysql,tier=db"
+	Labels string
+}
+
+// Options are the raw filter options, as given on the command line
+type Options struct {
+	// App matches the app label (app or app.kubernetes.io/name) or the deployment name exactly
+	App string
+	// Selector is a Kubernetes label selector, eg.: "app in (mysql,wordpress),tier!=cache"
+	Selector string
+	// Namespaces, Deployments and Containers are lists of exact names
+	Namespaces  []string
+	Deployments []string
+	Containers  []string
+	// PodRegex, ProcessRegex and FileRegex are regular expressions
+	PodRegex     string
+	ProcessRegex string
+	FileRegex    string
+	// Excludes are glob patterns of pods, processes or files to leave out, "**" matches across "/"
+	Excludes []string
+	// Statuses are the policy outcomes to keep: Allow, Audit or Block, case insensitive
+	Statuses []string
+}
+
+// Filter selects workloads and behaviors, the zero value (or nil) matches everything.
+// It is shared by every visualisation so that all the views select the same behaviors.
+type Filter struct {
+	app          string
+	selector     labels.Selector
+	namespaces   map[string]bool
+	deployments  []string
+	containers   map[string]bool
+	podRegex     *regexp.Regexp
+	processRegex *regexp.Regexp
+	fileRegex    *regexp.Regexp
+	excludes     []*regexp.Regexp
+	statuses     map[string]bool
+}
+
+// New compiles the options into a Filter
+func New(opts Options) (*Filter, error) {
+	f := &Filter{
+		app:         opts.App,
+		deployments: opts.Deployments,
+	}
+	var err error
+	if opts.Selector != "" {
+		f.selector, err = labels.Parse(opts.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", opts.Selector, err)
+		}
+	}
+	f.namespaces = ToSet(opts.Namespaces)
+	f.containers = ToSet(opts.Containers)
+	if f.podRegex, err = compile("pod", opts.PodRegex); err != nil {
+		return nil, err
+	}
+	if f.processRegex, err = compile("process", opts.ProcessRegex); err != nil {
+		return nil, err
+	}
+	if f.fileRegex, err = compile("file", opts.FileRegex); err != nil {
+		return nil, err
+	}
+	for _, glob := range opts.Excludes {
+		re, err := GlobToRegexp(glob)
+		if err != nil {
+			return nil, err
+		}
+		f.excludes = append(f.excludes, re)
+	}
+	for _, status := range opts.Statuses {
+		s := strings.ToLower(strings.TrimSpace(status))
+		if s != "allow" && s != "audit" && s != "block" {
+			return nil, fmt.Errorf("invalid status %q: must be Allow, Audit or Block", status)
+		}
+		if f.statuses == nil {
+			f.statuses = make(map[string]bool)
+		}
+		f.statuses[s] = true
+	}
+	return f, nil
+}
+
+// App returns the app name the filter selects, if any
+func (f *Filter) App() string {
+	if f == nil {
+		return ""
+	}
+	return f.app
+}
+
+// IsEmpty reports whether the filter matches everything
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (f.app == "" && f.selector == nil && len(f.namespaces) == 0 && len(f.deployments) == 0 &&
+		len(f.containers) == 0 && f.podRegex == nil && f.processRegex == nil && f.fileRegex == nil && len(f.excludes) == 0 &&
+		len(f.statuses) == 0)
+}
+
+// MatchWorkload reports whether the workload is selected.
+// A criterion that is set but can not be evaluated, eg.: a deployment name for a
+// connection peer, does not match.
+func (f *Filter) MatchWorkload(w Workload) bool {
+	if f == nil {
+		return true
+	}
+	set := ParseLabels(w.Labels)
+	if f.app != "" {
+		if set["app"] != f.app && set["app.kubernetes.io/name"] != f.app && !MatchDeployment(w.Deployment, f.app) {
+			return false
+		}
+	}
+	if f.selector != nil && !f.selector.Matches(set) {
+		return false
+	}
+	if len(f.namespaces) > 0 && !f.namespaces[w.Namespace] {
+		return false
+	}
+	if len(f.deployments) > 0 {
+		found := false
+		for _, d := range f.deployments {
+			if MatchDeployment(w.Deployment, d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.containers) > 0 && !f.containers[w.Container] {
+		return false
+	}
+	if f.podRegex != nil && !f.podRegex.MatchString(w.Pod) {
+		return false
+	}
+	return w.Pod == "" || !f.excluded(w.Pod)
+}
+
+// MatchProcess reports whether the process (a binary path) is selected
+func (f *Filter) MatchProcess(process string) bool {
+	if f == nil {
+		return true
+	}
+	if f.processRegex != nil && !f.processRegex.MatchString(process) {
+		return false
+	}
+	return !f.excluded(process)
+}
+
+// MatchFile reports whether the file path is selected
+func (f *Filter) MatchFile(file string) bool {
+	if f == nil {
+		return true
+	}
+	if f.fileRegex != nil && !f.fileRegex.MatchString(file) {
+		return false
+	}
+	return !f.excluded(file)
+}
+
+// MatchExec reports whether a process execution, from the parent binary to the child binary
+// or path, is selected: the process regex must match one of them and none may be excluded
+func (f *Filter) MatchExec(parent, child string) bool {
+	if f == nil {
+		return true
+	}
+	if f.processRegex != nil && !f.processRegex.MatchString(parent) && !f.processRegex.MatchString(child) {
+		return false
+	}
+	return !f.excluded(parent) && !f.excluded(child)
+}
+
+// MatchFileAccess reports whether a file access by a process is selected
+func (f *Filter) MatchFileAccess(process, file string) bool {
+	return f.MatchProcess(process) && f.MatchFile(file)
+}
+
+// MatchStatus reports whether the policy outcome (Allow, Audit or Block) is selected,
+// an empty status is Allow
+func (f *Filter) MatchStatus(status string) bool {
+	if f == nil || len(f.statuses) == 0 {
+		return true
+	}
+	s := strings.ToLower(strings.TrimSpace(status))
+	if s == "" {
+		s = "allow"
+	}
+	return f.statuses[s]
+}
+
+// excluded reports whether s matches one of the exclusion globs
+func (f *Filter) excluded(s string) bool {
+	for _, re := range f.excludes {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLabels parses labels in karmor format, pairs are separated by commas, semicolons or spaces
+func ParseLabels(s string) labels.Set {
+	set := labels.Set{}
+	for _, pair := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	}) {
+		k, v, _ := strings.Cut(pair, "=")
+		set[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return set
+}
+
+// podTemplateHashChars are the characters of the pod-template-hash Kubernetes appends to replica set names
+const podTemplateHashChars = "bcdfghjklmnpqrstvwxz2456789"
+
+// MatchDeployment reports whether a karmor deployment name, which may carry the
+// pod-template-hash suffix (eg.: mysql-5df4cd65d5), is the deployment want
+func MatchDeployment(name, want string) bool {
+	if name == "" || want == "" {
+		return false
+	}
+	if name == want {
+		return true
+	}
+	hash := strings.TrimPrefix(name, want+"-")
+	if hash == name || len(hash) < 5 || len(hash) > 10 {
+		return false
+	}
+	return strings.Trim(hash, podTemplateHashChars) == ""
+}
+
+// DeploymentName returns a karmor deployment name without its pod-template-hash suffix,
+// eg.: mysql-5df4cd65d5 gives mysql
+func DeploymentName(name string) string {
+	i := strings.LastIndexByte(name, '-')
+	if i <= 0 || !MatchDeployment(name, name[:i]) {
+		return name
+	}
+	return name[:i]
+}
+
+// GlobToRegexp converts a glob pattern into an anchored regular expression.
+// "*" and "?" do not match "/", "**" matches anything.
+func GlobToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+	return re, nil
+}
+
+// compile compiles an optional regular expression
+func compile(kind, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s regex %q: %w", kind, expr, err)
+	}
+	return re, nil
+}
+
+// ToSet converts a list of names into a set, nil if the list is empty
+func ToSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
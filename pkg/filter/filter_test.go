@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package filter
+
+import "testing"
+
+func TestMatchWorkload(t *testing.T) {
+	mysql := Workload{Namespace: "wordpress-mysql", Deployment: "mysql-5df4cd65d5", Pod: "mysql-5df4cd65d5-x8kqz", Container: "mysql", Labels: "app=mysql,tier=db"}
+	wordpress := Workload{Namespace: "wordpress-mysql", Deployment: "wordpress", Pod: "wordpress-7b5c9d6f8d-fghjk", Container: "php", Labels: "app.kubernetes.io/name=wordpress"}
+	tests := []struct {
+		name string
+		opts Options
+		want map[string]bool
+	}{
+		{name: "empty", want: map[string]bool{"mysql": true, "wordpress": true}},
+		{name: "app label", opts: Options{App: "mysql"}, want: map[string]bool{"mysql": true}},
+		{name: "app name label", opts: Options{App: "wordpress"}, want: map[string]bool{"wordpress": true}},
+		{name: "app prefix", opts: Options{App: "my"}, want: map[string]bool{}},
+		{name: "selector", opts: Options{Selector: "app in (mysql,wordpress),tier!=cache"}, want: map[string]bool{"mysql": true}},
+		{name: "namespace", opts: Options{Namespaces: []string{"wordpress-mysql"}}, want: map[string]bool{"mysql": true, "wordpress": true}},
+		{name: "other namespace", opts: Options{Namespaces: []string{"sock-shop"}}, want: map[string]bool{}},
+		{name: "deployment with hash", opts: Options{Deployments: []string{"mysql"}}, want: map[string]bool{"mysql": true}},
+		{name: "container", opts: Options{Containers: []string{"php"}}, want: map[string]bool{"wordpress": true}},
+		{name: "pod regex", opts: Options{PodRegex: "^word"}, want: map[string]bool{"wordpress": true}},
+		{name: "exclude pod", opts: Options{Excludes: []string{"mysql-*"}}, want: map[string]bool{"wordpress": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for name, w := range map[string]Workload{"mysql": mysql, "wordpress": wordpress} {
+				if got := f.MatchWorkload(w); got != tt.want[name] {
+					t.Errorf("MatchWorkload(%s) = %v, want %v", name, got, tt.want[name])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchBehaviors(t *testing.T) {
+	f, err := New(Options{ProcessRegex: "^/usr/sbin/", FileRegex: "^/etc/", Excludes: []string{"/etc/ssl/**", "/usr/sbin/cron"}, Statuses: []string{"Block", " audit "}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{name: "process", got: f.MatchProcess("/usr/sbin/mysqld"), want: true},
+		{name: "process regex", got: f.MatchProcess("/bin/sh")},
+		{name: "excluded process", got: f.MatchProcess("/usr/sbin/cron")},
+		{name: "file", got: f.MatchFile("/etc/passwd"), want: true},
+		{name: "excluded file", got: f.MatchFile("/etc/ssl/certs/ca.pem")},
+		{name: "file access", got: f.MatchFileAccess("/usr/sbin/mysqld", "/etc/hosts"), want: true},
+		{name: "file access by another process", got: f.MatchFileAccess("/bin/sh", "/etc/hosts")},
+		{name: "exec child", got: f.MatchExec("/bin/sh", "/usr/sbin/mysqld"), want: true},
+		{name: "exec excluded child", got: f.MatchExec("/usr/sbin/mysqld", "/usr/sbin/cron")},
+		{name: "status", got: f.MatchStatus("Block"), want: true},
+		{name: "status case", got: f.MatchStatus("AUDIT"), want: true},
+		{name: "status allow", got: f.MatchStatus("Allow")},
+		{name: "empty status", got: f.MatchStatus("")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	var nilFilter *Filter
+	if !nilFilter.IsEmpty() || !nilFilter.MatchWorkload(Workload{}) || !nilFilter.MatchProcess("/bin/sh") || !nilFilter.MatchStatus("Block") {
+		t.Errorf("a nil filter does not match everything")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, opts := range []Options{
+		{Selector: "app in (mysql"},
+		{PodRegex: "("},
+		{ProcessRegex: "["},
+		{FileRegex: "*"},
+		{Statuses: []string{"Deny"}},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) error = nil, want an error", opts)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		value string
+		want  bool
+	}{
+		{glob: "/usr/share/zoneinfo/**", value: "/usr/share/zoneinfo/Europe/Paris", want: true},
+		{glob: "/usr/share/zoneinfo/*", value: "/usr/share/zoneinfo/Europe/Paris"},
+		{glob: "/usr/share/zoneinfo/*", value: "/usr/share/zoneinfo/UTC", want: true},
+		{glob: "/tmp/php??????", value: "/tmp/php8Hk2lQ", want: true},
+		{glob: "/tmp/php??????", value: "/tmp/php8Hk2l"},
+		{glob: "/etc/*.conf", value: "/etc/nginx.conf", want: true},
+		{glob: "/etc/*.conf", value: "/etc/nginxconf"},
+		{glob: "mysql-*", value: "mysql-5df4cd65d5-x8kqz", want: true},
+	}
+	for _, tt := range tests {
+		re, err := GlobToRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("GlobToRegexp(%q) error = %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.value); got != tt.want {
+			t.Errorf("GlobToRegexp(%q).MatchString(%q) = %v, want %v", tt.glob, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDeploymentName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "mysql-5df4cd65d5", want: "mysql"},
+		{name: "wordpress-mysql-7b5c9d6f8d", want: "wordpress-mysql"},
+		{name: "wordpress-mysql", want: "wordpress-mysql"},
+		{name: "carts-abcde", want: "carts-abcde"},
+		{name: "carts", want: "carts"},
+	}
+	for _, tt := range tests {
+		if got := DeploymentName(tt.name); got != tt.want {
+			t.Errorf("DeploymentName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	got := ParseLabels("app=mysql,tier=db;team=data canary")
+	if len(got) != 4 || got["app"] != "mysql" || got["tier"] != "db" || got["team"] != "data" || !got.Has("canary") {
+		t.Errorf("ParseLabels() = %v", got)
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// DefaultMaxAnnotations is the number of annotations emitted for a diff,
+// GitHub shows at most 10 errors and 10 warnings per step anyway
+const DefaultMaxAnnotations = 50
+
+// AnnotationOptions configures the annotations of a diff report
+type AnnotationOptions struct {
+	// Manifests locates the manifests declaring the workloads, the annotations point at them
+	Manifests *visual.ManifestIndex
+	// Fallback is the file the annotations of workloads without manifest point at, eg.: the summary
+	Fallback string
+	// Max is the number of annotations, the riskiest behaviors first, DefaultMaxAnnotations if 0
+	Max int
+}
+
+// annotation is a new behavior of a workload, with its findings
+type annotation struct {
+	workload visual.DiffWorkload
+	behavior visual.Behavior
+	findings []visual.Finding
+}
+
+// level returns error if a finding of the behavior is high or critical, warning otherwise
+func (a annotation) level() string {
+	for _, finding := range a.findings {
+		if finding.Severity.Rank() >= visual.SeverityHigh.Rank() {
+			return "error"
+		}
+	}
+	return "warning"
+}
+
+// AnnotateDiff emits a ::warning or ::error annotation per behavior added in the report, the
+// riskiest first. An annotation is an error if a finding of the behavior is high or critical.
+// It returns the number of annotations emitted.
+func AnnotateDiff(action *githubactions.Action, report *visual.DiffReport, opts AnnotationOptions) int {
+	if opts.Max <= 0 {
+		opts.Max = DefaultMaxAnnotations
+	}
+	var annotations []annotation
+	for _, wd := range report.Workloads {
+		for _, b := range wd.Added {
+			a := annotation{workload: wd.Workload, behavior: b}
+			for _, finding := range wd.Findings {
+				if finding.New && finding.Behavior.Kind == b.Kind && finding.Behavior.String() == b.String() {
+					a.findings = append(a.findings, finding)
+				}
+			}
+			annotations = append(annotations, a)
+		}
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		if annotations[i].level() != annotations[j].level() {
+			return annotations[i].level() == "error"
+		}
+		return annotations[i].behavior.Risk > annotations[j].behavior.Risk
+	})
+
+	emitted := 0
+	for _, a := range annotations {
+		if emitted == opts.Max {
+			action.Noticef("%d more new behaviors are not annotated, see the diff report", len(annotations)-emitted)
+			break
+		}
+		fields := map[string]string{"title": fmt.Sprintf("New %s behavior in %s", a.behavior.Kind, a.workload)}
+		if len(a.findings) > 0 {
+			fields["title"] = fmt.Sprintf("%s in %s", a.findings[0].Title, a.workload)
+		}
+		if loc, ok := opts.Manifests.Locate(a.workload); ok {
+			fields["file"] = loc.Path
+			fields["line"] = strconv.Itoa(loc.Line)
+		} else if opts.Fallback != "" {
+			fields["file"] = visual.RelativePath(opts.Fallback)
+		}
+
+		msg := fmt.Sprintf("%s (%s), risk %d", a.behavior, a.behavior.Status, a.behavior.Risk)
+		if len(a.behavior.RiskFactors) > 0 {
+			msg += ": " + strings.Join(a.behavior.RiskFactors, ", ")
+		}
+		for _, finding := range a.findings {
+			msg += "\n" + finding.String()
+		}
+		if a.level() == "error" {
+			action.WithFieldsMap(fields).Errorf("%s", msg)
+		} else {
+			action.WithFieldsMap(fields).Warningf("%s", msg)
+		}
+		emitted++
+	}
+	return emitted
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// testAction returns an action reading the environment variables from env and writing its
+// commands to a buffer
+func testAction(env map[string]string) (*githubactions.Action, *bytes.Buffer) {
+	var buf bytes.Buffer
+	getenv := func(k string) string { return env[k] }
+	return githubactions.New(githubactions.WithWriter(&buf), githubactions.WithGetenv(getenv)), &buf
+}
+
+// annotateReport returns a report of two workloads: web with a new high finding, an old finding
+// and two added behaviors without finding, and api with an added behavior
+func annotateReport() *visual.DiffReport {
+	web := visual.DiffWorkload{Namespace: "default", Deployment: "web", Container: "web"}
+	api := visual.DiffWorkload{Namespace: "other", Deployment: "api", Container: "api"}
+	token := visual.Behavior{Kind: visual.KindFile, Source: "/bin/sh", Destination: "/run/secrets/kubernetes.io/serviceaccount/token",
+		Status: visual.StatusAllow, Risk: 45}
+	curl := visual.Behavior{Kind: visual.KindProcess, Source: "/bin/sh", Destination: "/usr/bin/curl", Status: visual.StatusAllow, Risk: 25}
+	egress := visual.Behavior{Kind: visual.KindEgress, Source: "/usr/bin/node", Destination: "8.8.8.8", Protocol: "TCP", Port: "53",
+		Status: visual.StatusAllow, Risk: 40, RiskFactors: []string{"egress-external +40"}}
+	ls := visual.Behavior{Kind: visual.KindProcess, Source: "/bin/sh", Destination: "/bin/ls", Status: visual.StatusBlock, Risk: 7}
+	return &visual.DiffReport{
+		Workloads: []*visual.WorkloadDiff{
+			{
+				Workload: web,
+				Added:    []visual.Behavior{curl, egress, token},
+				Findings: []visual.Finding{
+					{RuleID: "KA-FILE-002", Title: "Read of the service account token", Severity: visual.SeverityHigh, Behavior: token, New: true},
+					// an old finding does not make the added behavior an error
+					{RuleID: "KA-PROC-004", Title: "Network tool executed", Severity: visual.SeverityHigh, Behavior: curl},
+				},
+			},
+			{Workload: api, Added: []visual.Behavior{ls}},
+		},
+	}
+}
+
+func TestAnnotateDiff(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "web.yaml")
+	err := os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: default\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := visual.IndexManifests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.ToSlash(manifest)
+
+	tests := []struct {
+		name    string
+		opts    AnnotationOptions
+		emitted int
+		want    []string
+	}{
+		{
+			name:    "riskiest first",
+			emitted: 4,
+			want: []string{
+				"::error title=Read of the service account token in default/web/web::/bin/sh -> /run/secrets/kubernetes.io/serviceaccount/token (Allow), risk 45%0Ahigh KA-FILE-002 Read of the service account token: /bin/sh -> /run/secrets/kubernetes.io/serviceaccount/token",
+				"::warning title=New egress behavior in default/web/web::/usr/bin/node -> 8.8.8.8 (TCP/53) (Allow), risk 40: egress-external +40",
+				"::warning title=New process behavior in default/web/web::/bin/sh -> /usr/bin/curl (Allow), risk 25",
+				"::warning title=New process behavior in other/api/api::/bin/sh -> /bin/ls (Block), risk 7",
+			},
+		},
+		{
+			name:    "max",
+			opts:    AnnotationOptions{Max: 1},
+			emitted: 1,
+			want: []string{
+				"::error title=Read of the service account token in default/web/web::/bin/sh -> /run/secrets/kubernetes.io/serviceaccount/token (Allow), risk 45%0Ahigh KA-FILE-002 Read of the service account token: /bin/sh -> /run/secrets/kubernetes.io/serviceaccount/token",
+				"::notice::3 more new behaviors are not annotated, see the diff report",
+			},
+		},
+		{
+			name:    "manifests and fallback",
+			opts:    AnnotationOptions{Manifests: manifests, Fallback: "summary.json", Max: 2},
+			emitted: 2,
+			want: []string{
+				"::error file=" + file + ",line=4,title=Read of the service account token in default/web/web::/bin/sh -> /run/secrets/kubernetes.io/serviceaccount/token (Allow), risk 45%0Ahigh KA-FILE-002 Read of the service account token: /bin/sh -> /run/secrets/kubernetes.io/serviceaccount/token",
+				"::warning file=" + file + ",line=4,title=New egress behavior in default/web/web::/usr/bin/node -> 8.8.8.8 (TCP/53) (Allow), risk 40: egress-external +40",
+				"::notice::2 more new behaviors are not annotated, see the diff report",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, buf := testAction(nil)
+			if got := AnnotateDiff(action, annotateReport(), tt.opts); got != tt.emitted {
+				t.Errorf("AnnotateDiff() = %d, want %d", got, tt.emitted)
+			}
+			got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("AnnotateDiff() wrote\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestAnnotateDiffFallback(t *testing.T) {
+	action, buf := testAction(nil)
+	report := annotateReport()
+	report.Workloads = report.Workloads[1:]
+	AnnotateDiff(action, report, AnnotationOptions{Fallback: "summary.json"})
+	if want := "::warning file=summary.json,title=New process behavior in other/api/api::"; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("AnnotateDiff() wrote %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// DefaultAPIURL is the URL of the GitHub REST API
+const DefaultAPIURL = "https://api.github.com"
+
+// Defaults of the rate limit handling
+const (
+	// DefaultMaxRetries is the number of times a rate limited request is retried
+	DefaultMaxRetries = 3
+	// DefaultMaxWait is the longest wait for a rate limit reset, longer waits fail the request
+	DefaultMaxWait = 2 * time.Minute
+)
+
+// APIError is returned when the GitHub API responds with an error status code
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Message is the message of the response body, if any
+	Message string
+	// RateLimited is set when the request was rejected by a rate limit
+	RateLimited bool
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: server reported %d", e.Method, e.URL, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Client is a minimal GitHub REST API client
+type Client struct {
+	// BaseURL is the API URL, eg.: $GITHUB_API_URL, or the URL of a stand-in server in tests
+	BaseURL string
+	// Token authenticates the requests, eg.: $GITHUB_TOKEN
+	Token string
+	// HTTPClient sends the requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// MaxRetries is the number of times a rate limited or failed (5xx) request is retried, failed
+	// requests only if their method is idempotent
+	MaxRetries int
+	// MaxWait is the longest wait for a rate limit reset, DefaultMaxWait if 0
+	MaxWait time.Duration
+	// Login is the login of the authenticated user, looked up on the first comment search if empty
+	Login string
+
+	// sleep waits between retries, time.Sleep if nil, replaced in tests
+	sleep func(time.Duration)
+}
+
+// NewClient returns a client of the API at baseURL, DefaultAPIURL if empty
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: DefaultMaxRetries,
+		MaxWait:    DefaultMaxWait,
+		sleep:      time.Sleep,
+	}
+}
+
+// do sends a request to the API path, or to the absolute URL of a next page, and decodes
+// the JSON response into out. It returns the URL of the next page, if any.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) (string, error) {
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = c.BaseURL + path
+	}
+	httpClient, sleep, maxWait := c.HTTPClient, c.sleep, c.MaxWait
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	if maxWait <= 0 {
+		maxWait = DefaultMaxWait
+	}
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.Token != "" && sameHost(u, c.BaseURL) {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", err
+		}
+
+		if resp.StatusCode < 300 {
+			if out != nil && len(data) > 0 {
+				if err := json.Unmarshal(data, out); err != nil {
+					return "", fmt.Errorf("decoding %s %s: %w", method, u, err)
+				}
+			}
+			return nextPage(resp.Header.Get("Link")), nil
+		}
+
+		apiErr := &APIError{Method: method, URL: u, StatusCode: resp.StatusCode, RateLimited: rateLimited(resp)}
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &msg) == nil {
+			apiErr.Message = msg.Message
+		}
+		wait, retry := retryAfter(method, resp)
+		if !retry || attempt >= c.MaxRetries {
+			return "", apiErr
+		}
+		if wait > maxWait {
+			return "", fmt.Errorf("rate limited until %s: %w", time.Now().Add(wait).Format(time.RFC3339), apiErr)
+		}
+		klog.Infof("%v, retrying in %s", apiErr, wait)
+		sleep(wait)
+	}
+}
+
+// idempotentMethods are the methods whose requests can be sent again after a server error, the
+// client only uses PATCH to replace the body of a comment, which is idempotent as well
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+	http.MethodPatch:  true,
+}
+
+// retryAfter returns how long to wait before retrying a failed request, and whether to retry it:
+// rate limited requests (429, or 403 with no remaining requests or a Retry-After) are retried
+// whatever their method after Retry-After or at X-RateLimit-Reset, they had no effect; server
+// errors are retried after Retry-After or a backoff only if the method is idempotent, a POST may
+// have succeeded, eg.: a comment created before a 502 would be posted twice
+func retryAfter(method string, resp *http.Response) (time.Duration, bool) {
+	retryAfter, hasRetryAfter := retryAfterHeader(resp)
+	limited := rateLimited(resp)
+	switch {
+	case limited && hasRetryAfter:
+		return retryAfter, true
+	case limited:
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait := time.Until(time.Unix(reset, 0)) + time.Second
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return wait, true
+		}
+		// secondary rate limits without headers, wait a minute as GitHub recommends
+		return time.Minute, true
+	case resp.StatusCode >= 500 && idempotentMethods[method]:
+		if hasRetryAfter {
+			return retryAfter, true
+		}
+		return 2 * time.Second, true
+	}
+	return 0, false
+}
+
+// retryAfterHeader returns the wait of the Retry-After header, in seconds, and whether it is set
+func retryAfterHeader(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// rateLimited reports whether the response rejects a request because of a primary or secondary
+// rate limit: a 429, or a 403 with no remaining requests or a Retry-After
+func rateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	_, hasRetryAfter := retryAfterHeader(resp)
+	return resp.StatusCode == http.StatusForbidden && (resp.Header.Get("X-RateLimit-Remaining") == "0" || hasRetryAfter)
+}
+
+// linkNext matches the next page of a Link header, eg.: <https://api.github.com/...&page=2>; rel="next"
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage returns the URL of the next page of a Link header, or an empty string
+func nextPage(link string) string {
+	m := linkNext.FindStringSubmatch(link)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// sameHost reports whether the URL u is on the host of the API, the token is not sent to the
+// other hosts a Link header may point to
+func sameHost(u, baseURL string) bool {
+	target, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(target.Scheme, base.Scheme) && strings.EqualFold(target.Host, base.Host)
+}
+
+// repoPath returns the API path of a repository
+func repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
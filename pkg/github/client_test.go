@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client of srv recording its waits instead of sleeping
+func newTestClient(srv *httptest.Server, waits *[]time.Duration) *Client {
+	c := NewClient(srv.URL, "token")
+	c.sleep = func(d time.Duration) { *waits = append(*waits, d) }
+	return c
+}
+
+func TestClientZeroValue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL}
+	login, err := c.AuthenticatedLogin(context.Background())
+	if err != nil {
+		t.Fatalf("AuthenticatedLogin() error = %v", err)
+	}
+	if login != "octocat" {
+		t.Errorf("AuthenticatedLogin() = %q, want %q", login, "octocat")
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(30*time.Second).Unix(), 10)
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		headers  http.Header
+		wantErr  bool
+		// wantCalls is the number of requests the server receives
+		wantCalls int
+		// wantWait is the longest wait expected before a retry
+		wantWait time.Duration
+	}{
+		{name: "ok", method: "GET", statuses: []int{200}, wantCalls: 1},
+		{name: "server error GET", method: "GET", statuses: []int{502, 503, 200}, wantCalls: 3, wantWait: 2 * time.Second},
+		{name: "server error PATCH", method: "PATCH", statuses: []int{500, 200}, wantCalls: 2, wantWait: 2 * time.Second},
+		{name: "server error POST", method: "POST", statuses: []int{502, 200}, wantErr: true, wantCalls: 1},
+		{name: "server error retries exhausted", method: "GET", statuses: []int{500, 500, 500, 500, 500}, wantErr: true, wantCalls: DefaultMaxRetries + 1, wantWait: 2 * time.Second},
+		{name: "not found", method: "GET", statuses: []int{404, 200}, wantErr: true, wantCalls: 1},
+		{
+			name: "retry after", method: "POST", statuses: []int{429, 200},
+			headers:   http.Header{"Retry-After": {"7"}},
+			wantCalls: 2, wantWait: 7 * time.Second,
+		},
+		{
+			name: "rate limit reset", method: "POST", statuses: []int{403, 200},
+			headers:   http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}},
+			wantCalls: 2, wantWait: 30 * time.Second,
+		},
+		{
+			name: "rate limit reset too late", method: "GET", statuses: []int{403, 200},
+			headers: http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)}},
+			wantErr: true, wantCalls: 1,
+		},
+		{name: "forbidden", method: "GET", statuses: []int{403, 200}, wantErr: true, wantCalls: 1},
+		{
+			name: "server error POST with retry after", method: "POST", statuses: []int{503, 200},
+			headers: http.Header{"Retry-After": {"5"}},
+			wantErr: true, wantCalls: 1,
+		},
+		{
+			name: "server error GET with retry after", method: "GET", statuses: []int{503, 200},
+			headers:   http.Header{"Retry-After": {"5"}},
+			wantCalls: 2, wantWait: 5 * time.Second,
+		},
+		{
+			name: "secondary rate limit", method: "POST", statuses: []int{403, 200},
+			headers:   http.Header{"Retry-After": {"9"}},
+			wantCalls: 2, wantWait: 9 * time.Second,
+		},
+		{
+			name: "not found with retry after", method: "GET", statuses: []int{404, 200},
+			headers: http.Header{"Retry-After": {"1"}},
+			wantErr: true, wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method {
+					t.Errorf("method = %s, want %s", r.Method, tt.method)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer token" {
+					t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+				}
+				status := tt.statuses[calls]
+				calls++
+				if status != 200 {
+					for k, v := range tt.headers {
+						w.Header()[k] = v
+					}
+					w.WriteHeader(status)
+					w.Write([]byte(`{"message":"failed"}`))
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			var waits []time.Duration
+			_, err := newTestClient(srv, &waits).do(context.Background(), tt.method, "/path", nil, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			var longest time.Duration
+			for _, w := range waits {
+				if w > longest {
+					longest = w
+				}
+			}
+			// the reset is in whole seconds
+			if longest < tt.wantWait-time.Second || longest > tt.wantWait+time.Second {
+				t.Errorf("longest wait = %v, want %v", longest, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestClientPages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `<`+srv.URL+`/repos/o/r/issues/1/comments?page=2>; rel="next", <`+srv.URL+`/repos/o/r/issues/1/comments?page=2>; rel="last"`)
+			w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "2":
+			w.Write([]byte(`[{"id":3}]`))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	comments, err := newTestClient(srv, &waits).ListComments(context.Background(), PullRequest{Owner: "o", Repo: "r", Number: 1})
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+	if len(comments) != 3 || comments[2].ID != 3 {
+		t.Errorf("ListComments() = %+v, want the comments 1, 2 and 3", comments)
+	}
+}
+
+func TestClientPagesOtherHost(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q sent to another host", got)
+		}
+		w.Write([]byte(`[{"id":2}]`))
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+		}
+		w.Header().Set("Link", `<`+other.URL+`/repos/o/r/issues/1/comments?page=2>; rel="next"`)
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	comments, err := newTestClient(srv, &waits).ListComments(context.Background(), PullRequest{Owner: "o", Repo: "r", Number: 1})
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+	if len(comments) != 2 {
+		t.Errorf("ListComments() = %+v, want the comments 1 and 2", comments)
+	}
+}
+
+func TestAuthenticatedLogin(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers http.Header
+		want    string
+		wantErr bool
+	}{
+		{name: "user", status: 200, want: "octocat"},
+		{name: "integration token", status: 403, want: DefaultCommentLogin},
+		{name: "unauthorized", status: 401, want: DefaultCommentLogin},
+		{
+			// the rate limit error wraps the API error, it is not mistaken for a token without access to /user
+			name: "rate limited", status: 403, wantErr: true,
+			headers: http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)}},
+		},
+		{name: "server error", status: 500, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"login":"octocat"}`))
+			}))
+			defer srv.Close()
+
+			var waits []time.Duration
+			got, err := newTestClient(srv, &waits).AuthenticatedLogin(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthenticatedLogin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AuthenticatedLogin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// DefaultCommentMarker is the hidden marker identifying the comments of kubearmor-action
+const DefaultCommentMarker = "<!-- kubearmor-action -->"
+
+// DefaultCommentLogin is the author of the comments made with the GITHUB_TOKEN of a workflow,
+// which cannot look up its own login
+const DefaultCommentLogin = "github-actions[bot]"
+
+// Comment is an issue or pull request comment
+type Comment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// PullRequest identifies a pull request
+type PullRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// String returns the pull request as owner/repo#number
+func (pr PullRequest) String() string {
+	return fmt.Sprintf("%s/%s#%d", pr.Owner, pr.Repo, pr.Number)
+}
+
+// PullRequestFromEnv returns the pull request, the API URL and the token of the GitHub Actions
+// environment: $GITHUB_REPOSITORY, the number of the pull_request or issue_comment event
+// payload, $GITHUB_API_URL and $GITHUB_TOKEN
+func PullRequestFromEnv(action *githubactions.Action) (PullRequest, string, string, error) {
+	ctx, err := action.Context()
+	if err != nil {
+		return PullRequest{}, "", "", err
+	}
+	pr := PullRequest{}
+	pr.Owner, pr.Repo = ctx.Repo()
+	for _, key := range []string{"pull_request", "issue"} {
+		if obj, ok := ctx.Event[key].(map[string]interface{}); ok {
+			if number, ok := obj["number"].(float64); ok {
+				pr.Number = int(number)
+				break
+			}
+		}
+	}
+	if pr.Number == 0 {
+		if number, ok := ctx.Event["number"].(float64); ok {
+			pr.Number = int(number)
+		}
+	}
+	return pr, ctx.APIURL, action.Getenv("GITHUB_TOKEN"), nil
+}
+
+// ParsePullRequest parses a pull request given as owner/repo#number, or completes pr with a
+// repository given as owner/repo and a number
+func ParsePullRequest(repo string, number int) (PullRequest, error) {
+	pr := PullRequest{Number: number}
+	if i := strings.LastIndex(repo, "#"); i >= 0 {
+		n, err := strconv.Atoi(repo[i+1:])
+		if err != nil {
+			return PullRequest{}, fmt.Errorf("invalid pull request %q, must be owner/repo#number", repo)
+		}
+		repo, pr.Number = repo[:i], n
+	}
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return PullRequest{}, fmt.Errorf("invalid repository %q, must be owner/repo", repo)
+	}
+	pr.Owner, pr.Repo = parts[0], parts[1]
+	return pr, nil
+}
+
+// ListComments returns all the comments of the pull request, following the pages
+func (c *Client) ListComments(ctx context.Context, pr PullRequest) ([]Comment, error) {
+	var comments []Comment
+	next := fmt.Sprintf("%s/issues/%d/comments?per_page=100", repoPath(pr.Owner, pr.Repo), pr.Number)
+	for next != "" {
+		var page []Comment
+		var err error
+		next, err = c.do(ctx, "GET", next, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+	}
+	return comments, nil
+}
+
+// CreateComment comments on the pull request
+func (c *Client) CreateComment(ctx context.Context, pr PullRequest, body string) (*Comment, error) {
+	comment := &Comment{}
+	path := fmt.Sprintf("%s/issues/%d/comments", repoPath(pr.Owner, pr.Repo), pr.Number)
+	if _, err := c.do(ctx, "POST", path, map[string]string{"body": body}, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// UpdateComment replaces the body of a comment of the repository of the pull request
+func (c *Client) UpdateComment(ctx context.Context, pr PullRequest, id int64, body string) (*Comment, error) {
+	comment := &Comment{}
+	path := fmt.Sprintf("%s/issues/comments/%d", repoPath(pr.Owner, pr.Repo), id)
+	if _, err := c.do(ctx, "PATCH", path, map[string]string{"body": body}, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// AuthenticatedLogin returns the login of the authenticated user: Client.Login if set, the login
+// of GET /user, or DefaultCommentLogin if the token cannot read it, eg.: a GITHUB_TOKEN
+func (c *Client) AuthenticatedLogin(ctx context.Context) (string, error) {
+	if c.Login != "" {
+		return c.Login, nil
+	}
+	user := struct {
+		Login string `json:"login"`
+	}{}
+	if _, err := c.do(ctx, "GET", "/user", nil, &user); err != nil {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.RateLimited || (apiErr.StatusCode != http.StatusUnauthorized && apiErr.StatusCode != http.StatusForbidden) {
+			return "", err
+		}
+		user.Login = DefaultCommentLogin
+	}
+	c.Login = user.Login
+	return c.Login, nil
+}
+
+// FindComment returns the first comment of the pull request made by the authenticated user and
+// containing the marker, nil if there is none. The comments of the other users are never
+// returned, they may quote the marker.
+func (c *Client) FindComment(ctx context.Context, pr PullRequest, marker string) (*Comment, error) {
+	login, err := c.AuthenticatedLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := c.ListComments(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		if comments[i].User.Login == login && strings.Contains(comments[i].Body, marker) {
+			return &comments[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// UpsertComment updates the comment of the pull request found by FindComment, or creates it.
+// The marker, eg.: an HTML comment, is appended to the body if missing. It returns the comment
+// and whether it was created.
+func (c *Client) UpsertComment(ctx context.Context, pr PullRequest, marker, body string) (*Comment, bool, error) {
+	if marker == "" {
+		marker = DefaultCommentMarker
+	}
+	if !strings.Contains(body, marker) {
+		body = strings.TrimRight(body, "\n") + "\n\n" + marker + "\n"
+	}
+	existing, err := c.FindComment(ctx, pr, marker)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		comment, err := c.CreateComment(ctx, pr, body)
+		return comment, err == nil, err
+	}
+	if existing.Body == body {
+		return existing, false, nil
+	}
+	comment, err := c.UpdateComment(ctx, pr, existing.ID, body)
+	return comment, false, err
+}
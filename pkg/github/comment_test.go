@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIssues is a stand-in of the issue comments API of a pull request
+type fakeIssues struct {
+	mu       sync.Mutex
+	login    string
+	comments []Comment
+	// userStatus is the status of GET /user, 200 if 0
+	userStatus int
+	creates    int
+	updates    int
+}
+
+func (f *fakeIssues) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/user":
+		if f.userStatus != 0 {
+			w.WriteHeader(f.userStatus)
+			w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"login": f.login})
+	case r.Method == "GET" && r.URL.Path == "/repos/o/r/issues/1/comments":
+		json.NewEncoder(w).Encode(f.comments)
+	case r.Method == "POST" && r.URL.Path == "/repos/o/r/issues/1/comments":
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		c := Comment{ID: int64(100 + len(f.comments)), Body: in["body"]}
+		c.User.Login = f.login
+		if c.User.Login == "" {
+			c.User.Login = DefaultCommentLogin
+		}
+		f.comments = append(f.comments, c)
+		f.creates++
+		json.NewEncoder(w).Encode(c)
+	case r.Method == "PATCH" && strings.HasPrefix(r.URL.Path, "/repos/o/r/issues/comments/"):
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues/comments/"), 10, 64)
+		var in map[string]string
+		json.NewDecoder(r.Body).Decode(&in)
+		for i := range f.comments {
+			if f.comments[i].ID == id {
+				f.comments[i].Body = in["body"]
+				f.updates++
+				json.NewEncoder(w).Encode(f.comments[i])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// comment returns a comment of login
+func comment(id int64, login, body string) Comment {
+	c := Comment{ID: id, Body: body}
+	c.User.Login = login
+	return c
+}
+
+func TestUpsertComment(t *testing.T) {
+	marker := DefaultCommentMarker
+	tests := []struct {
+		name       string
+		login      string
+		userStatus int
+		comments   []Comment
+		body       string
+		wantCreate bool
+		wantUpdate bool
+		// wantID is the ID of the upserted comment
+		wantID int64
+	}{
+		{
+			name: "create", login: "bot",
+			comments: []Comment{comment(1, "someone", "LGTM")}, body: "report",
+			wantCreate: true, wantID: 101,
+		},
+		{
+			name: "update", login: "bot",
+			comments: []Comment{comment(1, "someone", "LGTM"), comment(2, "bot", "old report\n\n"+marker+"\n")}, body: "report",
+			wantUpdate: true, wantID: 2,
+		},
+		{
+			name: "unchanged", login: "bot",
+			comments: []Comment{comment(2, "bot", "report\n\n"+marker+"\n")}, body: "report",
+			wantID: 2,
+		},
+		{
+			name: "marker quoted by another user", login: "bot",
+			comments: []Comment{comment(1, "someone", "> old report\n> "+marker)}, body: "report",
+			wantCreate: true, wantID: 101,
+		},
+		{
+			name: "workflow token", userStatus: http.StatusForbidden,
+			comments: []Comment{comment(1, "someone", marker), comment(2, DefaultCommentLogin, "old report "+marker)}, body: "report",
+			wantUpdate: true, wantID: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeIssues{login: tt.login, userStatus: tt.userStatus, comments: tt.comments}
+			srv := httptest.NewServer(f)
+			defer srv.Close()
+
+			var waits []time.Duration
+			c := newTestClient(srv, &waits)
+			got, created, err := c.UpsertComment(context.Background(), PullRequest{Owner: "o", Repo: "r", Number: 1}, "", tt.body)
+			if err != nil {
+				t.Fatalf("UpsertComment() error = %v", err)
+			}
+			if created != tt.wantCreate {
+				t.Errorf("UpsertComment() created = %v, want %v", created, tt.wantCreate)
+			}
+			if got.ID != tt.wantID {
+				t.Errorf("UpsertComment() ID = %d, want %d", got.ID, tt.wantID)
+			}
+			if !strings.Contains(got.Body, tt.body) || !strings.Contains(got.Body, marker) {
+				t.Errorf("UpsertComment() body = %q, want the body and the marker", got.Body)
+			}
+			wantCreates, wantUpdates := 0, 0
+			if tt.wantCreate {
+				wantCreates = 1
+			}
+			if tt.wantUpdate {
+				wantUpdates = 1
+			}
+			if f.creates != wantCreates || f.updates != wantUpdates {
+				t.Errorf("creates, updates = %d, %d, want %d, %d", f.creates, f.updates, wantCreates, wantUpdates)
+			}
+		})
+	}
+}
+
+func TestUpsertCommentServerError(t *testing.T) {
+	// the comment is created but the response is lost, the POST must not be sent again
+	f := &fakeIssues{login: "bot"}
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			posts++
+			f.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		f.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	_, _, err := newTestClient(srv, &waits).UpsertComment(context.Background(), PullRequest{Owner: "o", Repo: "r", Number: 1}, "", "report")
+	if err == nil {
+		t.Fatalf("UpsertComment() error = nil, want the server error")
+	}
+	if posts != 1 || len(f.comments) != 1 {
+		t.Errorf("posts = %d, comments = %d, want a single comment", posts, len(f.comments))
+	}
+}
+
+func TestParsePullRequest(t *testing.T) {
+	tests := []struct {
+		repo    string
+		number  int
+		want    PullRequest
+		wantErr bool
+	}{
+		{repo: "kubearmor/kubearmor-action#12", want: PullRequest{Owner: "kubearmor", Repo: "kubearmor-action", Number: 12}},
+		{repo: "kubearmor/kubearmor-action", number: 3, want: PullRequest{Owner: "kubearmor", Repo: "kubearmor-action", Number: 3}},
+		{repo: "kubearmor/kubearmor-action#x", wantErr: true},
+		{repo: "kubearmor", wantErr: true},
+		{repo: "a/b/c", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePullRequest(tt.repo, tt.number)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePullRequest(%q) error = %v, wantErr %v", tt.repo, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePullRequest(%q) = %+v, want %+v", tt.repo, got, tt.want)
+		}
+	}
+}
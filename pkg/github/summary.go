@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/controller/client"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// Enabled reports whether the process runs in a GitHub Actions step
+func Enabled() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// AddStepSummary appends the Markdown to the job summary, it does nothing
+// and returns false outside of GitHub Actions, where $GITHUB_STEP_SUMMARY is not set
+func AddStepSummary(action *githubactions.Action, markdown string) bool {
+	if action.Getenv("GITHUB_STEP_SUMMARY") == "" {
+		return false
+	}
+	action.AddStepSummary(markdown)
+	return true
+}
+
+// ReadinessSummary renders the readiness of the pods as a Markdown table, the pods that are not ready first
+func ReadinessSummary(pods []client.PodReadiness) string {
+	sorted := append([]client.PodReadiness(nil), pods...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Ready != sorted[j].Ready {
+			return !sorted[i].Ready
+		}
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	ready := 0
+	for _, pod := range pods {
+		if pod.Ready {
+			ready++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Pod Readiness\n\n")
+	sb.WriteString(fmt.Sprintf("%d of %d pods are ready.\n\n", ready, len(pods)))
+	if len(pods) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("| Namespace | Pod | Phase | Ready | Restarts | Reason |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, pod := range sorted {
+		mark := "✅"
+		if !pod.Ready {
+			mark = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d | %s |\n", pod.Namespace, pod.Name, pod.Phase, mark, pod.Restarts, pod.Reason))
+	}
+	return sb.String()
+}
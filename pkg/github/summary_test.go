@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubearmor/kubearmor-action/pkg/controller/client"
+)
+
+func TestAddStepSummary(t *testing.T) {
+	action, _ := testAction(nil)
+	if AddStepSummary(action, "# Report") {
+		t.Errorf("AddStepSummary() = true outside of GitHub Actions, want false")
+	}
+
+	file := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(file, []byte("# Readiness\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	action, _ = testAction(map[string]string{"GITHUB_STEP_SUMMARY": file})
+	if !AddStepSummary(action, "# Report") {
+		t.Errorf("AddStepSummary() = false, want true")
+	}
+	data, err := os.ReadFile(file) // #nosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the summary is appended to the summaries of the previous steps
+	if got, want := string(data), "# Readiness\n# Report\n"; got != want {
+		t.Errorf("$GITHUB_STEP_SUMMARY = %q, want %q", got, want)
+	}
+}
+
+func TestReadinessSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		pods []client.PodReadiness
+		want string
+	}{
+		{
+			name: "no pods",
+			want: "## Pod Readiness\n\n0 of 0 pods are ready.\n\n",
+		},
+		{
+			name: "not ready first",
+			pods: []client.PodReadiness{
+				{Namespace: "wordpress-mysql", Name: "wordpress-1", Phase: "Running", Ready: true},
+				{Namespace: "wordpress-mysql", Name: "mysql-1", Phase: "Running", Ready: false, Restarts: 3, Reason: "CrashLoopBackOff"},
+				{Namespace: "default", Name: "api-1", Phase: "Running", Ready: true, Restarts: 1},
+				{Namespace: "default", Name: "api-0", Phase: "Pending", Ready: false, Reason: "ContainerCreating"},
+			},
+			want: "## Pod Readiness\n\n2 of 4 pods are ready.\n\n" +
+				"| Namespace | Pod | Phase | Ready | Restarts | Reason |\n" +
+				"| --- | --- | --- | --- | --- | --- |\n" +
+				"| default | api-0 | Pending | ❌ | 0 | ContainerCreating |\n" +
+				"| wordpress-mysql | mysql-1 | Running | ❌ | 3 | CrashLoopBackOff |\n" +
+				"| default | api-1 | Running | ✅ | 1 |  |\n" +
+				"| wordpress-mysql | wordpress-1 | Running | ✅ | 0 |  |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadinessSummary(tt.pods); got != tt.want {
+				t.Errorf("ReadinessSummary() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kubearmor/kubearmor-action/pkg/baseline"
+	"github.com/sethvargo/go-githubactions"
+	"k8s.io/klog"
+)
+
+// BaselineFile is the file the selected baseline is written to
+const BaselineFile = "kubearmor-baseline.json"
+
+// selectBaseline writes the baseline of the pull request context to BaselineFile. If the store
+// has none, it returns the reason instead, and the run is a first run.
+func selectBaseline(ctx context.Context, action *githubactions.Action, in Inputs) (file string, sel *baseline.Selection, reason string, err error) {
+	s, err := baseline.Open(in.BaselineStore, in.BaselineStoreToken)
+	if err != nil {
+		return "", nil, "", err
+	}
+	defer baseline.Close(s)
+	sel, err = baseline.Select(ctx, s, baselineContext(action))
+	if errors.Is(err, baseline.ErrNotFound) {
+		klog.Infof("%v", err)
+		return "", nil, "no baseline was recorded for the base branch, the merge-base commit or the default branch", nil
+	}
+	if err != nil {
+		return "", nil, "", err
+	}
+	klog.Infof("Selected the %s baseline %s created at %s", sel.Source, sel.Baseline.Key, sel.Baseline.Created)
+	return BaselineFile, sel, "", os.WriteFile(BaselineFile, sel.Data, 0600)
+}
+
+// recordBaseline stores the summary file as the baseline of the ref and the commit, and prunes the
+// store with the retention policy
+func recordBaseline(ctx context.Context, action *githubactions.Action, in Inputs) error {
+	if in.Ref == "" {
+		return fmt.Errorf("unknown branch, the summary is not recorded as a baseline")
+	}
+	data, err := os.ReadFile(in.File) // #nosec
+	if err != nil {
+		return err
+	}
+	s, err := baseline.Open(in.BaselineStore, in.BaselineStoreToken)
+	if err != nil {
+		return err
+	}
+	defer baseline.Close(s)
+	b, err := s.Put(ctx, baseline.Key{Branch: in.Ref, Commit: in.SHA}, data)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Recorded the baseline %s", b.Key)
+
+	policy := in.BaselineRetention
+	if policy.IsZero() {
+		return nil
+	}
+	if len(policy.KeepBranches) == 0 {
+		if branch := defaultBranch(action); branch != "" {
+			policy.KeepBranches = []string{branch}
+		}
+	}
+	expired, err := baseline.Prune(ctx, s, policy, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, b := range expired {
+		klog.Infof("Pruned the baseline %s created at %s", b.Key, b.Created.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// baselineContext returns the context of the baseline selection: the base branch and the
+// merge-base of a pull request, or the pushed branch, and the default branch of the repository
+func baselineContext(action *githubactions.Action) baseline.Context {
+	c := baseline.Context{BaseRef: action.Getenv("GITHUB_BASE_REF")}
+	if c.BaseRef == "" {
+		// outside of a pull request, compare with the previous baseline of the branch
+		c.BaseRef = action.Getenv("GITHUB_REF_NAME")
+	}
+	c.DefaultBranch = defaultBranch(action)
+	ctx, err := action.Context()
+	if err != nil {
+		return c
+	}
+	if pr, ok := ctx.Event["pull_request"].(map[string]interface{}); ok {
+		base, _ := pr["base"].(map[string]interface{})
+		head, _ := pr["head"].(map[string]interface{})
+		baseSHA, _ := base["sha"].(string)
+		headSHA, _ := head["sha"].(string)
+		var err error
+		if c.MergeBase, err = mergeBase(action.Getenv("GITHUB_WORKSPACE"), baseSHA, headSHA); err != nil {
+			action.Warningf("the baseline of the merge-base commit is not selected, the merge-base is unknown (check out with fetch-depth: 0): %v", err)
+		}
+	}
+	return c
+}
+
+// defaultBranch returns the default branch of the repository of the workflow event, if any
+func defaultBranch(action *githubactions.Action) string {
+	ctx, err := action.Context()
+	if err != nil {
+		return ""
+	}
+	repo, _ := ctx.Event["repository"].(map[string]interface{})
+	branch, _ := repo["default_branch"].(string)
+	return branch
+}
+
+// mergeBase returns the merge-base of the commits in the repository of the directory. In a
+// shallow clone, eg.: of actions/checkout without fetch-depth: 0, the history of the commits is
+// fetched first.
+func mergeBase(dir, a, b string) (string, error) {
+	if a == "" || b == "" {
+		return "", fmt.Errorf("unknown base or head commit")
+	}
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...) // #nosec
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if shallow, err := git("rev-parse", "--is-shallow-repository"); err == nil && shallow == "true" {
+		klog.Infof("Fetching the history of %s and %s from the shallow clone", a, b)
+		if _, err := git("fetch", "--quiet", "--no-tags", "--unshallow", "origin", a, b); err != nil {
+			return "", err
+		}
+	}
+	return git("merge-base", a, b)
+}
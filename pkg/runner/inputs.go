@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/baseline"
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// Inputs are the inputs of the kubearmor-action action
+type Inputs struct {
+	// OldSummaryPath is the summary of the base, a file or a URL, selected from BaselineStore if empty
+	OldSummaryPath string
+	// BaselineStore is the store the baselines are selected from and recorded to, see baseline.Open
+	BaselineStore string
+	// BaselineStoreToken authenticates to BaselineStore, Token if the store is a git repository of
+	// the GitHub server, no credential otherwise
+	BaselineStoreToken string
+	// RecordBaseline records the summary in BaselineStore as the baseline of Ref and SHA
+	RecordBaseline bool
+	// BaselineRetention prunes BaselineStore after recording, if set. The latest baseline of the
+	// default branch is kept if the policy protects no branch.
+	BaselineRetention baseline.RetentionPolicy
+	// Namespace is the namespace the summary is collected from
+	Namespace string
+	// AppName selects the workloads of an app, all the workloads if empty
+	AppName string
+	// File is the summary file, collected if SaveSummaryReport is set, read otherwise
+	File string
+	// SaveSummaryReport collects the summary with karmor
+	SaveSummaryReport bool
+	// Visualise renders the system and network images
+	Visualise bool
+	// MaxGrade fails the action if the risk grade of the changes is worse, if set
+	MaxGrade string
+	// Volume classifies the count changes as volume spikes or drops, the defaults if zero
+	Volume visual.VolumeThresholds
+	// Window selects the entries of the summaries by time, eg.: the phase of a load test
+	Window visual.TimeWindow
+	// Manifests are the manifest files or directories the annotations point at
+	Manifests []string
+	// Comment creates or updates the pull request comment with the report
+	Comment bool
+	// Token authenticates the pull request comment
+	Token string
+	// SHA names the images and the recorded baseline, eg.: the head commit of the pull request
+	SHA string
+	// Ref is the branch of the recorded baseline, the head branch of the pull request or the pushed branch
+	Ref string
+}
+
+// InputsFrom reads the inputs of the action from the INPUT_* environment variables
+func InputsFrom(action *githubactions.Action) (Inputs, error) {
+	in := Inputs{
+		OldSummaryPath:     strings.TrimSpace(action.GetInput("old-summary-path")),
+		Namespace:          action.GetInput("namespace"),
+		AppName:            action.GetInput("app-name"),
+		File:               action.GetInput("file"),
+		BaselineStore:      strings.TrimSpace(action.GetInput("baseline-store")),
+		Token:              action.GetInput("github-token"),
+		BaselineStoreToken: action.GetInput("baseline-store-token"),
+	}
+	if in.File == "" {
+		in.File = "summary.json"
+	}
+	var err error
+	for name, value := range map[string]*bool{
+		"save-summary-report": &in.SaveSummaryReport,
+		"visualise":           &in.Visualise,
+		"comment":             &in.Comment,
+		"record-baseline":     &in.RecordBaseline,
+	} {
+		if *value, err = parseBool(name, action.GetInput(name)); err != nil {
+			return Inputs{}, err
+		}
+	}
+	if grade := action.GetInput("max-grade"); grade != "" {
+		if in.MaxGrade, err = visual.ParseGrade(grade); err != nil {
+			return Inputs{}, err
+		}
+	}
+	if ratio := strings.TrimSpace(action.GetInput("volume-ratio")); ratio != "" {
+		if in.Volume.Ratio, err = strconv.ParseFloat(ratio, 64); err != nil {
+			return Inputs{}, fmt.Errorf("input 'volume-ratio' must be a number, not %q", ratio)
+		}
+	}
+	if delta := strings.TrimSpace(action.GetInput("volume-min-delta")); delta != "" {
+		minDelta, err := visual.ParseCount(delta)
+		if err != nil {
+			return Inputs{}, fmt.Errorf("input 'volume-min-delta' must be a count, not %q", delta)
+		}
+		in.Volume = visual.NewVolumeThresholds(in.Volume.Ratio, minDelta)
+	}
+	if in.BaselineRetention, err = baseline.ParseRetentionPolicy(action.GetInput("baseline-retention")); err != nil {
+		return Inputs{}, fmt.Errorf("input 'baseline-retention': %w", err)
+	}
+	if in.Window, err = windowFrom(action); err != nil {
+		return Inputs{}, err
+	}
+	for _, m := range strings.FieldsFunc(action.GetInput("manifests"), func(r rune) bool { return r == '\n' || r == ',' }) {
+		if m = strings.TrimSpace(m); m != "" {
+			in.Manifests = append(in.Manifests, m)
+		}
+	}
+	if in.Token == "" {
+		in.Token = action.Getenv("GITHUB_TOKEN")
+	}
+	if in.BaselineStoreToken == "" {
+		server := action.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		in.BaselineStoreToken = baseline.GitHubToken(in.BaselineStore, server, in.Token)
+	}
+	in.SHA = headSHA(action)
+	if in.Ref = action.Getenv("GITHUB_HEAD_REF"); in.Ref == "" {
+		in.Ref = action.Getenv("GITHUB_REF_NAME")
+	}
+	if in.SaveSummaryReport && in.Namespace == "" {
+		return Inputs{}, fmt.Errorf("input 'namespace' is required to save the summary report")
+	}
+	return in, nil
+}
+
+// windowFrom reads the time window of the since, until, phase and phases-file inputs,
+// since and until override the start and end of the phase
+func windowFrom(action *githubactions.Action) (visual.TimeWindow, error) {
+	var w visual.TimeWindow
+	if name := strings.TrimSpace(action.GetInput("phase")); name != "" {
+		file := strings.TrimSpace(action.GetInput("phases-file"))
+		if file == "" {
+			file = visual.DefaultPhasesFile
+		}
+		phases, err := visual.ReadPhases(file)
+		if err != nil {
+			return w, err
+		}
+		phase, err := phases.Get(name)
+		if err != nil {
+			return w, err
+		}
+		w = phase.Window()
+	}
+	for name, bound := range map[string]**visual.TimeBound{"since": &w.Since, "until": &w.Until} {
+		b, err := visual.ParseTimeBound(action.GetInput(name))
+		if err != nil {
+			return w, fmt.Errorf("input '%s': %w", name, err)
+		}
+		if b != nil {
+			*bound = b
+		}
+	}
+	return w, nil
+}
+
+// parseBool parses a boolean input, as the YAML 1.2 core schema does
+func parseBool(name, value string) (bool, error) {
+	switch strings.TrimSpace(value) {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "", "false", "False", "FALSE":
+		return false, nil
+	}
+	return false, fmt.Errorf("input '%s' must be true or false, not %q", name, value)
+}
+
+// headSHA returns the head commit of the pull request of the workflow event, or $GITHUB_SHA
+func headSHA(action *githubactions.Action) string {
+	if ctx, err := action.Context(); err == nil {
+		if pr, ok := ctx.Event["pull_request"].(map[string]interface{}); ok {
+			if head, ok := pr["head"].(map[string]interface{}); ok {
+				if sha, ok := head["sha"].(string); ok && sha != "" {
+					return sha
+				}
+			}
+		}
+	}
+	return action.Getenv("GITHUB_SHA")
+}
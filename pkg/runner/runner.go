@@ -0,0 +1,343 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	"github.com/kubearmor/kubearmor-action/pkg/github"
+	visual "github.com/kubearmor/kubearmor-action/pkg/visualisation"
+	"github.com/sethvargo/go-githubactions"
+	"k8s.io/klog"
+)
+
+// Names of the artifacts and files the action writes
+const (
+	SummaryArtifact       = "summary_report"
+	VisualisationArtifact = "app_visulisation"
+	DiffFile              = "kubearmor-diff.json"
+	ReportFile            = "kubearmor-report.md"
+)
+
+// Outputs are the outputs of the kubearmor-action action
+type Outputs struct {
+	SummaryReportArtifact        string
+	SummaryReportFile            string
+	VisualisationResultsArtifact string
+	SysVisualisationImage        string
+	NetworkVisualisationImage    string
+	DiffFile                     string
+	ReportFile                   string
+	RiskGrade                    string
+	RiskScore                    int
+	// Baseline is the selected baseline and how it was selected, if any
+	Baseline string
+	// FirstRun is set if there was no baseline to compare with
+	FirstRun bool
+}
+
+// Set writes the outputs that are set to $GITHUB_OUTPUT
+func (o Outputs) Set(action *githubactions.Action) {
+	if action.Getenv("GITHUB_OUTPUT") == "" {
+		return
+	}
+	outputs := map[string]string{
+		"summary-report-artifact":        o.SummaryReportArtifact,
+		"summary-report-file":            o.SummaryReportFile,
+		"visualisation-results-artifact": o.VisualisationResultsArtifact,
+		"sys-visualisation-image":        o.SysVisualisationImage,
+		"network-visualisation-image":    o.NetworkVisualisationImage,
+		"diff-file":                      o.DiffFile,
+		"report-file":                    o.ReportFile,
+		"risk-grade":                     o.RiskGrade,
+		"baseline":                       o.Baseline,
+	}
+	if o.FirstRun {
+		outputs["first-run"] = "true"
+	}
+	if o.RiskGrade != "" {
+		outputs["risk-score"] = fmt.Sprint(o.RiskScore)
+	}
+	keys := make([]string, 0, len(outputs))
+	for k := range outputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if outputs[k] != "" {
+			action.SetOutput(k, outputs[k])
+		}
+	}
+}
+
+// Run collects the summary, compares it with the old one or the baseline selected from the store,
+// renders the images, reports the changes and records the summary as a baseline, in-process.
+// Without baseline, the report is a first run report and the risk is not gated. Every phase is a
+// log group. The outputs of the phases that ran are returned, with an error if a phase failed or
+// the risk grade is worse than in.MaxGrade.
+func Run(ctx context.Context, action *githubactions.Action, in Inputs) (Outputs, error) {
+	out := Outputs{}
+	// eg.: a step installing kubearmor only, there is no summary to collect, read or compare
+	if !in.SaveSummaryReport && in.OldSummaryPath == "" && in.BaselineStore == "" {
+		if _, err := os.Stat(in.File); errors.Is(err, os.ErrNotExist) {
+			klog.Infof("Nothing to analyse: save-summary-report, old-summary-path and baseline-store are not set and %s does not exist", in.File)
+			return out, nil
+		}
+	}
+	f, err := filter.New(filter.Options{App: in.AppName})
+	if err != nil {
+		return out, err
+	}
+	n, err := visual.LoadNormalizer("")
+	if err != nil {
+		return out, err
+	}
+	p := visual.ParseOptions{Normalizer: n, Window: in.Window}
+	if !in.Window.IsZero() {
+		klog.Infof("Time window: %s", in.Window)
+	}
+
+	// collect
+	if in.SaveSummaryReport {
+		err := group(action, "Collecting the summary of namespace "+in.Namespace, func() error {
+			return collect(in.Namespace, in.File)
+		})
+		if err != nil {
+			return out, err
+		}
+		out.SummaryReportArtifact = SummaryArtifact
+		out.SummaryReportFile = in.File
+	}
+	err = group(action, "Validating the summary", func() error {
+		return validate(in.File)
+	})
+	if err != nil {
+		return out, err
+	}
+
+	// baseline
+	old, firstRun := in.OldSummaryPath, ""
+	if old == "" && in.BaselineStore != "" {
+		err := group(action, "Selecting the baseline", func() error {
+			file, sel, reason, err := selectBaseline(ctx, action, in)
+			if sel != nil {
+				out.Baseline = fmt.Sprintf("%s (%s)", sel.Baseline.Key, sel.Source)
+			}
+			old, firstRun = file, reason
+			return err
+		})
+		if err != nil {
+			return out, err
+		}
+	}
+
+	// diff
+	var report *visual.DiffReport
+	var vnd *visual.VisualNetworkData
+	if old != "" || firstRun != "" {
+		err := group(action, "Comparing with the baseline", func() error {
+			sdNews, err := p.ParseSummaryData(in.File)
+			if err != nil {
+				return err
+			}
+			var sdOlds []*visual.SummaryData
+			if old != "" {
+				sdOlds, err = p.Older().ParseSummaryData(old)
+				var httpErr *visual.HTTPError
+				if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+					// the baseline of the first run is not published yet
+					klog.Infof("%v", err)
+					old, firstRun, err = "", fmt.Sprintf("%s was not found", httpErr.URL), nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+			diff := visual.DiffOptions{Volume: in.Volume}
+			report = diff.Diff(sdOlds, sdNews, f, visual.BuiltinClassifier())
+			if firstRun == "" {
+				vnd = diff.ParseNetworkData(sdOlds, sdNews, f)
+				klog.Infof("Risk: %s", report.Risk)
+			}
+			return writeJSON(DiffFile, report)
+		})
+		if err != nil {
+			return out, err
+		}
+		out.DiffFile = DiffFile
+		out.FirstRun = firstRun != ""
+		if !out.FirstRun {
+			out.RiskGrade = report.Risk.Grade
+			out.RiskScore = report.Risk.Score
+			index, err := visual.IndexManifests(in.Manifests...)
+			if err != nil {
+				return out, err
+			}
+			github.AnnotateDiff(action, report, github.AnnotationOptions{Manifests: index, Fallback: in.File})
+		}
+	}
+
+	// render
+	if in.Visualise {
+		sysImage := "app_sys_" + in.SHA + ".png"
+		networkImage := "app_network_" + in.SHA + ".png"
+		err := group(action, "Rendering the images", func() error {
+			setPlantUMLDir(action)
+			if err := visual.ConvertSysJSONToImage(in.File, sysImage, f, p, visual.FileTreeOptions{KeepExpanded: visual.DefaultKeepExpanded}); err != nil {
+				return err
+			}
+			// without baseline, the network connections are shown without changes
+			if old == "" {
+				return visual.ConvertNetworkSnapshotToImage(in.File, networkImage, f, p)
+			}
+			return visual.DiffOptions{Volume: in.Volume}.ConvertNetworkJSONToImage(old, in.File, networkImage, f, p)
+		})
+		if err != nil {
+			return out, err
+		}
+		out.VisualisationResultsArtifact = VisualisationArtifact
+		out.SysVisualisationImage = sysImage
+		out.NetworkVisualisationImage = networkImage
+	}
+
+	// report
+	if report != nil {
+		var markdown string
+		if firstRun != "" {
+			markdown = visual.FirstRunReport(report, firstRun, visual.ReportOptions{})
+		} else {
+			markdown = visual.MarkdownReport(report, vnd, visual.ReportOptions{})
+		}
+		if err := os.WriteFile(ReportFile, []byte(markdown), 0600); err != nil {
+			return out, err
+		}
+		out.ReportFile = ReportFile
+		github.AddStepSummary(action, markdown)
+		if in.Comment {
+			if err := comment(ctx, action, in.Token, markdown); err != nil {
+				return out, err
+			}
+		}
+	}
+
+	// record
+	if in.RecordBaseline && in.BaselineStore != "" {
+		err := group(action, "Recording the baseline", func() error {
+			return recordBaseline(ctx, action, in)
+		})
+		if err != nil {
+			return out, err
+		}
+	}
+
+	// gate
+	if report != nil && firstRun == "" && in.MaxGrade != "" && visual.GradeWorse(report.Risk.Grade, in.MaxGrade) {
+		return out, fmt.Errorf("risk grade %s is worse than the maximum grade %s", report.Risk.Grade, in.MaxGrade)
+	}
+	return out, nil
+}
+
+// group runs fn in a log group
+func group(action *githubactions.Action, title string, fn func() error) error {
+	action.Group(title)
+	defer action.EndGroup()
+	err := fn()
+	klog.Flush()
+	return err
+}
+
+// collect writes the karmor summary of the namespace to the file
+func collect(namespace, file string) error {
+	f, err := os.Create(file) // #nosec
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cmd := exec.Command("karmor", "summary", "-n", namespace, "-o", "json") // #nosec
+	cmd.Stdout = f
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("karmor summary: %w", err)
+	}
+	return nil
+}
+
+// validate validates the summary file against the summary schema
+func validate(file string) error {
+	r, err := visual.OpenSummaryData(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	violations, err := visual.ValidateSummaryData(r, file)
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		klog.Errorf("%s: %s", file, v)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%s has %d schema violations", file, len(violations))
+	}
+	return nil
+}
+
+// comment creates or updates the pull request comment with the report
+func comment(ctx context.Context, action *githubactions.Action, token, markdown string) error {
+	pr, apiURL, envToken, err := github.PullRequestFromEnv(action)
+	if err != nil {
+		return err
+	}
+	if pr.Number == 0 {
+		action.Warningf("not a pull request event, the report is not commented")
+		return nil
+	}
+	if token == "" {
+		token = envToken
+	}
+	c, created, err := github.NewClient(apiURL, token).UpsertComment(ctx, pr, github.DefaultCommentMarker, markdown)
+	if err != nil {
+		return fmt.Errorf("commenting on %s: %w", pr, err)
+	}
+	if created {
+		action.Infof("created comment %s", c.HTMLURL)
+	} else {
+		action.Infof("updated comment %s", c.HTMLURL)
+	}
+	return nil
+}
+
+// setPlantUMLDir points the renderer at the plantuml.jar of the action, if the action runs from its checkout
+func setPlantUMLDir(action *githubactions.Action) {
+	dir := action.Getenv("GITHUB_ACTION_PATH")
+	if dir == "" {
+		return
+	}
+	dir = filepath.Join(dir, "pkg", "visualisation")
+	if _, err := os.Stat(filepath.Join(dir, "plantuml.jar")); err == nil {
+		visual.PWD = dir + "/"
+	}
+}
+
+// writeJSON writes v as indented JSON to the file
+func writeJSON(file string, v interface{}) error {
+	f, err := os.Create(file) // #nosec
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubearmor/kubearmor-action/pkg/baseline"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// testdata returns the absolute path of a summary of the test data, before inTempDir
+func testdata(t *testing.T, name string) string {
+	t.Helper()
+	p, err := filepath.Abs(filepath.Join("..", "..", "test", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// inTempDir runs the test in a temporary directory, Run writes its files to the working directory
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return dir
+}
+
+// fakeAction returns an action reading the environment variables from env and writing its
+// commands to a buffer
+func fakeAction(env map[string]string) (*githubactions.Action, *bytes.Buffer) {
+	var buf bytes.Buffer
+	getenv := func(k string) string { return env[k] }
+	return githubactions.New(githubactions.WithWriter(&buf), githubactions.WithGetenv(getenv)), &buf
+}
+
+// fakeStore returns the spec of a directory store holding the summaries as the baselines of the keys
+func fakeStore(t *testing.T, baselines map[baseline.Key]string) string {
+	t.Helper()
+	root := t.TempDir()
+	s := baseline.NewDirStore(root)
+	for key, file := range baselines {
+		data, err := os.ReadFile(file) // #nosec
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Put(context.Background(), key, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return "dir:" + root
+}
+
+func TestRunFirstRun(t *testing.T) {
+	summary := testdata(t, "new-summary-data.json")
+	inTempDir(t)
+	store := fakeStore(t, nil)
+	action, _ := fakeAction(map[string]string{"GITHUB_BASE_REF": "main"})
+	in := Inputs{
+		BaselineStore:  store,
+		RecordBaseline: true,
+		File:           summary,
+		MaxGrade:       "A",
+		Ref:            "main",
+		SHA:            "abc123",
+	}
+	out, err := Run(context.Background(), action, in)
+	if err != nil {
+		t.Fatalf("Run() error = %v, the first run is not gated", err)
+	}
+	if !out.FirstRun || out.RiskGrade != "" || out.Baseline != "" {
+		t.Errorf("Run() = %+v, want a first run without risk grade and baseline", out)
+	}
+	if out.DiffFile != DiffFile || out.ReportFile != ReportFile {
+		t.Errorf("Run() files = %q, %q, want %q, %q", out.DiffFile, out.ReportFile, DiffFile, ReportFile)
+	}
+	report, err := os.ReadFile(ReportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) == 0 {
+		t.Errorf("%s is empty", ReportFile)
+	}
+
+	// the summary is recorded, the next run compares with it
+	recorded, err := baseline.NewDirStore(strings.TrimPrefix(store, "dir:")).List(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 || recorded[0].Key.Commit != "abc123" {
+		t.Fatalf("recorded baselines = %+v, want main@abc123", recorded)
+	}
+	out, err = Run(context.Background(), action, in)
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if out.FirstRun || out.Baseline == "" {
+		t.Errorf("second Run() = %+v, want a comparison with the recorded baseline", out)
+	}
+}
+
+// summaryOf returns a summary of the wordpress workload with the process entries
+func summaryOf(processes ...[2]string) string {
+	var entries []string
+	for _, p := range processes {
+		entries = append(entries, fmt.Sprintf(`{"Source": %q, "Destination": %q, "Count": "1", "UpdatedTime": "Mon Jul  3 08:26:40 UTC 2023", "Status": "Allow"}`, p[0], p[1]))
+	}
+	return `[{"DeploymentName": "wordpress", "PodName": "wordpress-7c966b5d85-wvtln", "ClusterName": "default", "Namespace": "wordpress-mysql", "Label": "app=wordpress", "ContainerName": "wordpress", "ProcessData": [` + strings.Join(entries, ", ") + `]}]`
+}
+
+func TestRunMaxGrade(t *testing.T) {
+	apache := [2]string{"/usr/sbin/apache2", "/usr/sbin/apache2"}
+	// the shell spawned by apache is KA-PROC-001, high: 15 + 35 is grade C
+	shell := [2]string{"/usr/sbin/apache2", "/bin/sh"}
+	tests := []struct {
+		name     string
+		maxGrade string
+		wantErr  string
+	}{
+		{name: "no gate", maxGrade: ""},
+		{name: "worse", maxGrade: "B", wantErr: "risk grade C is worse than the maximum grade B"},
+		{name: "same", maxGrade: "C"},
+		{name: "better", maxGrade: "F"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inTempDir(t)
+			old, summary := filepath.Join(dir, "old.json"), filepath.Join(dir, "summary.json")
+			if err := os.WriteFile(old, []byte(summaryOf(apache)), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(summary, []byte(summaryOf(apache, shell)), 0600); err != nil {
+				t.Fatal(err)
+			}
+			store := fakeStore(t, map[baseline.Key]string{{Branch: "main", Commit: "base"}: old})
+			action, _ := fakeAction(map[string]string{"GITHUB_BASE_REF": "main"})
+			out, err := Run(context.Background(), action, Inputs{
+				BaselineStore: store,
+				File:          summary,
+				MaxGrade:      tt.maxGrade,
+			})
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			// the outputs are returned with the gate error
+			if out.FirstRun || out.RiskGrade != "C" || out.RiskScore != 50 {
+				t.Errorf("Run() = %+v, want grade C, score 50", out)
+			}
+			if out.Baseline != "main/_all@base (base-ref)" {
+				t.Errorf("Run() baseline = %q, want main/_all@base (base-ref)", out.Baseline)
+			}
+			if out.DiffFile != DiffFile || out.ReportFile != ReportFile {
+				t.Errorf("Run() files = %q, %q, want %q, %q", out.DiffFile, out.ReportFile, DiffFile, ReportFile)
+			}
+		})
+	}
+}
+
+func TestRunNothingToAnalyse(t *testing.T) {
+	inTempDir(t)
+	action, _ := fakeAction(nil)
+	out, err := Run(context.Background(), action, Inputs{File: "summary.json", MaxGrade: "A"})
+	if err != nil || out != (Outputs{}) {
+		t.Errorf("Run() = %+v, %v, want no outputs and no error", out, err)
+	}
+}
+
+func TestOutputsSet(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "output")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	action, _ := fakeAction(map[string]string{"GITHUB_OUTPUT": file})
+	Outputs{
+		DiffFile:   DiffFile,
+		ReportFile: ReportFile,
+		RiskGrade:  "C",
+		RiskScore:  45,
+		Baseline:   "main/all (base-ref)",
+	}.Set(action)
+	data, err := os.ReadFile(file) // #nosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{"baseline<<", "main/all (base-ref)", "diff-file<<", DiffFile, "risk-grade<<", "risk-score<<", "45"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("$GITHUB_OUTPUT = %q, want %q", got, want)
+		}
+	}
+	// the unset outputs and first-run of a comparison are not written
+	for _, unwanted := range []string{"first-run", "sys-visualisation-image", "summary-report-file"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("$GITHUB_OUTPUT = %q, want no %s", got, unwanted)
+		}
+	}
+
+	// without risk grade, the score is not written
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	Outputs{FirstRun: true}.Set(action)
+	if data, _ = os.ReadFile(file); !strings.Contains(string(data), "first-run<<") || strings.Contains(string(data), "risk-score") {
+		t.Errorf("$GITHUB_OUTPUT = %q, want first-run and no risk-score", data)
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	_ "embed" // embeds the ATT&CK catalog
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+)
+
+//go:embed catalog/attack.json
+var attackCatalogJSON []byte
+
+// Technique is a MITRE ATT&CK technique
+type Technique struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Tactics []string `json:"tactics"`
+	// URL is the ATT&CK page of the technique, derived from the ID if not set
+	URL string `json:"url,omitempty"`
+}
+
+// techniqueURL returns the ATT&CK page of a technique, eg.: https://attack.mitre.org/techniques/T1059/004/
+func techniqueURL(id string) string {
+	sub := ""
+	if len(id) > 5 && id[5] == '.' {
+		id, sub = id[:5], id[6:]+"/"
+	}
+	return "https://attack.mitre.org/techniques/" + id + "/" + sub
+}
+
+// AttackMapping maps the findings of rules, or the behaviors matching the criteria, to techniques.
+// The criteria are those of Rule. A rule may map to no technique, the note says why.
+type AttackMapping struct {
+	Rules          []string `json:"rules,omitempty"`
+	Kinds          []string `json:"kinds,omitempty"`
+	SourceBinaries []string `json:"sourceBinaries,omitempty"`
+	Binaries       []string `json:"binaries,omitempty"`
+	Paths          []string `json:"paths,omitempty"`
+	Destinations   []string `json:"destinations,omitempty"`
+	Techniques     []string `json:"techniques"`
+	Note           string   `json:"note,omitempty"`
+
+	matcher *behaviorMatcher
+}
+
+// AttackCatalog is a versioned catalog of ATT&CK techniques and of the mappings of behaviors to them
+type AttackCatalog struct {
+	// Version is the version of the catalog, AttackVersion the ATT&CK version it is based on
+	Version       string          `json:"version"`
+	AttackVersion string          `json:"attackVersion"`
+	Matrix        string          `json:"matrix"`
+	Techniques    []Technique     `json:"techniques"`
+	Mappings      []AttackMapping `json:"mappings"`
+
+	techniques map[string]Technique
+}
+
+// builtinAttackCatalog is the catalog embedded in the binary
+var builtinAttackCatalog = mustAttackCatalog(attackCatalogJSON)
+
+// BuiltinAttackCatalog returns the ATT&CK Enterprise catalog embedded in the binary, it covers
+// the Linux and Containers platforms
+func BuiltinAttackCatalog() *AttackCatalog {
+	return builtinAttackCatalog
+}
+
+// ParseAttackCatalog parses and checks a catalog, every mapped technique must be in the catalog
+func ParseAttackCatalog(data []byte) (*AttackCatalog, error) {
+	c := &AttackCatalog{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parsing the ATT&CK catalog: %w", err)
+	}
+	c.techniques = make(map[string]Technique, len(c.Techniques))
+	for i := range c.Techniques {
+		t := &c.Techniques[i]
+		if t.URL == "" {
+			t.URL = techniqueURL(t.ID)
+		}
+		c.techniques[t.ID] = *t
+	}
+	for i := range c.Mappings {
+		m := &c.Mappings[i]
+		for _, id := range m.Techniques {
+			if _, ok := c.techniques[id]; !ok {
+				return nil, fmt.Errorf("ATT&CK catalog mapping %d: unknown technique %s", i, id)
+			}
+		}
+		if len(m.Kinds) == 0 {
+			continue
+		}
+		matcher, err := newBehaviorMatcher(m.Kinds, m.SourceBinaries, m.Binaries, m.Paths, m.Destinations)
+		if err != nil {
+			return nil, fmt.Errorf("ATT&CK catalog mapping %d: %w", i, err)
+		}
+		m.matcher = matcher
+	}
+	return c, nil
+}
+
+// mustAttackCatalog parses a catalog that is known to be valid
+func mustAttackCatalog(data []byte) *AttackCatalog {
+	c, err := ParseAttackCatalog(data)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// String returns the catalog version, eg.: ATT&CK v14.1 Enterprise (catalog 2)
+func (c *AttackCatalog) String() string {
+	return fmt.Sprintf("ATT&CK v%s %s (catalog %s)", c.AttackVersion, c.Matrix, c.Version)
+}
+
+// Technique returns the technique with the ID
+func (c *AttackCatalog) Technique(id string) (Technique, bool) {
+	t, ok := c.techniques[id]
+	return t, ok
+}
+
+// Map returns the IDs of the techniques of a behavior and of the findings of the rules on it, in order
+func (c *AttackCatalog) Map(b Behavior, ruleIDs ...string) []string {
+	set := make(map[string]bool)
+	for _, m := range c.Mappings {
+		matched := m.matcher != nil && m.matcher.match(b)
+		for _, r := range m.Rules {
+			for _, id := range ruleIDs {
+				matched = matched || r == id
+			}
+		}
+		if !matched {
+			continue
+		}
+		for _, id := range m.Techniques {
+			set[id] = true
+		}
+	}
+	return setKeys(set)
+}
+
+// TechniqueObservation is a technique observed in a workload, with the behaviors it was observed in
+type TechniqueObservation struct {
+	Workload  DiffWorkload `json:"Workload"`
+	Technique Technique    `json:"Technique"`
+	Behaviors []Behavior   `json:"Behaviors"`
+}
+
+// MapSummaryData maps the behaviors of the workloads selected by the filter, and the findings
+// of the rules of the classifier on them, to techniques
+func (c *AttackCatalog) MapSummaryData(summaryDatas []*SummaryData, f *filter.Filter, cl *Classifier) []TechniqueObservation {
+	type key struct {
+		workload  DiffWorkload
+		technique string
+	}
+	observations := make(map[key]*TechniqueObservation)
+	for _, sd := range summaryDatas {
+		if !f.MatchWorkload(filterWorkload(sd)) {
+			continue
+		}
+		w := DiffWorkloadOf(sd)
+		for _, b := range BehaviorsOf(sd, f) {
+			var ruleIDs []string
+			for _, finding := range cl.Classify(b) {
+				ruleIDs = append(ruleIDs, finding.RuleID)
+			}
+			for _, id := range c.Map(b, ruleIDs...) {
+				k := key{workload: w, technique: id}
+				if _, ok := observations[k]; !ok {
+					observations[k] = &TechniqueObservation{Workload: w, Technique: c.techniques[id]}
+				}
+				observations[k].Behaviors = append(observations[k].Behaviors, b)
+			}
+		}
+	}
+	result := make([]TechniqueObservation, 0, len(observations))
+	for _, o := range observations {
+		sortBehaviors(o.Behaviors)
+		result = append(result, *o)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Workload != result[j].Workload {
+			return result[i].Workload.String() < result[j].Workload.String()
+		}
+		return result[i].Technique.ID < result[j].Technique.ID
+	})
+	return result
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinAttackCatalogRules(t *testing.T) {
+	c := BuiltinAttackCatalog()
+	if c.Matrix != "Enterprise" {
+		t.Errorf("Matrix = %q, want Enterprise", c.Matrix)
+	}
+	rules := make(map[string]bool)
+	for _, r := range BuiltinRules {
+		rules[r.ID] = true
+	}
+	mapped := make(map[string]bool)
+	used := make(map[string]bool)
+	for i, m := range c.Mappings {
+		for _, id := range m.Rules {
+			if !rules[id] {
+				t.Errorf("mapping %d: unknown rule %s", i, id)
+			}
+			mapped[id] = true
+		}
+		if len(m.Techniques) == 0 && m.Note == "" {
+			t.Errorf("mapping %d maps to no technique without a note", i)
+		}
+		for _, id := range m.Techniques {
+			used[id] = true
+		}
+	}
+	for id := range rules {
+		if !mapped[id] {
+			t.Errorf("rule %s is not in the catalog", id)
+		}
+	}
+	for _, tech := range c.Techniques {
+		if !used[tech.ID] {
+			t.Errorf("technique %s is not mapped", tech.ID)
+		}
+	}
+}
+
+func TestAttackCatalogMap(t *testing.T) {
+	c := BuiltinAttackCatalog()
+	tests := []struct {
+		name string
+		b    Behavior
+		want string
+	}{
+		{name: "shell from runtime", b: Behavior{Kind: KindProcess, Source: "/usr/sbin/php-fpm8.2", Destination: "/bin/sh"}, want: "T1059.004"},
+		{name: "interpreter from shell", b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/python3.11"}, want: "T1059"},
+		{name: "download tool", b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/curl"}, want: "T1105"},
+		{name: "package manager", b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/apt-get"}},
+		{name: "token", b: Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/var/run/secrets/kubernetes.io/serviceaccount/token"}, want: "T1528"},
+		{name: "metadata", b: Behavior{Kind: KindEgress, Source: "/usr/bin/python3", Destination: "169.254.169.254"}, want: "T1552.005"},
+		{name: "unmapped", b: Behavior{Kind: KindFile, Source: "/usr/sbin/nginx", Destination: "/etc/nginx/nginx.conf"}},
+	}
+	for _, tt := range tests {
+		var ruleIDs []string
+		for _, f := range BuiltinClassifier().Classify(tt.b) {
+			ruleIDs = append(ruleIDs, f.RuleID)
+		}
+		if got := strings.Join(c.Map(tt.b, ruleIDs...), ","); got != tt.want {
+			t.Errorf("%s: Map() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTechniqueURL(t *testing.T) {
+	for id, want := range map[string]string{
+		"T1059":     "https://attack.mitre.org/techniques/T1059/",
+		"T1059.004": "https://attack.mitre.org/techniques/T1059/004/",
+	} {
+		if got := techniqueURL(id); got != want {
+			t.Errorf("techniqueURL(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+)
+
+// Severity is how sensitive a behavior is
+type Severity string
+
+// Severities, from the least to the most severe
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// severityRanks orders the severities, unknown severities rank below low
+var severityRanks = map[Severity]int{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// Rank returns the rank of the severity, higher is more severe
+func (s Severity) Rank() int {
+	return severityRanks[s]
+}
+
+// Rule classifies behaviors as sensitive. A behavior matches the rule if its kind is one
+// of the rule kinds and every criterion that is set matches.
+type Rule struct {
+	ID        string   `json:"ID"`
+	Title     string   `json:"Title"`
+	Severity  Severity `json:"Severity"`
+	Rationale string   `json:"Rationale"`
+	// Kinds are the kinds of behaviors the rule applies to: process, file, ingress, egress or bind
+	Kinds []string `json:"Kinds"`
+	// SourceBinaries are the names of the processes, eg.: php, that perform the behavior
+	SourceBinaries []string `json:"SourceBinaries,omitempty"`
+	// Binaries are the names of the executed processes, eg.: sh
+	Binaries []string `json:"Binaries,omitempty"`
+	// Paths are glob patterns of the executed or accessed paths, "**" matches across "/"
+	Paths []string `json:"Paths,omitempty"`
+	// Destinations are the IPs or CIDRs of the connection peers
+	Destinations []string `json:"Destinations,omitempty"`
+}
+
+// Finding is a behavior matching a rule
+type Finding struct {
+	RuleID    string       `json:"RuleID"`
+	Title     string       `json:"Title"`
+	Severity  Severity     `json:"Severity"`
+	Rationale string       `json:"Rationale"`
+	Workload  DiffWorkload `json:"Workload"`
+	Behavior  Behavior     `json:"Behavior"`
+	// Techniques are the MITRE ATT&CK technique IDs of the behavior, eg.: T1059.004
+	Techniques []string `json:"Techniques,omitempty"`
+	// New is true if the behavior is not in the old summary
+	New bool `json:"New,omitempty"`
+}
+
+// String returns the finding as "high KA-FILE-001 Read of the shadow password file: /bin/cat -> /etc/shadow [T1003.008]"
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s %s %s: %s", f.Severity, f.RuleID, f.Title, f.Behavior)
+	if len(f.Techniques) > 0 {
+		s += " [" + strings.Join(f.Techniques, " ") + "]"
+	}
+	return s
+}
+
+// packageManagers are the binaries installing software
+var packageManagers = []string{"apt", "apt-get", "aptitude", "dpkg", "yum", "dnf", "rpm", "microdnf", "apk", "zypper",
+	"pip", "npm", "yarn", "gem", "cargo"}
+
+// BuiltinRules is the curated rule pack of sensitive behaviors
+var BuiltinRules = []Rule{
+	{
+		ID: "KA-FILE-001", Title: "Read of the shadow password file", Severity: SeverityHigh, Kinds: []string{KindFile},
+		Paths:     []string{"/etc/shadow", "/etc/gshadow", "/etc/shadow-", "/etc/gshadow-"},
+		Rationale: "The shadow files hold the password hashes of the users, applications have no reason to read them.",
+	},
+	{
+		ID: "KA-FILE-002", Title: "Read of the service account token", Severity: SeverityHigh, Kinds: []string{KindFile},
+		Paths:     []string{"/var/run/secrets/kubernetes.io/serviceaccount/token", "/run/secrets/kubernetes.io/serviceaccount/token"},
+		Rationale: "The token authenticates the pod to the Kubernetes API, a stolen token gives its permissions to an attacker.",
+	},
+	{
+		ID: "KA-FILE-003", Title: "Read of process environment", Severity: SeverityMedium, Kinds: []string{KindFile},
+		Paths:     []string{"/proc/*/environ"},
+		Rationale: "Environment variables often hold credentials and API keys.",
+	},
+	{
+		ID: "KA-FILE-004", Title: "Access to SSH keys", Severity: SeverityHigh, Kinds: []string{KindFile},
+		Paths:     []string{"/root/.ssh/**", "/home/*/.ssh/**"},
+		Rationale: "SSH keys give access to other hosts, containers should not use them.",
+	},
+	{
+		ID: "KA-PROC-001", Title: "Shell spawned by an application runtime", Severity: SeverityHigh, Kinds: []string{KindProcess},
+		SourceBinaries: setKeys(appRuntimes), Binaries: setKeys(shells),
+		Rationale: "Application runtimes seldom need a shell, it is the usual outcome of a command injection or a web shell.",
+	},
+	{
+		ID: "KA-PROC-002", Title: "Package manager run in a container", Severity: SeverityMedium, Kinds: []string{KindProcess},
+		Binaries:  packageManagers,
+		Rationale: "Containers are immutable, installing software at runtime drifts from the image and is a common attacker step.",
+	},
+	{
+		ID: "KA-PROC-003", Title: "Binary executed from a temporary directory", Severity: SeverityHigh, Kinds: []string{KindProcess},
+		Paths:     []string{"/tmp/**", "/var/tmp/**", "/dev/shm/**"},
+		Rationale: "Temporary directories are writable by everyone, payloads dropped by attackers are executed from there.",
+	},
+	{
+		ID: "KA-PROC-004", Title: "Network tool executed", Severity: SeverityLow, Kinds: []string{KindProcess},
+		Binaries:  setKeys(networkTools),
+		Rationale: "Network tools are used to download payloads, open reverse shells or move laterally.",
+	},
+	{
+		ID: "KA-PROC-005", Title: "Container or cluster discovery command", Severity: SeverityLow, Kinds: []string{KindProcess},
+		Binaries:  []string{"kubectl", "crictl", "docker", "ctr", "nerdctl", "podman", "helm"},
+		Rationale: "Workloads seldom query the container runtime or the Kubernetes API from the command line, it is a discovery step.",
+	},
+	{
+		ID: "KA-NET-001", Title: "Egress to the cloud metadata service", Severity: SeverityCritical, Kinds: []string{KindEgress},
+		Destinations: []string{"169.254.169.254", "fd00:ec2::254", "100.100.100.200", "metadata.google.internal"},
+		Rationale:    "The metadata service hands out the cloud credentials of the node, pods should not reach it.",
+	},
+}
+
+// behaviorMatcher matches behaviors by kind, process names, paths and peers
+type behaviorMatcher struct {
+	kinds          map[string]bool
+	sourceBinaries map[string]bool
+	binaries       map[string]bool
+	paths          []*regexp.Regexp
+	ips            map[string]bool
+	cidrs          []*net.IPNet
+}
+
+// newBehaviorMatcher compiles the criteria of a matcher, see Rule
+func newBehaviorMatcher(kinds, sourceBinaries, binaries, paths, destinations []string) (*behaviorMatcher, error) {
+	m := &behaviorMatcher{
+		kinds:          filter.ToSet(kinds),
+		sourceBinaries: binarySet(sourceBinaries),
+		binaries:       binarySet(binaries),
+		ips:            make(map[string]bool),
+	}
+	var err error
+	m.paths, err = compileGlobs(paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range destinations {
+		if _, cidr, err := net.ParseCIDR(d); err == nil {
+			m.cidrs = append(m.cidrs, cidr)
+			continue
+		}
+		m.ips[d] = true
+	}
+	return m, nil
+}
+
+// compiledRule is a rule with its criteria compiled
+type compiledRule struct {
+	Rule
+	*behaviorMatcher
+}
+
+// Classifier classifies behaviors with rules, the nil Classifier finds nothing
+type Classifier struct {
+	rules []*compiledRule
+}
+
+// builtinClassifier is the classifier of the built-in rule pack
+var builtinClassifier = mustClassifier(BuiltinRules)
+
+// BuiltinClassifier returns the classifier of the built-in rule pack
+func BuiltinClassifier() *Classifier {
+	return builtinClassifier
+}
+
+// NewClassifier compiles the rules into a Classifier
+func NewClassifier(rules []Rule) (*Classifier, error) {
+	c := &Classifier{}
+	for _, rule := range rules {
+		if rule.ID == "" || len(rule.Kinds) == 0 {
+			return nil, fmt.Errorf("rule %q: an ID and kinds are required", rule.ID)
+		}
+		if rule.Severity.Rank() == 0 {
+			return nil, fmt.Errorf("rule %s: invalid severity %q", rule.ID, rule.Severity)
+		}
+		m, err := newBehaviorMatcher(rule.Kinds, rule.SourceBinaries, rule.Binaries, rule.Paths, rule.Destinations)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		c.rules = append(c.rules, &compiledRule{Rule: rule, behaviorMatcher: m})
+	}
+	return c, nil
+}
+
+// mustClassifier compiles rules that are known to be valid
+func mustClassifier(rules []Rule) *Classifier {
+	c, err := NewClassifier(rules)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Classify returns the findings of the rules matching the behavior, without workload
+func (c *Classifier) Classify(b Behavior) []Finding {
+	if c == nil {
+		return nil
+	}
+	var findings []Finding
+	for _, r := range c.rules {
+		if r.match(b) {
+			findings = append(findings, Finding{RuleID: r.ID, Title: r.Title, Severity: r.Severity, Rationale: r.Rationale, Behavior: b,
+				Techniques: BuiltinAttackCatalog().Map(b, r.ID)})
+		}
+	}
+	return findings
+}
+
+// ClassifySummaryData returns the findings of the workloads and behaviors selected by the filter,
+// the most severe first
+func (c *Classifier) ClassifySummaryData(summaryDatas []*SummaryData, f *filter.Filter) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, sd := range summaryDatas {
+		if !f.MatchWorkload(filterWorkload(sd)) {
+			continue
+		}
+		w := DiffWorkloadOf(sd)
+		for _, b := range BehaviorsOf(sd, f) {
+			for _, finding := range c.Classify(b) {
+				key := w.String() + "\x00" + finding.RuleID + "\x00" + finding.Behavior.String()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				finding.Workload = w
+				findings = append(findings, finding)
+			}
+		}
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// match reports whether the behavior matches every criterion that is set
+func (r *behaviorMatcher) match(b Behavior) bool {
+	if !r.kinds[b.Kind] {
+		return false
+	}
+	if len(r.sourceBinaries) > 0 && !r.sourceBinaries[binaryName(b.Source)] {
+		return false
+	}
+	if len(r.binaries) > 0 && !r.binaries[binaryName(b.Destination)] {
+		return false
+	}
+	dst := strings.TrimSpace(b.Destination)
+	if len(r.paths) > 0 && !matchAny(r.paths, dst) {
+		return false
+	}
+	if len(r.ips) > 0 || len(r.cidrs) > 0 {
+		if r.ips[dst] {
+			return true
+		}
+		ip := net.ParseIP(dst)
+		if ip == nil {
+			return false
+		}
+		for _, cidr := range r.cidrs {
+			if cidr.Contains(ip) {
+				return true
+			}
+		}
+		// the IPs are compared parsed, eg.: fd00:ec2:0::254
+		for s := range r.ips {
+			if other := net.ParseIP(s); other != nil && other.Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// sortFindings sorts findings by severity, the most severe first, then by rule, workload and behavior
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() > b.Severity.Rank()
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		if a.Workload != b.Workload {
+			return a.Workload.String() < b.Workload.String()
+		}
+		return lessBehavior(a.Behavior, b.Behavior)
+	})
+}
+
+// setKeys returns the keys of a set, in order
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// binarySet converts a list of binaries into a set of binary names, see binaryName
+func binarySet(binaries []string) map[string]bool {
+	set := make(map[string]bool, len(binaries))
+	for _, b := range binaries {
+		set[binaryName(b)] = true
+	}
+	return set
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"testing"
+)
+
+// ruleIDs returns the rule IDs of the findings, comma separated
+func ruleIDs(findings []Finding) string {
+	ids := make([]string, 0, len(findings))
+	for _, f := range findings {
+		ids = append(ids, f.RuleID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestBuiltinClassifier(t *testing.T) {
+	tests := []struct {
+		b    Behavior
+		want string
+	}{
+		{b: Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/etc/shadow"}, want: "KA-FILE-001"},
+		{b: Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/etc/passwd"}, want: ""},
+		{b: Behavior{Kind: KindFile, Source: "/bin/sh", Destination: "/var/run/secrets/kubernetes.io/serviceaccount/token"}, want: "KA-FILE-002"},
+		{b: Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/proc/1/environ"}, want: "KA-FILE-003"},
+		{b: Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/proc/1/task/2/environ"}, want: ""},
+		{b: Behavior{Kind: KindFile, Source: "/usr/bin/ssh", Destination: "/home/app/.ssh/id_rsa"}, want: "KA-FILE-004"},
+		{b: Behavior{Kind: KindFile, Source: "/bin/sh", Destination: "/usr/bin/curl"}, want: ""},
+		{b: Behavior{Kind: KindProcess, Source: "/usr/sbin/php-fpm8.2", Destination: "/bin/bash"}, want: "KA-PROC-001"},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/bash", Destination: "/bin/sh"}, want: ""},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/apt-get"}, want: "KA-PROC-002"},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/pip3"}, want: "KA-PROC-002"},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/tmp/x"}, want: "KA-PROC-003"},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/curl"}, want: "KA-PROC-004"},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/tmp/curl"}, want: "KA-PROC-003,KA-PROC-004"},
+		{b: Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/local/bin/kubectl"}, want: "KA-PROC-005"},
+		{b: Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: "169.254.169.254"}, want: "KA-NET-001"},
+		{b: Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: " 169.254.169.254 "}, want: "KA-NET-001"},
+		{b: Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: "fd00:ec2:0::254"}, want: "KA-NET-001"},
+		{b: Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: "metadata.google.internal"}, want: "KA-NET-001"},
+		{b: Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: "10.0.0.1"}, want: ""},
+		{b: Behavior{Kind: KindIngress, Source: "/usr/sbin/nginx", Destination: "169.254.169.254"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := ruleIDs(BuiltinClassifier().Classify(tt.b)); got != tt.want {
+			t.Errorf("Classify(%s %s) = %q, want %q", tt.b.Kind, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNewClassifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "valid", rule: Rule{ID: "X-1", Severity: SeverityLow, Kinds: []string{KindEgress}, Destinations: []string{"10.0.0.0/8"}}},
+		{name: "no ID", rule: Rule{Severity: SeverityLow, Kinds: []string{KindEgress}}, wantErr: true},
+		{name: "no kinds", rule: Rule{ID: "X-1", Severity: SeverityLow}, wantErr: true},
+		{name: "invalid severity", rule: Rule{ID: "X-1", Severity: "urgent", Kinds: []string{KindEgress}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClassifier([]Rule{tt.rule}); (err != nil) != tt.wantErr {
+				t.Errorf("NewClassifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClassifierDestinations(t *testing.T) {
+	c, err := NewClassifier([]Rule{{ID: "X-1", Severity: SeverityLow, Kinds: []string{KindEgress}, Destinations: []string{"10.0.0.0/8", "2001:db8::1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		destination string
+		want        bool
+	}{
+		{destination: "10.1.2.3", want: true},
+		{destination: "192.168.0.1", want: false},
+		{destination: "2001:db8:0::1", want: true},
+		{destination: "svc/mysql", want: false},
+	}
+	for _, tt := range tests {
+		got := len(c.Classify(Behavior{Kind: KindEgress, Destination: tt.destination})) > 0
+		if got != tt.want {
+			t.Errorf("Classify(%s) matched = %v, want %v", tt.destination, got, tt.want)
+		}
+	}
+	var nilClassifier *Classifier
+	if findings := nilClassifier.Classify(Behavior{Kind: KindEgress, Destination: "10.1.2.3"}); findings != nil {
+		t.Errorf("nil Classify() = %v, want no findings", findings)
+	}
+}
+
+func TestClassifySummaryData(t *testing.T) {
+	sds := []*SummaryData{
+		{
+			Namespace: "wordpress-mysql", DeploymentName: "wordpress-7c966b5d85", PodName: "wordpress-7c966b5d85-wvtln", ContainerName: "wordpress",
+			ProcessData: []ProcessData{{Source: "/bin/sh", Destination: "/usr/bin/curl", Count: 1, Status: StatusAllow}},
+			FileData:    []FileData{{Source: "/bin/cat", Destination: "/etc/shadow", Count: 1, Status: StatusBlock}},
+		},
+		// another pod of the deployment, the findings are not repeated
+		{
+			Namespace: "wordpress-mysql", DeploymentName: "wordpress-7c966b5d85", PodName: "wordpress-7c966b5d85-x9z2k", ContainerName: "wordpress",
+			ProcessData: []ProcessData{{Source: "/bin/sh", Destination: "/usr/bin/curl", Count: 3, Status: StatusAllow}},
+		},
+		{
+			Namespace: "default", PodName: "debug", ContainerName: "debug",
+			EgressConnection: []EgressConnection{{Command: "/usr/bin/curl", IP: "169.254.169.254", Protocol: "TCP", Port: "80"}},
+		},
+	}
+	var got []string
+	for _, f := range BuiltinClassifier().ClassifySummaryData(sds, nil) {
+		got = append(got, f.Workload.String()+" "+f.String())
+	}
+	want := []string{
+		"default/pod/debug/debug critical KA-NET-001 Egress to the cloud metadata service: /usr/bin/curl -> 169.254.169.254 (TCP/80) [T1552.005]",
+		"wordpress-mysql/wordpress/wordpress high KA-FILE-001 Read of the shadow password file: /bin/cat -> /etc/shadow [T1003.008]",
+		"wordpress-mysql/wordpress/wordpress low KA-PROC-004 Network tool executed: /bin/sh -> /usr/bin/curl [T1105]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ClassifySummaryData() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSeverityRank(t *testing.T) {
+	order := []Severity{"", SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+	for i := 1; i < len(order); i++ {
+		if order[i].Rank() <= order[i-1].Rank() {
+			t.Errorf("%q.Rank() = %d, want more than %q.Rank() = %d", order[i], order[i].Rank(), order[i-1], order[i-1].Rank())
+		}
+	}
+}
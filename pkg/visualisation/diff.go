@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	"k8s.io/klog"
+)
+
+// Kinds of behaviors
+const (
+	KindProcess = "process"
+	KindFile    = "file"
+	KindIngress = "ingress"
+	KindEgress  = "egress"
+	KindBind    = "bind"
+)
+
+// Behavior is a process, file or network entry of a workload, flattened so that
+// all the kinds can be compared and classified the same way.
+// For processes and files, Source is the process and Destination the process or file path,
+// for connections, Source is the command and Destination the peer IP or the bind address.
+type Behavior struct {
+	Kind        string `json:"Kind"`
+	Source      string `json:"Source,omitempty"`
+	Destination string `json:"Destination,omitempty"`
+	Protocol    string `json:"Protocol,omitempty"`
+	Port        string `json:"Port,omitempty"`
+	Status      string `json:"Status,omitempty"`
+	Count       Count  `json:"Count,omitempty"`
+	// Namespace is the namespace of the connection peer, if known
+	Namespace string `json:"Namespace,omitempty"`
+	// Risk is the risk score of the change, from 0 to 100, and RiskFactors what it is made of
+	Risk        int      `json:"Risk,omitempty"`
+	RiskFactors []string `json:"RiskFactors,omitempty"`
+}
+
+// behaviorKey identifies a behavior regardless of its status and count
+type behaviorKey struct {
+	kind        string
+	source      string
+	destination string
+	protocol    string
+	port        string
+}
+
+// key returns the identity of the behavior
+func (b Behavior) key() behaviorKey {
+	return behaviorKey{kind: b.Kind, source: b.Source, destination: b.Destination, protocol: b.Protocol, port: b.Port}
+}
+
+// String returns the behavior as source -> destination, eg.: /usr/bin/curl -> svc/mysql (TCP/3306)
+func (b Behavior) String() string {
+	s := strings.TrimSpace(b.Source) + " -> " + strings.TrimSpace(b.Destination)
+	if b.Protocol != "" || b.Port != "" {
+		s += " (" + b.Protocol + "/" + b.Port + ")"
+	}
+	return s
+}
+
+// BehaviorsOf returns the behaviors of a summary data entry selected by the filter
+func BehaviorsOf(sd *SummaryData, f *filter.Filter) []Behavior {
+	var bs []Behavior
+	for _, ps := range sd.ProcessData {
+		status := NormalizeStatus(ps.Status)
+		if !f.MatchExec(ps.Source, ps.Destination) || !f.MatchStatus(status) {
+			continue
+		}
+		bs = append(bs, Behavior{Kind: KindProcess, Source: ps.Source, Destination: ps.Destination, Status: status, Count: ps.Count})
+	}
+	for _, file := range sd.FileData {
+		status := NormalizeStatus(file.Status)
+		if !f.MatchFileAccess(file.Source, file.Destination) || !f.MatchStatus(status) {
+			continue
+		}
+		bs = append(bs, Behavior{Kind: KindFile, Source: file.Source, Destination: file.Destination, Status: status, Count: file.Count})
+	}
+	// connections carry no status, they were allowed
+	if !f.MatchStatus(StatusAllow) {
+		return bs
+	}
+	for _, net := range sd.IngressConnection {
+		if !f.MatchProcess(net.Command) {
+			continue
+		}
+		bs = append(bs, Behavior{Kind: KindIngress, Source: net.Command, Destination: net.IP, Protocol: net.Protocol, Port: net.Port,
+			Status: StatusAllow, Count: net.Count, Namespace: net.Namespace})
+	}
+	for _, net := range sd.EgressConnection {
+		if !f.MatchProcess(net.Command) {
+			continue
+		}
+		bs = append(bs, Behavior{Kind: KindEgress, Source: net.Command, Destination: net.IP, Protocol: net.Protocol, Port: net.Port,
+			Status: StatusAllow, Count: net.Count, Namespace: net.Namespace})
+	}
+	for _, bind := range sd.BindConnection {
+		if !f.MatchProcess(bind.Command) {
+			continue
+		}
+		bs = append(bs, Behavior{Kind: KindBind, Source: bind.Command, Destination: bind.BindAddress, Protocol: bind.Protocol, Port: bind.BindPort,
+			Status: StatusAllow, Count: bind.Count})
+	}
+	return bs
+}
+
+// DiffWorkload identifies a workload across runs: pod names and replica set hashes
+// change from run to run, so only the namespace, the deployment and the container are kept
+type DiffWorkload struct {
+	Namespace  string `json:"Namespace"`
+	Deployment string `json:"Deployment,omitempty"`
+	Container  string `json:"Container,omitempty"`
+}
+
+// String returns the workload as namespace/deployment[/container]
+func (w DiffWorkload) String() string {
+	s := w.Namespace + "/" + w.Deployment
+	if w.Container != "" {
+		s += "/" + w.Container
+	}
+	return s
+}
+
+// DiffWorkloadOf returns the workload of a summary data entry, without the pod-template-hash
+func DiffWorkloadOf(sd *SummaryData) DiffWorkload {
+	deployment := filter.DeploymentName(sd.DeploymentName)
+	if deployment == "" {
+		deployment = "pod/" + sd.PodName
+	}
+	return DiffWorkload{Namespace: sd.Namespace, Deployment: deployment, Container: sd.ContainerName}
+}
+
+// StatusChange is a behavior whose status changed, eg.: it is now blocked
+type StatusChange struct {
+	Behavior
+	OldStatus string `json:"OldStatus"`
+}
+
+// WorkloadDiff is the behaviors of a workload that were added, removed or changed status
+type WorkloadDiff struct {
+	Workload DiffWorkload   `json:"Workload"`
+	Added    []Behavior     `json:"Added,omitempty"`
+	Removed  []Behavior     `json:"Removed,omitempty"`
+	Changed  []StatusChange `json:"Changed,omitempty"`
+	// Volume are the behaviors in both summaries whose count spiked or dropped
+	Volume []VolumeChange `json:"Volume,omitempty"`
+	// Findings are the sensitive behaviors of the workload in the new summary
+	Findings []Finding `json:"Findings,omitempty"`
+}
+
+// DiffReport is the difference of behaviors between two summaries
+type DiffReport struct {
+	// Risk is the overall risk of the changes, shown first
+	Risk RiskSummary `json:"Risk"`
+	// Attack is the version of the ATT&CK catalog the techniques of the findings come from
+	Attack    string          `json:"Attack"`
+	Workloads []*WorkloadDiff `json:"Workloads"`
+}
+
+// IsEmpty reports whether no behavior changed
+func (r *DiffReport) IsEmpty() bool {
+	for _, w := range r.Workloads {
+		if len(w.Added) > 0 || len(w.Removed) > 0 || len(w.Changed) > 0 || len(w.Volume) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Findings returns the findings of all the workloads, the most severe first
+func (r *DiffReport) Findings() []Finding {
+	var findings []Finding
+	for _, w := range r.Workloads {
+		findings = append(findings, w.Findings...)
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// DiffOptions configures the comparison of two summaries
+type DiffOptions struct {
+	// Volume classifies the count changes of the behaviors in both summaries
+	Volume VolumeThresholds
+}
+
+// Diff compares the behaviors of the old and the new summaries, workload by workload.
+// Only the workloads and behaviors selected by the filter are compared. The behaviors
+// of the new summary are classified with c, the findings on added behaviors are marked New,
+// and the changes are scored, see ScoreBehavior.
+// The volume changes are classified with the default thresholds, see DiffOptions.Diff.
+func Diff(sdOlds, sdNews []*SummaryData, f *filter.Filter, c *Classifier) *DiffReport {
+	return DiffOptions{}.Diff(sdOlds, sdNews, f, c)
+}
+
+// Diff compares the behaviors of the old and the new summaries like Diff, the behaviors in both
+// summaries whose count spiked or dropped beyond the volume thresholds are reported as well
+func (o DiffOptions) Diff(sdOlds, sdNews []*SummaryData, f *filter.Filter, c *Classifier) *DiffReport {
+	olds, _ := behaviorsByWorkload(sdOlds, f)
+	news, order := behaviorsByWorkload(sdNews, f)
+	for w := range olds {
+		if _, ok := news[w]; !ok {
+			order = append(order, w)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].String() < order[j].String() })
+
+	report := &DiffReport{Attack: BuiltinAttackCatalog().String()}
+	for _, w := range order {
+		old, cur := olds[w], news[w]
+		wd := &WorkloadDiff{Workload: w}
+		for k, b := range cur {
+			prev, ok := old[k]
+			if !ok {
+				wd.Added = append(wd.Added, b)
+			} else if prev.Status != b.Status {
+				wd.Changed = append(wd.Changed, StatusChange{Behavior: b, OldStatus: prev.Status})
+			}
+			if ok {
+				if v := newVolumeChange(b, prev.Count, o.Volume); v != nil {
+					wd.Volume = append(wd.Volume, *v)
+				}
+			}
+			for _, finding := range c.Classify(b) {
+				finding.Workload = w
+				finding.New = !ok
+				wd.Findings = append(wd.Findings, finding)
+			}
+		}
+		for k, b := range old {
+			if _, ok := cur[k]; !ok {
+				wd.Removed = append(wd.Removed, b)
+			}
+		}
+		sortBehaviors(wd.Added)
+		sortBehaviors(wd.Removed)
+		sort.Slice(wd.Changed, func(i, j int) bool { return lessBehavior(wd.Changed[i].Behavior, wd.Changed[j].Behavior) })
+		sort.Slice(wd.Volume, func(i, j int) bool { return lessBehavior(wd.Volume[i].Behavior, wd.Volume[j].Behavior) })
+		sortFindings(wd.Findings)
+		report.Workloads = append(report.Workloads, wd)
+	}
+	report.score(c)
+	return report
+}
+
+// behaviorsByWorkload indexes the behaviors of the summary by workload, the counts of identical
+// behaviors are summed and the most restrictive status is kept
+func behaviorsByWorkload(summaryDatas []*SummaryData, f *filter.Filter) (map[DiffWorkload]map[behaviorKey]Behavior, []DiffWorkload) {
+	byWorkload := make(map[DiffWorkload]map[behaviorKey]Behavior)
+	var order []DiffWorkload
+	for _, sd := range summaryDatas {
+		if !f.MatchWorkload(filterWorkload(sd)) {
+			continue
+		}
+		w := DiffWorkloadOf(sd)
+		if _, ok := byWorkload[w]; !ok {
+			byWorkload[w] = make(map[behaviorKey]Behavior)
+			order = append(order, w)
+		}
+		for _, b := range BehaviorsOf(sd, f) {
+			k := b.key()
+			if prev, ok := byWorkload[w][k]; ok {
+				b.Count += prev.Count
+				b.Status = worseStatus(prev.Status, b.Status)
+			}
+			byWorkload[w][k] = b
+		}
+	}
+	return byWorkload, order
+}
+
+// sortBehaviors sorts behaviors by kind, source, destination, protocol and port
+func sortBehaviors(bs []Behavior) {
+	sort.Slice(bs, func(i, j int) bool { return lessBehavior(bs[i], bs[j]) })
+}
+
+// lessBehavior orders behaviors by kind, source, destination, protocol and port
+func lessBehavior(a, b Behavior) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Source != b.Source {
+		return a.Source < b.Source
+	}
+	if a.Destination != b.Destination {
+		return a.Destination < b.Destination
+	}
+	if a.Protocol != b.Protocol {
+		return a.Protocol < b.Protocol
+	}
+	return a.Port < b.Port
+}
+
+// DiffJSONFiles parses two summaries with p and compares them with the built-in rule pack
+// and the default volume thresholds
+func DiffJSONFiles(jsonFileOld, jsonFileNew string, f *filter.Filter, p ParseOptions) (*DiffReport, error) {
+	return DiffOptions{}.DiffJSONFiles(jsonFileOld, jsonFileNew, f, p)
+}
+
+// DiffJSONFiles parses two summaries with p and compares them with the built-in rule pack
+func (o DiffOptions) DiffJSONFiles(jsonFileOld, jsonFileNew string, f *filter.Filter, p ParseOptions) (*DiffReport, error) {
+	klog.Infoln("Parsing Old Summary Data...")
+	sdOlds, err := p.Older().ParseSummaryData(jsonFileOld)
+	if err != nil {
+		return nil, err
+	}
+	klog.Infoln("Parsing New Summary Data...")
+	sdNews, err := p.ParseSummaryData(jsonFileNew)
+	if err != nil {
+		return nil, err
+	}
+	logSubstitutions(p.Normalizer)
+	return o.Diff(sdOlds, sdNews, f, BuiltinClassifier()), nil
+}
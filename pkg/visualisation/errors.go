@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+)
+
+// IOError is returned when the summary data can not be opened or read
+type IOError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface
+func (e *IOError) Error() string {
+	return fmt.Sprintf("reading summary data %q: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPError is returned when a remote summary data responds with a non-200 status code
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("fetching summary data %q: server reported %s", e.URL, e.Status)
+}
+
+// JSONError is returned when the summary data is not well-formed JSON
+type JSONError struct {
+	Path string
+	// Offset is the byte offset in the (decompressed) input where the error occurred
+	Offset int64
+	Err    error
+}
+
+// Error implements the error interface
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("parsing summary data %q at byte offset %d: %v", e.Path, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError is returned when the summary data is well-formed JSON,
+// but a value does not match the SummaryData structure
+type SchemaError struct {
+	Path string
+	// Index is the index of the SummaryData entry in the input
+	Index int
+	// Field is the offending field, if known
+	Field string
+	// Pointer is the JSON pointer (RFC 6901) of the offending value, if known
+	Pointer string
+	// Offset is the byte offset in the (decompressed) input of the offending entry
+	Offset int64
+	Err    error
+}
+
+// Error implements the error interface
+func (e *SchemaError) Error() string {
+	if e.Pointer != "" {
+		return fmt.Sprintf("invalid summary data %q: %s: %v", e.Path, e.Pointer, e.Err)
+	}
+	if e.Field != "" {
+		return fmt.Sprintf("invalid summary data %q: entry %d, field %s: %v", e.Path, e.Index, e.Field, e.Err)
+	}
+	return fmt.Sprintf("invalid summary data %q: entry %d: %v", e.Path, e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+)
+
+// DefaultKeepExpanded are the sensitive paths that are never collapsed
+var DefaultKeepExpanded = []string{
+	"/etc/**",
+	"/root/**",
+	"/proc/*/environ",
+	"/run/secrets/**",
+	"/var/run/secrets/**",
+	"/home/*/.ssh/**",
+}
+
+// FileTreeOptions configures how file accesses are aggregated into a directory tree
+type FileTreeOptions struct {
+	// CollapseDepth collapses the directories deeper than this depth into dir/**, 0 never collapses
+	CollapseDepth int
+	// MaxFanout collapses the directories with more children than this into dir/**, 0 never collapses
+	MaxFanout int
+	// KeepExpanded are glob patterns of paths that are never collapsed, "**" matches across "/"
+	KeepExpanded []string
+}
+
+// IsZero reports whether the options never collapse anything
+func (o FileTreeOptions) IsZero() bool {
+	return o.CollapseDepth == 0 && o.MaxFanout == 0
+}
+
+// FileNode is a directory or a file in a file access tree
+type FileNode struct {
+	// Name is the last element of the path, dir/** for collapsed directories
+	Name string `json:"Name"`
+	// Path is the absolute path of the node
+	Path string `json:"Path"`
+	// Count is the number of accesses to the node and everything below it
+	Count Count `json:"Count"`
+	// Files is the number of distinct files accessed below the node, 1 for a file
+	Files int `json:"Files"`
+	// Status is the most restrictive status of the accesses to the node and everything below it
+	Status string `json:"Status"`
+	// Collapsed is true if the children of the node have been collapsed
+	Collapsed bool        `json:"Collapsed,omitempty"`
+	Children  []*FileNode `json:"Children,omitempty"`
+
+	children  map[string]*FileNode
+	sensitive bool
+}
+
+// BuildFileTree aggregates the file accesses into a directory tree and collapses it with the options.
+// Only the file accesses selected by the filter are included.
+func BuildFileTree(files []FileData, f *filter.Filter, opts FileTreeOptions) (*FileNode, error) {
+	keep, err := compileGlobs(opts.KeepExpanded)
+	if err != nil {
+		return nil, err
+	}
+	root := &FileNode{Name: "/", Path: "/", children: make(map[string]*FileNode)}
+	for _, file := range files {
+		p := strings.TrimSpace(file.Destination)
+		if p == "" || !f.MatchFileAccess(file.Source, p) {
+			continue
+		}
+		status := NormalizeStatus(file.Status)
+		if !f.MatchStatus(status) {
+			continue
+		}
+		root.add(p, file.Count, status, matchAny(keep, p))
+	}
+	root.finish()
+	root.collapse(0, opts)
+	return root, nil
+}
+
+// add adds an access to the file at path p below the node
+func (n *FileNode) add(p string, count Count, status string, sensitive bool) {
+	node := n
+	node.Count += count
+	node.Status = worseStatus(node.Status, status)
+	node.sensitive = node.sensitive || sensitive
+	for _, name := range strings.Split(strings.Trim(p, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		child, ok := node.children[name]
+		if !ok {
+			child = &FileNode{Name: name, Path: strings.TrimSuffix(node.Path, "/") + "/" + name, children: make(map[string]*FileNode)}
+			node.children[name] = child
+		}
+		child.Count += count
+		child.Status = worseStatus(child.Status, status)
+		child.sensitive = child.sensitive || sensitive
+		node = child
+	}
+	if node.Files == 0 {
+		node.Files = -1 // marks a leaf, counted in finish
+	}
+}
+
+// finish sorts the children and counts the files below every node
+func (n *FileNode) finish() int {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := 0
+	if n.Files == -1 {
+		files = 1
+	}
+	n.Children = n.Children[:0]
+	for _, name := range names {
+		child := n.children[name]
+		files += child.finish()
+		n.Children = append(n.Children, child)
+	}
+	n.Files = files
+	return files
+}
+
+// collapse collapses the node or its children according to the options
+func (n *FileNode) collapse(depth int, opts FileTreeOptions) {
+	if len(n.Children) == 0 {
+		return
+	}
+	tooDeep := opts.CollapseDepth > 0 && depth >= opts.CollapseDepth
+	tooWide := opts.MaxFanout > 0 && len(n.Children) > opts.MaxFanout
+	// the root is never collapsed as a whole, its children are
+	if (tooDeep || tooWide) && !n.sensitive && n.Path != "/" {
+		n.Name += "/**"
+		n.Collapsed = true
+		n.Children = nil
+		return
+	}
+	if tooDeep || tooWide {
+		// keep the sensitive children, collapse the others into one node
+		var kept []*FileNode
+		other := &FileNode{Name: "**", Path: strings.TrimSuffix(n.Path, "/") + "/**", Collapsed: true}
+		for _, c := range n.Children {
+			if c.sensitive {
+				kept = append(kept, c)
+				continue
+			}
+			other.Count += c.Count
+			other.Status = worseStatus(other.Status, c.Status)
+			other.Files += c.Files
+		}
+		if other.Files > 0 {
+			kept = append(kept, other)
+		}
+		n.Children = kept
+	}
+	for _, c := range n.Children {
+		if !c.Collapsed {
+			c.collapse(depth+1, opts)
+		}
+	}
+}
+
+// Leaves returns the files and the collapsed directories of the tree
+func (n *FileNode) Leaves() []*FileNode {
+	if len(n.Children) == 0 {
+		return []*FileNode{n}
+	}
+	var leaves []*FileNode
+	for _, c := range n.Children {
+		leaves = append(leaves, c.Leaves()...)
+	}
+	return leaves
+}
+
+// Label returns a short description of the node, eg.: x3, 237 files, x512 or x2, Block
+func (n *FileNode) Label() string {
+	label := fmt.Sprintf("x%d", n.Count)
+	if n.Collapsed || n.Files > 1 {
+		label = fmt.Sprintf("%d files, x%d", n.Files, n.Count)
+	}
+	if n.Status != "" && n.Status != StatusAllow {
+		label += ", " + n.Status
+	}
+	return label
+}
+
+// compileGlobs compiles glob patterns
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(globs))
+	for _, g := range globs {
+		re, err := filter.GlobToRegexp(g)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// matchAny reports whether s matches one of the regular expressions
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
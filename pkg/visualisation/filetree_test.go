@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+)
+
+// treeFiles are the file accesses of the file tree tests
+var treeFiles = []FileData{
+	{Source: "/usr/sbin/apache2", Destination: "/usr/share/zoneinfo/UTC", Count: 1, Status: StatusAllow},
+	{Source: "/usr/sbin/apache2", Destination: "/usr/share/zoneinfo/Etc/GMT", Count: 1, Status: StatusAllow},
+	{Source: "/usr/sbin/apache2", Destination: "/usr/share/zoneinfo/Iceland", Count: 1, Status: StatusAllow},
+	{Source: "/usr/sbin/apache2", Destination: "/etc/passwd", Count: 2, Status: StatusAudit},
+	{Source: "/usr/sbin/apache2", Destination: "/etc/group", Count: 1, Status: StatusAllow},
+	{Source: "/bin/sh", Destination: "/var/run/secrets/kubernetes.io/serviceaccount/token", Count: 1, Status: StatusBlock},
+	{Source: "/bin/sh", Destination: " /tmp/healthcheck ", Count: 1, Status: StatusAllow},
+	{Source: "/bin/sh", Destination: "", Count: 1, Status: StatusAllow},
+}
+
+func TestBuildFileTree(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     FileTreeOptions
+		statuses []string
+		want     []string
+	}{
+		{
+			name: "no collapse",
+			want: []string{
+				"/etc/group x1",
+				"/etc/passwd x2, Audit",
+				"/tmp/healthcheck x1",
+				"/usr/share/zoneinfo/Etc/GMT x1",
+				"/usr/share/zoneinfo/Iceland x1",
+				"/usr/share/zoneinfo/UTC x1",
+				"/var/run/secrets/kubernetes.io/serviceaccount/token x1, Block",
+			},
+		},
+		{
+			name: "fanout keeps the sensitive children of the root",
+			opts: FileTreeOptions{MaxFanout: 2, KeepExpanded: DefaultKeepExpanded},
+			want: []string{
+				"/etc/group x1",
+				"/etc/passwd x2, Audit",
+				"/var/run/secrets/kubernetes.io/serviceaccount/token x1, Block",
+				"/** 4 files, x4",
+			},
+		},
+		{
+			name: "depth",
+			opts: FileTreeOptions{CollapseDepth: 2, KeepExpanded: DefaultKeepExpanded},
+			want: []string{
+				"/etc/group x1",
+				"/etc/passwd x2, Audit",
+				"/tmp/healthcheck x1",
+				"/usr/share/** 3 files, x3",
+				"/var/run/secrets/kubernetes.io/serviceaccount/token x1, Block",
+			},
+		},
+		{
+			name: "depth without sensitive paths",
+			opts: FileTreeOptions{CollapseDepth: 1},
+			want: []string{
+				"/etc/** 2 files, x3, Audit",
+				"/tmp/** 1 files, x1",
+				"/usr/** 3 files, x3",
+				"/var/** 1 files, x1, Block",
+			},
+		},
+		{
+			name: "depth keeps a sensitive file",
+			opts: FileTreeOptions{CollapseDepth: 1, KeepExpanded: []string{"/usr/share/zoneinfo/UTC"}},
+			want: []string{
+				"/etc/** 2 files, x3, Audit",
+				"/tmp/** 1 files, x1",
+				"/usr/share/zoneinfo/UTC x1",
+				"/usr/share/zoneinfo/** 2 files, x2",
+				"/var/** 1 files, x1, Block",
+			},
+		},
+		{
+			name:     "statuses",
+			opts:     FileTreeOptions{CollapseDepth: 1},
+			statuses: []string{"Audit", "Block"},
+			want: []string{
+				"/etc/** 1 files, x2, Audit",
+				"/var/** 1 files, x1, Block",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := filter.New(filter.Options{Statuses: tt.statuses})
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := BuildFileTree(treeFiles, f, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, leaf := range root.Leaves() {
+				p := leaf.Path
+				if leaf.Collapsed {
+					p = strings.TrimSuffix(p, "/**") + "/**"
+				}
+				got = append(got, p+" "+leaf.Label())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("BuildFileTree() leaves =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			// the root counts every access and file of the tree
+			files, count := 0, Count(0)
+			for _, leaf := range root.Leaves() {
+				files += leaf.Files
+				count += leaf.Count
+			}
+			if root.Files != files || root.Count != count {
+				t.Errorf("root = %d files, x%d, want %d files, x%d", root.Files, root.Count, files, count)
+			}
+		})
+	}
+}
+
+func TestFileTreeOptionsIsZero(t *testing.T) {
+	tests := []struct {
+		opts FileTreeOptions
+		want bool
+	}{
+		{opts: FileTreeOptions{}, want: true},
+		{opts: FileTreeOptions{KeepExpanded: DefaultKeepExpanded}, want: true},
+		{opts: FileTreeOptions{MaxFanout: 20}, want: false},
+		{opts: FileTreeOptions{CollapseDepth: 3}, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.IsZero(); got != tt.want {
+			t.Errorf("%+v.IsZero() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
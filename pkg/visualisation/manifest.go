@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workloadKinds are the manifest kinds that declare workloads
+var workloadKinds = map[string]bool{
+	"Deployment": true, "StatefulSet": true, "DaemonSet": true, "ReplicaSet": true,
+	"Job": true, "CronJob": true, "Pod": true,
+}
+
+// ManifestLocation is where a workload is declared
+type ManifestLocation struct {
+	// Path is the manifest file, relative to the working directory if it is below it
+	Path string `json:"Path"`
+	// Line is the line of the workload name
+	Line int `json:"Line"`
+}
+
+// ManifestIndex locates the manifests declaring workloads
+type ManifestIndex struct {
+	// byName are the locations by namespace/name, and by name for manifests without namespace
+	byName map[string]ManifestLocation
+}
+
+// IndexManifests indexes the workloads declared in the YAML files of the paths, directories are walked
+func IndexManifests(paths ...string) (*ManifestIndex, error) {
+	idx := &ManifestIndex{byName: make(map[string]ManifestLocation)}
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				return nil
+			}
+			return idx.addFile(path)
+		})
+		if err != nil {
+			return nil, &IOError{Path: root, Err: err}
+		}
+	}
+	return idx, nil
+}
+
+// addFile indexes the workloads of a multi-document YAML file, documents that are not
+// Kubernetes objects are skipped
+func (idx *ManifestIndex) addFile(path string) error {
+	// #nosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// not a manifest, eg.: a Helm template
+			return nil
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		if !workloadKinds[scalarOf(root, "kind")] {
+			continue
+		}
+		metadata := mappingOf(root, "metadata")
+		if metadata == nil {
+			continue
+		}
+		name := valueNode(metadata, "name")
+		if name == nil {
+			continue
+		}
+		loc := ManifestLocation{Path: RelativePath(path), Line: name.Line}
+		key := name.Value
+		if ns := scalarOf(metadata, "namespace"); ns != "" {
+			key = ns + "/" + name.Value
+		}
+		if _, ok := idx.byName[key]; !ok {
+			idx.byName[key] = loc
+		}
+	}
+}
+
+// Locate returns where the workload is declared, manifests without namespace match any namespace
+func (idx *ManifestIndex) Locate(w DiffWorkload) (ManifestLocation, bool) {
+	if idx == nil {
+		return ManifestLocation{}, false
+	}
+	if loc, ok := idx.byName[w.Namespace+"/"+w.Deployment]; ok {
+		return loc, true
+	}
+	loc, ok := idx.byName[w.Deployment]
+	return loc, ok
+}
+
+// valueNode returns the value of a key of a YAML mapping
+func valueNode(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarOf returns the scalar value of a key of a YAML mapping
+func scalarOf(m *yaml.Node, key string) string {
+	v := valueNode(m, key)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
+
+// mappingOf returns the mapping value of a key of a YAML mapping
+func mappingOf(m *yaml.Node, key string) *yaml.Node {
+	v := valueNode(m, key)
+	if v == nil || v.Kind != yaml.MappingNode {
+		return nil
+	}
+	return v
+}
+
+// RelativePath returns the path relative to the working directory, the repository root in workflows
+func RelativePath(path string) string {
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// workloadKey identifies a workload across summaries,
+// pods of the same deployment are merged into one entry
+type workloadKey struct {
+	cluster    string
+	namespace  string
+	deployment string
+	container  string
+}
+
+// psKey identifies a process or file entry
+type psKey struct {
+	source      string
+	destination string
+	status      string
+}
+
+// netKey identifies an ingress or egress connection entry
+type netKey struct {
+	protocol  string
+	command   string
+	ip        string
+	port      string
+	labels    string
+	namespace string
+}
+
+// bindKey identifies a bind connection entry
+type bindKey struct {
+	protocol string
+	command  string
+	port     string
+	address  string
+}
+
+// mergedWorkload is a workload being merged, with indexes of its entries
+type mergedWorkload struct {
+	sd       *SummaryData
+	process  map[psKey]int
+	file     map[psKey]int
+	ingress  map[netKey]int
+	egress   map[netKey]int
+	bind     map[bindKey]int
+	lastSeen Timestamp
+}
+
+// Merger merges summary data entries into one deduplicated summary.
+// Identical process, file and connection entries of a workload are merged into one,
+// their counts are summed and the latest UpdatedTime is kept.
+type Merger struct {
+	workloads map[workloadKey]*mergedWorkload
+	order     []workloadKey
+}
+
+// NewMerger returns an empty Merger
+func NewMerger() *Merger {
+	return &Merger{
+		workloads: make(map[workloadKey]*mergedWorkload),
+	}
+}
+
+// MergeSummaryData merges several summaries into one deduplicated summary
+func MergeSummaryData(summaries ...[]*SummaryData) []*SummaryData {
+	m := NewMerger()
+	for _, summaryDatas := range summaries {
+		for _, sd := range summaryDatas {
+			m.Add(sd)
+		}
+	}
+	return m.Result()
+}
+
+// Add merges a summary data entry, sd is not modified
+func (m *Merger) Add(sd *SummaryData) {
+	key := workloadKey{
+		cluster:    sd.ClusterName,
+		namespace:  sd.Namespace,
+		deployment: sd.DeploymentName,
+		container:  sd.ContainerName,
+	}
+	// without a deployment, the pod is the workload
+	if key.deployment == "" {
+		key.deployment = "pod/" + sd.PodName
+	}
+	w, ok := m.workloads[key]
+	if !ok {
+		w = &mergedWorkload{
+			sd: &SummaryData{
+				DeploymentName: sd.DeploymentName,
+				PodName:        sd.PodName,
+				ClusterName:    sd.ClusterName,
+				Namespace:      sd.Namespace,
+				Label:          sd.Label,
+				ContainerName:  sd.ContainerName,
+				ContainerImage: sd.ContainerImage,
+				NormalizedBy:   sd.NormalizedBy,
+			},
+			process: make(map[psKey]int),
+			file:    make(map[psKey]int),
+			ingress: make(map[netKey]int),
+			egress:  make(map[netKey]int),
+			bind:    make(map[bindKey]int),
+		}
+		m.workloads[key] = w
+		m.order = append(m.order, key)
+	}
+
+	latest := latestUpdate(sd)
+	// the most recently active pod names the merged workload
+	if latest.After(w.lastSeen.Time) {
+		w.lastSeen = latest
+		w.sd.PodName = sd.PodName
+		if sd.ContainerImage != "" {
+			w.sd.ContainerImage = sd.ContainerImage
+		}
+	}
+	if w.sd.Label == "" {
+		w.sd.Label = sd.Label
+	}
+	w.sd.NormalizedBy = joinRules(w.sd.NormalizedBy, sd.NormalizedBy)
+	for k, v := range sd.Extra {
+		if w.sd.Extra == nil {
+			w.sd.Extra = make(map[string]json.RawMessage)
+		}
+		if _, ok := w.sd.Extra[k]; !ok {
+			w.sd.Extra[k] = v
+		}
+	}
+
+	for _, ps := range sd.ProcessData {
+		k := psKey{source: ps.Source, destination: ps.Destination, status: ps.Status}
+		if i, ok := w.process[k]; ok {
+			merged := &w.sd.ProcessData[i]
+			merged.Count += ps.Count
+			merged.UpdatedTime = laterTimestamp(merged.UpdatedTime, ps.UpdatedTime)
+			merged.NormalizedBy = joinRules(merged.NormalizedBy, ps.NormalizedBy)
+			continue
+		}
+		w.process[k] = len(w.sd.ProcessData)
+		w.sd.ProcessData = append(w.sd.ProcessData, ps)
+	}
+	for _, file := range sd.FileData {
+		k := psKey{source: file.Source, destination: file.Destination, status: file.Status}
+		if i, ok := w.file[k]; ok {
+			merged := &w.sd.FileData[i]
+			merged.Count += file.Count
+			merged.UpdatedTime = laterTimestamp(merged.UpdatedTime, file.UpdatedTime)
+			merged.NormalizedBy = joinRules(merged.NormalizedBy, file.NormalizedBy)
+			continue
+		}
+		w.file[k] = len(w.sd.FileData)
+		w.sd.FileData = append(w.sd.FileData, file)
+	}
+	for _, net := range sd.IngressConnection {
+		k := netKey{protocol: net.Protocol, command: net.Command, ip: net.IP, port: net.Port, labels: net.Labels, namespace: net.Namespace}
+		if i, ok := w.ingress[k]; ok {
+			merged := &w.sd.IngressConnection[i]
+			merged.Count += net.Count
+			merged.UpdatedTime = laterTimestamp(merged.UpdatedTime, net.UpdatedTime)
+			merged.NormalizedBy = joinRules(merged.NormalizedBy, net.NormalizedBy)
+			continue
+		}
+		w.ingress[k] = len(w.sd.IngressConnection)
+		w.sd.IngressConnection = append(w.sd.IngressConnection, net)
+	}
+	for _, net := range sd.EgressConnection {
+		k := netKey{protocol: net.Protocol, command: net.Command, ip: net.IP, port: net.Port, labels: net.Labels, namespace: net.Namespace}
+		if i, ok := w.egress[k]; ok {
+			merged := &w.sd.EgressConnection[i]
+			merged.Count += net.Count
+			merged.UpdatedTime = laterTimestamp(merged.UpdatedTime, net.UpdatedTime)
+			merged.NormalizedBy = joinRules(merged.NormalizedBy, net.NormalizedBy)
+			continue
+		}
+		w.egress[k] = len(w.sd.EgressConnection)
+		w.sd.EgressConnection = append(w.sd.EgressConnection, net)
+	}
+	for _, bind := range sd.BindConnection {
+		k := bindKey{protocol: bind.Protocol, command: bind.Command, port: bind.BindPort, address: bind.BindAddress}
+		if i, ok := w.bind[k]; ok {
+			merged := &w.sd.BindConnection[i]
+			merged.Count += bind.Count
+			merged.UpdatedTime = laterTimestamp(merged.UpdatedTime, bind.UpdatedTime)
+			merged.NormalizedBy = joinRules(merged.NormalizedBy, bind.NormalizedBy)
+			continue
+		}
+		w.bind[k] = len(w.sd.BindConnection)
+		w.sd.BindConnection = append(w.sd.BindConnection, bind)
+	}
+}
+
+// Result returns the merged summary, sorted by namespace, deployment and container
+func (m *Merger) Result() []*SummaryData {
+	keys := make([]workloadKey, len(m.order))
+	copy(keys, m.order)
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.cluster != b.cluster {
+			return a.cluster < b.cluster
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.deployment != b.deployment {
+			return a.deployment < b.deployment
+		}
+		return a.container < b.container
+	})
+	result := make([]*SummaryData, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, m.workloads[k].sd)
+	}
+	return result
+}
+
+// latestUpdate returns the latest UpdatedTime of all the entries of sd
+func latestUpdate(sd *SummaryData) Timestamp {
+	var latest Timestamp
+	for _, ps := range sd.ProcessData {
+		latest = laterTimestamp(latest, ps.UpdatedTime)
+	}
+	for _, file := range sd.FileData {
+		latest = laterTimestamp(latest, file.UpdatedTime)
+	}
+	for _, net := range sd.IngressConnection {
+		latest = laterTimestamp(latest, net.UpdatedTime)
+	}
+	for _, net := range sd.EgressConnection {
+		latest = laterTimestamp(latest, net.UpdatedTime)
+	}
+	for _, bind := range sd.BindConnection {
+		latest = laterTimestamp(latest, bind.UpdatedTime)
+	}
+	return latest
+}
+
+// laterTimestamp returns the later of two timestamps
+func laterTimestamp(a, b Timestamp) Timestamp {
+	if b.After(a.Time) {
+		return b
+	}
+	return a
+}
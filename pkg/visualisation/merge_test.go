@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMergeSummaryData(t *testing.T) {
+	at := func(sec int) Timestamp {
+		return Timestamp{Time: time.Date(2023, 7, 3, 8, 0, sec, 0, time.UTC)}
+	}
+	first := []*SummaryData{{
+		DeploymentName: "carts", PodName: "carts-5df4cd65d5-x8kqz", ClusterName: "default", Namespace: "sock-shop", Label: "name=carts",
+		ProcessData: []ProcessData{
+			{Source: "/bin/sh", Destination: "/bin/ls", Count: 2, UpdatedTime: at(10), Status: "Allow"},
+			{Source: "/bin/sh", Destination: "/bin/rm", Count: 1, UpdatedTime: at(10), Status: "Block"},
+		},
+		EgressConnection: []EgressConnection{{Protocol: "TCP", Command: "/usr/bin/java", IP: "svc/catalogue", Port: "80", Count: 3}},
+		Extra:            map[string]json.RawMessage{"Workload": json.RawMessage(`{"Kind":"Deployment"}`)},
+	}}
+	second := []*SummaryData{
+		{
+			DeploymentName: "carts", PodName: "carts-5df4cd65d5-fghjk", ClusterName: "default", Namespace: "sock-shop",
+			ProcessData: []ProcessData{
+				{Source: "/bin/sh", Destination: "/bin/ls", Count: 5, UpdatedTime: at(30), Status: "Allow"},
+				// another status is another entry
+				{Source: "/bin/sh", Destination: "/bin/rm", Count: 1, UpdatedTime: at(20), Status: "Allow"},
+			},
+			EgressConnection: []EgressConnection{{Protocol: "TCP", Command: "/usr/bin/java", IP: "svc/catalogue", Port: "80", Count: 4, UpdatedTime: at(5)}},
+		},
+		// pods without a deployment are merged by pod name
+		{PodName: "debug", ClusterName: "default", Namespace: "default"},
+		{DeploymentName: "carts", PodName: "carts-5df4cd65d5-x8kqz", ClusterName: "default", Namespace: "sock-shop", ContainerName: "istio-proxy"},
+	}
+	firstJSON, _ := json.Marshal(first)
+
+	got := MergeSummaryData(first, second)
+	if len(got) != 3 {
+		t.Fatalf("MergeSummaryData() = %d workloads, want 3", len(got))
+	}
+	if got[0].PodName != "debug" || got[1].ContainerName != "" || got[2].ContainerName != "istio-proxy" {
+		t.Errorf("MergeSummaryData() order = %s, %s/%s, %s/%s, want debug, carts, carts/istio-proxy",
+			got[0].PodName, got[1].DeploymentName, got[1].ContainerName, got[2].DeploymentName, got[2].ContainerName)
+	}
+	carts := got[1]
+	// the most recently active pod names the workload, the first label is kept
+	if carts.PodName != "carts-5df4cd65d5-fghjk" || carts.Label != "name=carts" || string(carts.Extra["Workload"]) != `{"Kind":"Deployment"}` {
+		t.Errorf("carts = %s, %s, %s", carts.PodName, carts.Label, carts.Extra)
+	}
+	wantProcesses := []ProcessData{
+		{Source: "/bin/sh", Destination: "/bin/ls", Count: 7, UpdatedTime: at(30), Status: "Allow"},
+		{Source: "/bin/sh", Destination: "/bin/rm", Count: 1, UpdatedTime: at(10), Status: "Block"},
+		{Source: "/bin/sh", Destination: "/bin/rm", Count: 1, UpdatedTime: at(20), Status: "Allow"},
+	}
+	if len(carts.ProcessData) != len(wantProcesses) {
+		t.Fatalf("ProcessData = %+v, want %+v", carts.ProcessData, wantProcesses)
+	}
+	for i, want := range wantProcesses {
+		if got := carts.ProcessData[i]; got.Source != want.Source || got.Destination != want.Destination || got.Status != want.Status ||
+			got.Count != want.Count || !got.UpdatedTime.Equal(want.UpdatedTime.Time) {
+			t.Errorf("ProcessData[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+	if egress := carts.EgressConnection; len(egress) != 1 || egress[0].Count != 7 || !egress[0].UpdatedTime.Equal(at(5).Time) {
+		t.Errorf("EgressConnection = %+v, want a single connection counted 7 times", egress)
+	}
+
+	// the inputs are not modified
+	if data, _ := json.Marshal(first); string(data) != string(firstJSON) {
+		t.Errorf("MergeSummaryData() modified its input:\n%s\nwant\n%s", data, firstJSON)
+	}
+}
@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+)
+
+// Fields a normalization rule can apply to
+const (
+	// FieldPath is the process and file paths, and the connection commands
+	FieldPath = "path"
+	// FieldPort is the connection ports
+	FieldPort = "port"
+	// FieldIP is the connection IPs, eg.: pod/mysql-5df4cd65d5-x8kqz
+	FieldIP = "ip"
+	// FieldPod is the pod names of the workloads, eg.: mysql-5df4cd65d5-x8kqz
+	FieldPod = "pod"
+	// FieldDeployment is the deployment names of the workloads, karmor reports them with the
+	// pod-template-hash, eg.: mysql-5df4cd65d5
+	FieldDeployment = "deployment"
+)
+
+// NormalizationRule replaces the values of a field matching a regular expression
+type NormalizationRule struct {
+	// Name identifies the rule in the substitutions
+	Name string `json:"name"`
+	// Field is the field the rule applies to: path, port, ip, pod or deployment
+	Field string `json:"field"`
+	// Match is the regular expression, Replace the replacement, it can refer to groups as $1
+	Match   string `json:"match"`
+	Replace string `json:"replace"`
+
+	re *regexp.Regexp
+}
+
+// NormalizationConfig is the content of a normalization config file, in YAML or JSON
+type NormalizationConfig struct {
+	// DisableBuiltin disables the built-in rules, only the rules of the file apply
+	DisableBuiltin bool `json:"disableBuiltin,omitempty"`
+	// Enable names the optional rules applied after the built-in rules, eg.: ephemeral-port
+	Enable []string `json:"enable,omitempty"`
+	// Rules are applied after the built-in rules, in order
+	Rules []NormalizationRule `json:"rules"`
+}
+
+// podNameChars are the characters of the random suffixes Kubernetes appends to pod names
+const podNameChars = "[bcdfghjklmnpqrstvwxz2456789]"
+
+// tmpDirs are the directories of the temp files
+const tmpDirs = `^(/tmp|/var/tmp|/dev/shm)/`
+
+// BuiltinNormalizationRules are the rules removing the usual run-to-run noise. The temp file
+// rules only match the names generated by the usual libraries, so that stable names, eg.:
+// /tmp/healthcheck, are kept.
+var BuiltinNormalizationRules = []NormalizationRule{
+	{Name: "proc-pid", Field: FieldPath, Match: `^/proc/[0-9]+(/|$)`, Replace: "/proc/<pid>$1"},
+	{Name: "proc-task", Field: FieldPath, Match: `^(/proc/[^/]+)/task/[0-9]+(/|$)`, Replace: "$1/task/<tid>$2"},
+	// PHP uploads, eg.: /tmp/php8Hk2lQ
+	{Name: "tmp-php-upload", Field: FieldPath, Match: tmpDirs + `php[A-Za-z0-9]{6}$`, Replace: "$1/php<random>"},
+	// PHP sessions, eg.: /tmp/sess_k2jd8s0f9a3l4m5n6b7v8c9x0z
+	{Name: "tmp-php-session", Field: FieldPath, Match: tmpDirs + `sess_[A-Za-z0-9,-]{22,}$`, Replace: "$1/sess_<random>"},
+	// mktemp, eg.: /tmp/tmp.Xf3kL9qPz2
+	{Name: "tmp-mktemp", Field: FieldPath, Match: tmpDirs + `tmp\.[A-Za-z0-9]{10}$`, Replace: "$1/tmp.<random>"},
+	// Python tempfile, eg.: /tmp/tmpk3j_2x9a
+	{Name: "tmp-python", Field: FieldPath, Match: tmpDirs + `tmp[a-z0-9_]{8}$`, Replace: "$1/tmp<random>"},
+	{Name: "uuid", Field: FieldPath, Match: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, Replace: "<uuid>"},
+	{Name: "hash", Field: FieldPath, Match: `(^|[/._-])[0-9a-f]{16,}([/._-]|$)`, Replace: "$1<hash>$2"},
+	{Name: "pod-name", Field: FieldIP, Match: `^pod/(.+)-` + podNameChars + `{5,10}-` + podNameChars + `{5}$`, Replace: "pod/$1-<pod>"},
+	{Name: "pod-name", Field: FieldPod, Match: `^(.+)-` + podNameChars + `{5,10}-` + podNameChars + `{5}$`, Replace: "$1-<pod>"},
+	{Name: "pod-template-hash", Field: FieldDeployment, Match: `^(.+)-` + podNameChars + `{5,10}$`, Replace: "$1"},
+}
+
+// OptionalNormalizationRules are the rules a config file enables by name. The ports of the
+// summaries are the ports the workloads listen on and connect to, not the client ports, so the
+// ports in the Linux ephemeral range, eg.: 50051 of gRPC or 33060 of MySQL X, are kept unless
+// the workloads bind random ports.
+var OptionalNormalizationRules = []NormalizationRule{
+	{Name: "ephemeral-port", Field: FieldPort, Match: `^(3276[89]|327[7-9][0-9]|32[89][0-9]{2}|3[3-9][0-9]{3}|[45][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$`, Replace: "<ephemeral>"},
+}
+
+// Substitution records a value replaced by a normalization rule
+type Substitution struct {
+	Field      string `json:"Field"`
+	Original   string `json:"Original"`
+	Normalized string `json:"Normalized"`
+	// Rule is the name of the rule that replaced the value, or the comma separated names of the rules
+	Rule string `json:"Rule"`
+}
+
+// Normalizer rewrites the paths, ports and IPs of the summary data with normalization rules,
+// so that temp files, pids, hashes or pod names do not differ from run to run.
+// The nil Normalizer does not change anything. A Normalizer is not safe for concurrent use.
+type Normalizer struct {
+	rules         []NormalizationRule
+	substitutions map[Substitution]bool
+}
+
+// NewNormalizer compiles the rules into a Normalizer, the rules are applied in order
+func NewNormalizer(rules []NormalizationRule) (*Normalizer, error) {
+	n := &Normalizer{substitutions: make(map[Substitution]bool)}
+	for i, rule := range rules {
+		switch rule.Field {
+		case FieldPath, FieldPort, FieldIP, FieldPod, FieldDeployment:
+		default:
+			return nil, fmt.Errorf("normalization rule %q: invalid field %q, must be path, port, ip, pod or deployment", rule.Name, rule.Field)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("normalization rule %q: %w", rule.Name, err)
+		}
+		rule.re = re
+		n.rules = append(n.rules, rule)
+	}
+	return n, nil
+}
+
+// LoadNormalizer returns a Normalizer with the built-in rules and the rules of the config file,
+// if configFile is empty only the built-in rules apply
+func LoadNormalizer(configFile string) (*Normalizer, error) {
+	if configFile == "" {
+		return NewNormalizer(BuiltinNormalizationRules)
+	}
+	// #nosec
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, &IOError{Path: configFile, Err: err}
+	}
+	var config NormalizationConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing normalization config %s: %w", configFile, err)
+	}
+	var rules []NormalizationRule
+	if !config.DisableBuiltin {
+		rules = append(rules, BuiltinNormalizationRules...)
+	}
+	for _, name := range config.Enable {
+		rule, ok := optionalRule(name)
+		if !ok {
+			return nil, fmt.Errorf("parsing normalization config %s: unknown optional rule %q", configFile, name)
+		}
+		rules = append(rules, rule)
+	}
+	return NewNormalizer(append(rules, config.Rules...))
+}
+
+// optionalRule returns the optional rule by name
+func optionalRule(name string) (NormalizationRule, bool) {
+	for _, rule := range OptionalNormalizationRules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return NormalizationRule{}, false
+}
+
+// Normalize rewrites the summary data entries in place and merges the entries
+// that became identical, their counts are summed
+func (n *Normalizer) Normalize(summaryDatas []*SummaryData) []*SummaryData {
+	if n == nil {
+		return summaryDatas
+	}
+	for i, sd := range summaryDatas {
+		summaryDatas[i] = n.NormalizeEntry(sd)
+	}
+	return summaryDatas
+}
+
+// NormalizeEntry rewrites a summary data entry in place and returns it with
+// the entries that became identical merged. The rules that rewrote an entry are kept in its
+// NormalizedBy field.
+func (n *Normalizer) NormalizeEntry(sd *SummaryData) *SummaryData {
+	if n == nil {
+		return sd
+	}
+	sd.PodName = n.applyTo(FieldPod, sd.PodName, &sd.NormalizedBy)
+	sd.DeploymentName = n.applyTo(FieldDeployment, sd.DeploymentName, &sd.NormalizedBy)
+	for i := range sd.ProcessData {
+		ps := &sd.ProcessData[i]
+		ps.Source = n.applyTo(FieldPath, ps.Source, &ps.NormalizedBy)
+		ps.Destination = n.applyTo(FieldPath, ps.Destination, &ps.NormalizedBy)
+	}
+	for i := range sd.FileData {
+		file := &sd.FileData[i]
+		file.Source = n.applyTo(FieldPath, file.Source, &file.NormalizedBy)
+		file.Destination = n.applyTo(FieldPath, file.Destination, &file.NormalizedBy)
+	}
+	for i := range sd.IngressConnection {
+		net := &sd.IngressConnection[i]
+		net.Command = n.applyTo(FieldPath, net.Command, &net.NormalizedBy)
+		net.IP = n.applyTo(FieldIP, net.IP, &net.NormalizedBy)
+		net.Port = n.applyTo(FieldPort, net.Port, &net.NormalizedBy)
+	}
+	for i := range sd.EgressConnection {
+		net := &sd.EgressConnection[i]
+		net.Command = n.applyTo(FieldPath, net.Command, &net.NormalizedBy)
+		net.IP = n.applyTo(FieldIP, net.IP, &net.NormalizedBy)
+		net.Port = n.applyTo(FieldPort, net.Port, &net.NormalizedBy)
+	}
+	for i := range sd.BindConnection {
+		bind := &sd.BindConnection[i]
+		bind.Command = n.applyTo(FieldPath, bind.Command, &bind.NormalizedBy)
+		bind.BindPort = n.applyTo(FieldPort, bind.BindPort, &bind.NormalizedBy)
+	}
+	return dedupeEntry(sd)
+}
+
+// Substitutions returns the values replaced so far, with the rule that replaced them
+func (n *Normalizer) Substitutions() []Substitution {
+	if n == nil {
+		return nil
+	}
+	subs := make([]Substitution, 0, len(n.substitutions))
+	for s := range n.substitutions {
+		subs = append(subs, s)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Field != subs[j].Field {
+			return subs[i].Field < subs[j].Field
+		}
+		return subs[i].Original < subs[j].Original
+	})
+	return subs
+}
+
+// logSubstitutions logs how many values were normalized, and each substitution at verbosity 2
+func logSubstitutions(n *Normalizer) {
+	subs := n.Substitutions()
+	if len(subs) == 0 {
+		return
+	}
+	klog.Infof("Normalized %d values", len(subs))
+	for _, s := range subs {
+		klog.V(2).Infof("normalized %s %q to %q (rule %s)", s.Field, s.Original, s.Normalized, s.Rule)
+	}
+}
+
+// apply applies the rules of the field to the value, each rule sees the result of the previous ones.
+// The substitution is recorded with the rules that changed the value, eg.: proc-pid,hash
+func (n *Normalizer) apply(field, value string) string {
+	return n.applyTo(field, value, nil)
+}
+
+// applyTo applies the rules of the field to the value like apply, and adds the names of the
+// rules that changed it to rules, if not nil
+func (n *Normalizer) applyTo(field, value string, rules *string) string {
+	result := strings.TrimSpace(value)
+	if result == "" {
+		return value
+	}
+	var applied []string
+	for _, r := range n.rules {
+		if r.Field != field {
+			continue
+		}
+		replaced := r.re.ReplaceAllString(result, r.Replace)
+		if replaced != result {
+			result = replaced
+			applied = append(applied, r.Name)
+		}
+	}
+	if len(applied) == 0 {
+		return value
+	}
+	n.substitutions[Substitution{Field: field, Original: value, Normalized: result, Rule: strings.Join(applied, ",")}] = true
+	if rules != nil {
+		*rules = joinRules(*rules, strings.Join(applied, ","))
+	}
+	return result
+}
+
+// joinRules returns the union of two comma separated lists of rule names, sorted
+func joinRules(a, b string) string {
+	if a == b || b == "" {
+		return a
+	}
+	if a == "" {
+		return b
+	}
+	set := make(map[string]bool)
+	for _, name := range strings.Split(a+","+b, ",") {
+		set[name] = true
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// dedupeEntry merges the identical process, file and connection entries of a summary data entry
+func dedupeEntry(sd *SummaryData) *SummaryData {
+	m := NewMerger()
+	m.Add(sd)
+	merged := m.Result()[0]
+	// the merger names the workload after its latest pod, keep the (normalized) entry as is
+	merged.PodName = sd.PodName
+	merged.ContainerImage = sd.ContainerImage
+	merged.NormalizedBy = sd.NormalizedBy
+	return merged
+}
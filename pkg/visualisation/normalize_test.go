@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinNormalizationRules(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{field: FieldPath, value: "/proc/1234/environ", want: "/proc/<pid>/environ"},
+		{field: FieldPath, value: "/proc/1234", want: "/proc/<pid>"},
+		{field: FieldPath, value: "/proc/self/task/42/stat", want: "/proc/self/task/<tid>/stat"},
+		{field: FieldPath, value: "/proc/1/task/42/stat", want: "/proc/<pid>/task/<tid>/stat"},
+		{field: FieldPath, value: "/tmp/php8Hk2lQ", want: "/tmp/php<random>"},
+		{field: FieldPath, value: "/dev/shm/sess_k2jd8s0f9a3l4m5n6b7v8c9x0z", want: "/dev/shm/sess_<random>"},
+		{field: FieldPath, value: "/tmp/tmp.Xf3kL9qPz2", want: "/tmp/tmp.<random>"},
+		{field: FieldPath, value: "/var/tmp/tmpk3j_2x9a", want: "/var/tmp/tmp<random>"},
+		{field: FieldPath, value: "/tmp/ab", want: "/tmp/ab"},
+		// stable names are kept
+		{field: FieldPath, value: "/tmp/healthcheck", want: "/tmp/healthcheck"},
+		{field: FieldPath, value: "/tmp/app_config", want: "/tmp/app_config"},
+		{field: FieldPath, value: "/tmp/phpinfo.php", want: "/tmp/phpinfo.php"},
+		{field: FieldPath, value: "/dev/shm/sess_abcdef12", want: "/dev/shm/sess_abcdef12"},
+		{field: FieldPath, value: "/var/lib/kubelet/pods/0f8a3e2c-7d4b-4f1e-9a6b-2c3d4e5f6a7b/volumes", want: "/var/lib/kubelet/pods/<uuid>/volumes"},
+		{field: FieldPath, value: "/cache/0123456789abcdef0123.json", want: "/cache/<hash>.json"},
+		{field: FieldPath, value: "/usr/bin/python3", want: "/usr/bin/python3"},
+		{field: FieldIP, value: "pod/carts-5df4cd65d5-x8kqz", want: "pod/carts-<pod>"},
+		{field: FieldIP, value: "svc/carts", want: "svc/carts"},
+		{field: FieldIP, value: "10.0.0.1", want: "10.0.0.1"},
+		{field: FieldPod, value: "carts-5df4cd65d5-x8kqz", want: "carts-<pod>"},
+		{field: FieldPod, value: "mysql-0", want: "mysql-0"},
+		{field: FieldDeployment, value: "carts-5df4cd65d5", want: "carts"},
+		{field: FieldDeployment, value: "wordpress-mysql", want: "wordpress-mysql"},
+		// the ports are service ports, the ephemeral-port rule is optional
+		{field: FieldPort, value: "50051", want: "50051"},
+		{field: FieldPort, value: "33060", want: "33060"},
+		{field: FieldPort, value: "80", want: "80"},
+	}
+	n, err := NewNormalizer(BuiltinNormalizationRules)
+	if err != nil {
+		t.Fatalf("NewNormalizer() error = %v", err)
+	}
+	for _, tt := range tests {
+		if got := n.apply(tt.field, tt.value); got != tt.want {
+			t.Errorf("apply(%s, %q) = %q, want %q", tt.field, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoadNormalizer(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		field   string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "builtin", config: "rules: []", field: FieldPath, value: "/proc/1/environ", want: "/proc/<pid>/environ"},
+		{name: "disable builtin", config: "disableBuiltin: true\nrules: []", field: FieldPath, value: "/proc/1/environ", want: "/proc/1/environ"},
+		{name: "ephemeral port", config: "enable: [ephemeral-port]", field: FieldPort, value: "50051", want: "<ephemeral>"},
+		{name: "ephemeral port range", config: "enable: [ephemeral-port]", field: FieldPort, value: "32767", want: "32767"},
+		{
+			name:   "custom rule",
+			config: "rules:\n  - name: zoneinfo\n    field: path\n    match: ^/usr/share/zoneinfo/.*\n    replace: /usr/share/zoneinfo/<zone>\n",
+			field:  FieldPath, value: "/usr/share/zoneinfo/Europe/Paris", want: "/usr/share/zoneinfo/<zone>",
+		},
+		{name: "unknown optional rule", config: "enable: [client-port]", wantErr: true},
+		{name: "invalid field", config: "rules:\n  - field: pid\n    match: x\n", wantErr: true},
+		{name: "invalid regexp", config: "rules:\n  - field: path\n    match: '('\n", wantErr: true},
+		{name: "unknown key", config: "rule: []", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "rules.yaml")
+			if err := os.WriteFile(file, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			n, err := LoadNormalizer(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadNormalizer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := n.apply(tt.field, tt.value); got != tt.want {
+				t.Errorf("apply(%s, %q) = %q, want %q", tt.field, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEntry(t *testing.T) {
+	n, err := NewNormalizer(BuiltinNormalizationRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := &SummaryData{
+		DeploymentName: "carts-5df4cd65d5",
+		PodName:        "carts-5df4cd65d5-x8kqz",
+		Namespace:      "sock-shop",
+		ProcessData: []ProcessData{
+			{Source: "/bin/sh", Destination: "/proc/12/environ", Count: 2, Status: "Allow"},
+			{Source: "/bin/sh", Destination: "/proc/34/environ", Count: 3, Status: "Allow"},
+		},
+		EgressConnection: []EgressConnection{
+			{Protocol: "TCP", Command: "/usr/bin/java", IP: "pod/catalogue-7b5c9d6f8d-x8kqz", Port: "50051", Count: 1},
+			{Protocol: "TCP", Command: "/usr/bin/java", IP: "pod/catalogue-7b5c9d6f8d-fghjk", Port: "50051", Count: 4},
+		},
+		BindConnection: []BindConnection{{Protocol: "TCP", Command: "/usr/bin/java", BindPort: "33060", Count: 1}},
+	}
+	got := n.NormalizeEntry(sd)
+	if len(got.ProcessData) != 1 || got.ProcessData[0].Destination != "/proc/<pid>/environ" || got.ProcessData[0].Count != 5 {
+		t.Errorf("ProcessData = %+v, want a single /proc/<pid>/environ entry counted 5 times", got.ProcessData)
+	}
+	if len(got.EgressConnection) != 1 || got.EgressConnection[0].IP != "pod/catalogue-<pod>" || got.EgressConnection[0].Port != "50051" || got.EgressConnection[0].Count != 5 {
+		t.Errorf("EgressConnection = %+v, want a single pod/catalogue-<pod> entry on port 50051 counted 5 times", got.EgressConnection)
+	}
+	if len(got.BindConnection) != 1 || got.BindConnection[0].BindPort != "33060" {
+		t.Errorf("BindConnection = %+v, want the bind port 33060 kept", got.BindConnection)
+	}
+	if got.PodName != "carts-<pod>" || got.DeploymentName != "carts" || got.NormalizedBy != "pod-name,pod-template-hash" {
+		t.Errorf("PodName, DeploymentName = %q, %q normalized by %q, want carts-<pod>, carts normalized by pod-name,pod-template-hash",
+			got.PodName, got.DeploymentName, got.NormalizedBy)
+	}
+	if got.ProcessData[0].NormalizedBy != "proc-pid" || got.EgressConnection[0].NormalizedBy != "pod-name" || got.BindConnection[0].NormalizedBy != "" {
+		t.Errorf("NormalizedBy = %q, %q, %q, want proc-pid, pod-name and none",
+			got.ProcessData[0].NormalizedBy, got.EgressConnection[0].NormalizedBy, got.BindConnection[0].NormalizedBy)
+	}
+	if subs := n.Substitutions(); len(subs) != 6 {
+		t.Errorf("Substitutions() = %+v, want 6 substitutions", subs)
+	}
+}
+
+func TestNormalizeNetworkDiff(t *testing.T) {
+	// run returns the summary of a run where wordpress connects to mysql, with the pod hashes of the run
+	run := func(wordpressHash, mysqlHash string) []*SummaryData {
+		wordpress, mysql := "wordpress-5df4cd65d5-"+wordpressHash, "mysql-7b5c9d6f8d-"+mysqlHash
+		return []*SummaryData{
+			{
+				DeploymentName: "wordpress-5df4cd65d5", PodName: wordpress, Namespace: "wordpress-mysql", Label: "app=wordpress",
+				EgressConnection: []EgressConnection{{Protocol: "TCP", Command: "/usr/sbin/apache2", IP: "pod/" + mysql, Port: "3306", Count: 3}},
+			},
+			{
+				DeploymentName: "mysql-7b5c9d6f8d", PodName: mysql, Namespace: "wordpress-mysql", Label: "app=mysql",
+				IngressConnection: []IngressConnection{{Protocol: "TCP", Command: "/usr/sbin/mysqld", IP: "pod/" + wordpress, Port: "3306", Count: 3}},
+			},
+		}
+	}
+	normalize := func(sds []*SummaryData) []*SummaryData {
+		n, err := NewNormalizer(BuiltinNormalizationRules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n.Normalize(sds)
+	}
+	olds, news := normalize(run("4pl25", "x8kqz")), normalize(run("l2zl2", "fghjk"))
+
+	vn := ParseNetworkData(olds, news, nil)
+	// the egress of wordpress and the ingress of mysql are the same edge
+	if len(vn.Edges) != 1 {
+		t.Fatalf("Edges = %+v, want a single edge", vn.Edges)
+	}
+	for _, e := range vn.Edges {
+		if e.Change != "" || e.Source != "pod/wordpress-<pod>" || e.Destination != "pod/mysql-<pod>" {
+			t.Errorf("edge = %+v, want an unchanged pod/wordpress-<pod> -> pod/mysql-<pod> edge", e)
+		}
+	}
+	for _, c := range vn.Connections {
+		if strings.Contains(c, "++") || strings.Contains(c, "--") {
+			t.Errorf("connection %q is added or removed", strings.TrimSpace(c))
+		}
+	}
+	nodes := vn.NsIps["wordpress-mysql"]
+	sort.Strings(nodes)
+	if strings.Join(nodes, ",") != "pod/mysql-<pod>,pod/wordpress-<pod>" {
+		t.Errorf("NsIps = %v, want each pod once", vn.NsIps)
+	}
+	if report := Diff(olds, news, nil, nil); !report.IsEmpty() {
+		t.Errorf("Diff() = %+v, want no changes", report)
+	}
+}
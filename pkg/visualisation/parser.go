@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kubearmor/kubearmor-action/utils"
+	"github.com/kubearmor/kubearmor-action/utils/urlfile"
+	"k8s.io/klog"
+)
+
+// gzipMagic is the header of a gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ParseOptions configures how the summary data is parsed
+type ParseOptions struct {
+	// Strict rejects entries with unknown fields or missing required fields,
+	// as described by SummarySchema, instead of ignoring them
+	Strict bool
+	// Normalizer rewrites each entry to remove the run-to-run noise, nil leaves the entries as is
+	Normalizer *Normalizer
+	// Window selects the entries by their UpdatedTime, the relative bounds need the whole summary,
+	// so only ParseSummaryData and ReadSummaryData accept them
+	Window TimeWindow
+}
+
+// Older returns the options to parse the summary of an older run than the one the window was
+// given for, eg.: the baseline of a diff, see TimeWindow.Relative
+func (o ParseOptions) Older() ParseOptions {
+	o.Window = o.Window.Relative()
+	return o
+}
+
+// ParseSummaryData parses the summary data and returns a slice of SummaryData objects.
+// The path can be a local file, a remote url or "-" for the standard input,
+// and the content can be gzip compressed.
+func ParseSummaryData(path string) ([]*SummaryData, error) {
+	return ParseOptions{}.ParseSummaryData(path)
+}
+
+// WalkSummaryData streams the summary data from the given path and calls fn for each entry.
+// Only one entry is held in memory at a time, so it can handle very large summaries.
+func WalkSummaryData(path string, fn func(*SummaryData) error) error {
+	return ParseOptions{}.WalkSummaryData(path, fn)
+}
+
+// ReadSummaryData reads all the summary data entries from r.
+// The name is only used in error messages.
+func ReadSummaryData(r io.Reader, name string) ([]*SummaryData, error) {
+	return ParseOptions{}.ReadSummaryData(r, name)
+}
+
+// DecodeSummaryData decodes the summary data from r and calls fn for each entry.
+// The input is either a JSON array of entries, as written by karmor summary -o json,
+// or a stream of JSON objects. Gzip compressed input is detected and decompressed.
+// The name is only used in error messages.
+func DecodeSummaryData(r io.Reader, name string, fn func(*SummaryData) error) error {
+	return ParseOptions{}.DecodeSummaryData(r, name, fn)
+}
+
+// ParseSummaryData parses the summary data from the given path with the options, see ParseSummaryData
+func (o ParseOptions) ParseSummaryData(path string) ([]*SummaryData, error) {
+	window := o.Window
+	o.Window = TimeWindow{}
+	var summaryDatas []*SummaryData
+	err := o.WalkSummaryData(path, func(sd *SummaryData) error {
+		summaryDatas = append(summaryDatas, sd)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return window.Apply(summaryDatas), nil
+}
+
+// WalkSummaryData streams the summary data from the given path with the options, see WalkSummaryData
+func (o ParseOptions) WalkSummaryData(path string, fn func(*SummaryData) error) error {
+	rc, err := OpenSummaryData(path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return o.DecodeSummaryData(rc, path, fn)
+}
+
+// ReadSummaryData reads all the summary data entries from r with the options, see ReadSummaryData
+func (o ParseOptions) ReadSummaryData(r io.Reader, name string) ([]*SummaryData, error) {
+	window := o.Window
+	o.Window = TimeWindow{}
+	var summaryDatas []*SummaryData
+	err := o.DecodeSummaryData(r, name, func(sd *SummaryData) error {
+		summaryDatas = append(summaryDatas, sd)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return window.Apply(summaryDatas), nil
+}
+
+// DecodeSummaryData decodes the summary data from r with the options, see DecodeSummaryData
+func (o ParseOptions) DecodeSummaryData(r io.Reader, name string, fn func(*SummaryData) error) error {
+	if o.Window.IsRelative() {
+		return fmt.Errorf("the time window %s is relative to the newest entry, it cannot select the entries of a stream", o.Window)
+	}
+	var dropped, recovered int
+	err := walkRawSummaryData(r, name, func(index int, offset int64, raw json.RawMessage) error {
+		if o.Strict {
+			violations, err := validateEntry(raw, index, true)
+			if err != nil {
+				return &JSONError{Path: name, Offset: offset, Err: err}
+			}
+			if len(violations) > 0 {
+				return &SchemaError{Path: name, Index: index, Pointer: violations[0].Pointer, Offset: offset,
+					Err: errors.New(violations[0].Message)}
+			}
+		}
+		sd := &SummaryData{}
+		if err := json.Unmarshal(raw, sd); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return &SchemaError{Path: name, Index: index, Field: typeErr.Field, Offset: offset,
+					Err: fmt.Errorf("cannot use JSON %s as %s", typeErr.Value, typeErr.Type)}
+			}
+			// errors returned by the UnmarshalJSON methods, eg.: an invalid Count
+			return &SchemaError{Path: name, Index: index, Offset: offset, Err: err}
+		}
+		if sd.droppedContainerName {
+			dropped++
+		}
+		if sd.recoveredProcess {
+			recovered++
+		}
+		if !o.Window.IsZero() {
+			o.Window.apply(sd, time.Time{})
+		}
+		return fn(o.Normalizer.NormalizeEntry(sd))
+	})
+	if dropped > 0 {
+		klog.Warningf("%s: dropped %d container names holding a leaked record, recovered %d process entries from them", name, dropped, recovered)
+	}
+	return err
+}
+
+// OpenSummaryData opens the summary data from a local file, a remote url or "-" for the standard input
+func OpenSummaryData(path string) (io.ReadCloser, error) {
+	rc, err := utils.OpenFile(path)
+	if err != nil {
+		var se *urlfile.StatusError
+		if errors.As(err, &se) {
+			return nil, &HTTPError{URL: se.URL, StatusCode: se.StatusCode, Status: se.Status}
+		}
+		return nil, &IOError{Path: path, Err: err}
+	}
+	return rc, nil
+}
+
+// WriteSummaryData writes the summary data to w as an indented JSON array, the way karmor does
+func WriteSummaryData(w io.Writer, summaryDatas []*SummaryData) error {
+	if summaryDatas == nil {
+		summaryDatas = []*SummaryData{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	return enc.Encode(summaryDatas)
+}
+
+// walkRawSummaryData streams the raw SummaryData entries of r, see DecodeSummaryData
+func walkRawSummaryData(r io.Reader, name string, fn func(index int, offset int64, raw json.RawMessage) error) error {
+	src := &readErrRecorder{r: r}
+	br := bufio.NewReader(src)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return &IOError{Path: name, Err: err}
+	}
+	if bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return &IOError{Path: name, Err: err}
+		}
+		defer zr.Close()
+		src = &readErrRecorder{r: zr}
+		br = bufio.NewReader(src)
+	}
+
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		// an empty input means no entries
+		return nil
+	}
+	if err != nil {
+		return &IOError{Path: name, Err: err}
+	}
+
+	dec := json.NewDecoder(br)
+	isArray := first == '['
+	if isArray {
+		// consume the opening bracket
+		if _, err := dec.Token(); err != nil {
+			return decodeError(dec, src, name, err)
+		}
+	}
+
+	for index := 0; ; index++ {
+		if isArray && !dec.More() {
+			break
+		}
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if !isArray && err == io.EOF {
+			break
+		}
+		if err != nil {
+			return decodeError(dec, src, name, err)
+		}
+		// the entry starts right before the decoder position
+		offset := dec.InputOffset() - int64(len(raw))
+		if err := fn(index, offset, raw); err != nil {
+			return err
+		}
+	}
+
+	if isArray {
+		// consume the closing bracket
+		if _, err := dec.Token(); err != nil {
+			return decodeError(dec, src, name, err)
+		}
+	}
+	return nil
+}
+
+// readErrRecorder remembers the first read error of the underlying reader,
+// so that I/O failures can be told apart from malformed JSON
+type readErrRecorder struct {
+	r   io.Reader
+	err error
+}
+
+// Read implements the io.Reader interface
+func (rr *readErrRecorder) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if err != nil && err != io.EOF && rr.err == nil {
+		rr.err = err
+	}
+	return n, err
+}
+
+// peekNonSpace returns the first byte of br that is not white space, without consuming anything
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for n := 1; ; n++ {
+		b, err := br.Peek(n)
+		if err != nil {
+			return 0, err
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		default:
+			return b[n-1], nil
+		}
+	}
+}
+
+// decodeError converts an error returned by the json decoder to a typed error
+func decodeError(dec *json.Decoder, src *readErrRecorder, name string, err error) error {
+	// read errors of the underlying reader, eg.: a broken gzip stream or connection
+	if src.err != nil {
+		return &IOError{Path: name, Err: src.err}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &JSONError{Path: name, Offset: syntaxErr.Offset, Err: err}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &JSONError{Path: name, Offset: dec.InputOffset(), Err: io.ErrUnexpectedEOF}
+	}
+	return &JSONError{Path: name, Offset: dec.InputOffset(), Err: err}
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSummaryData(t *testing.T) {
+	const (
+		carts   = `{"DeploymentName": "carts", "PodName": "carts", "ClusterName": "default", "Namespace": "sock-shop", "Label": "name=carts"}`
+		catalog = `{"DeploymentName": "catalogue", "PodName": "catalogue", "ClusterName": "default", "Namespace": "sock-shop", "Label": "name=catalogue"}`
+	)
+	gzipped := func(s string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.String()
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "array", input: "[" + carts + ",\n" + catalog + "]", want: []string{"carts", "catalogue"}},
+		{name: "stream", input: carts + "\n" + catalog + "\n", want: []string{"carts", "catalogue"}},
+		{name: "empty array", input: "[]"},
+		{name: "empty", input: "  \n"},
+		{name: "gzip", input: gzipped("[" + carts + "]"), want: []string{"carts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sds, err := ReadSummaryData(strings.NewReader(tt.input), "input")
+			if err != nil {
+				t.Fatalf("ReadSummaryData() error = %v", err)
+			}
+			var got []string
+			for _, sd := range sds {
+				got = append(got, sd.DeploymentName)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ReadSummaryData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSummaryDataErrors(t *testing.T) {
+	const entry = `"DeploymentName": "carts", "PodName": "carts", "ClusterName": "default", "Namespace": "sock-shop", "Label": "name=carts"`
+	tests := []struct {
+		name   string
+		input  string
+		check  func(error) bool
+		offset int64
+		index  int
+	}{
+		{
+			name:  "malformed",
+			input: `[{` + entry + `}, {"DeploymentName": }]`,
+			check: func(err error) bool {
+				var je *JSONError
+				return errors.As(err, &je) && je.Path == "input" && je.Offset > 0
+			},
+		},
+		{
+			name:  "wrong type",
+			input: `[{` + entry + `}, {` + entry + `, "ProcessData": {}}]`,
+			check: func(err error) bool {
+				var se *SchemaError
+				return errors.As(err, &se) && se.Index == 1 && se.Field == "ProcessData"
+			},
+		},
+		{
+			name:  "invalid count",
+			input: `[{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "Count": "many", "Status": "Allow"}]}]`,
+			check: func(err error) bool {
+				var se *SchemaError
+				return errors.As(err, &se) && se.Index == 0 && strings.Contains(se.Error(), `"many"`)
+			},
+		},
+		{
+			name:  "truncated",
+			input: `[{` + entry + `}`,
+			check: func(err error) bool {
+				var je *JSONError
+				return errors.As(err, &je)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadSummaryData(strings.NewReader(tt.input), "input")
+			if err == nil || !tt.check(err) {
+				t.Errorf("ReadSummaryData() error = %v (%T)", err, err)
+			}
+		})
+	}
+}
+
+func TestParseSummaryDataIOError(t *testing.T) {
+	_, err := ParseSummaryData(filepath.Join(t.TempDir(), "missing.json"))
+	var ioErr *IOError
+	if !errors.As(err, &ioErr) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ParseSummaryData() error = %v, want an IOError wrapping os.ErrNotExist", err)
+	}
+}
+
+func TestWriteSummaryData(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSummaryData(&buf, nil); err != nil {
+		t.Fatalf("WriteSummaryData() error = %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("WriteSummaryData(nil) = %q, want []", got)
+	}
+
+	sds := []*SummaryData{{DeploymentName: "carts", PodName: "carts", Namespace: "sock-shop", Label: "name=carts",
+		FileData: []FileData{{Source: "/bin/sh", Destination: "/etc/<passwd>", Count: 2, Status: "Allow"}}}}
+	buf.Reset()
+	if err := WriteSummaryData(&buf, sds); err != nil {
+		t.Fatalf("WriteSummaryData() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "/etc/<passwd>") {
+		t.Errorf("WriteSummaryData() escapes HTML:\n%s", buf.String())
+	}
+	got, err := ReadSummaryData(&buf, "output")
+	if err != nil {
+		t.Fatalf("ReadSummaryData() error = %v", err)
+	}
+	if len(got) != 1 || len(got[0].FileData) != 1 || got[0].FileData[0] != sds[0].FileData[0] {
+		t.Errorf("ReadSummaryData(WriteSummaryData()) = %+v, want %+v", got, sds)
+	}
+}
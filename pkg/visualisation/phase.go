@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPhasesFile is the file the workflow steps record the phases of a run in
+const DefaultPhasesFile = "kubearmor-phases.txt"
+
+// Marks of a phase
+const (
+	PhaseStart = "start"
+	PhaseEnd   = "end"
+)
+
+// Phase is a named part of a run recorded by the workflow steps, eg.: a load test
+type Phase struct {
+	Name string
+	// Start and End are the marks of the phase, zero if not recorded
+	Start time.Time
+	End   time.Time
+}
+
+// Window returns the time window of the phase, open on the ends that were not recorded. The start
+// is truncated to the second, as karmor records the times of the entries to the second.
+func (p Phase) Window() TimeWindow {
+	var w TimeWindow
+	if !p.Start.IsZero() {
+		w.Since = &TimeBound{Time: p.Start.Truncate(time.Second)}
+	}
+	if !p.End.IsZero() {
+		w.Until = &TimeBound{Time: p.End}
+	}
+	return w
+}
+
+// Phases are the phases of a run by name
+type Phases map[string]*Phase
+
+// ReadPhases reads the phases recorded in a file, one mark per line as "<phase> start|end <time>",
+// eg.: "load-test start 2023-07-03T08:26:40Z". The time is RFC 3339 or in the karmor layout, empty
+// lines and lines starting with # are skipped, and a mark recorded twice keeps the last time.
+func ReadPhases(path string) (Phases, error) {
+	file, err := os.Open(path) // #nosec
+	if err != nil {
+		return nil, fmt.Errorf("reading phases: %w", err)
+	}
+	defer file.Close()
+	phases := Phases{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, " ", 3)
+		if len(fields) != 3 || (fields[1] != PhaseStart && fields[1] != PhaseEnd) {
+			return nil, fmt.Errorf("%s:%d: invalid phase mark %q, must be \"<phase> start|end <time>\"", path, line, text)
+		}
+		t, err := ParseTimestamp(fields[2])
+		if err != nil || t.IsZero() {
+			return nil, fmt.Errorf("%s:%d: invalid time of phase %s: %q", path, line, fields[0], fields[2])
+		}
+		p, ok := phases[fields[0]]
+		if !ok {
+			p = &Phase{Name: fields[0]}
+			phases[fields[0]] = p
+		}
+		if fields[1] == PhaseStart {
+			p.Start = t.Time
+		} else {
+			p.End = t.Time
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading phases %s: %w", path, err)
+	}
+	return phases, nil
+}
+
+// Get returns the phase by name
+func (ps Phases) Get(name string) (*Phase, error) {
+	if p, ok := ps[name]; ok {
+		return p, nil
+	}
+	names := make([]string, 0, len(ps))
+	for n := range ps {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("phase %q is not recorded, recorded phases: [%s]", name, strings.Join(names, ", "))
+}
+
+// RecordPhase appends a mark of the phase at t to the file with nanoseconds, see ReadPhases
+func RecordPhase(path, name, mark string, t time.Time) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid phase name %q, must be a word", name)
+	}
+	if mark != PhaseStart && mark != PhaseEnd {
+		return fmt.Errorf("invalid phase mark %q, must be %s or %s", mark, PhaseStart, PhaseEnd)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec
+	if err != nil {
+		return fmt.Errorf("recording phase: %w", err)
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintf(file, "%s %s %s\n", name, mark, t.UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("recording phase: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	"k8s.io/klog"
+)
+
+// maxTreeDepth bounds the depth of a process tree, exec graphs may have long chains
+const maxTreeDepth = 16
+
+var (
+	// appRuntimes are the binaries of application runtimes and servers, without their version, see binaryName
+	appRuntimes = map[string]bool{
+		"php": true, "php-fpm": true, "php-cgi": true, "python": true,
+		"node": true, "java": true, "ruby": true, "perl": true, "apache": true, "httpd": true,
+		"nginx": true, "mysqld": true, "postgres": true, "redis-server": true, "dotnet": true,
+	}
+	// shells are the command interpreters
+	shells = map[string]bool{
+		"sh": true, "bash": true, "dash": true, "ash": true, "zsh": true, "ksh": true, "csh": true, "busybox": true,
+	}
+	// networkTools are the binaries used to download payloads or open connections
+	networkTools = map[string]bool{
+		"curl": true, "wget": true, "nc": true, "ncat": true, "netcat": true, "socat": true, "telnet": true,
+		"ssh": true, "scp": true, "ftp": true, "tftp": true,
+	}
+)
+
+// ProcessNode is a process in an execution tree
+type ProcessNode struct {
+	// Path is the binary (or path) of the process
+	Path string `json:"Path"`
+	// Count is the number of times the process was spawned by its parent,
+	// for a root it is the number of processes it spawned
+	Count Count `json:"Count"`
+	// Depth is the distance from the root, roots have depth 0
+	Depth int `json:"Depth"`
+	// Status is the most restrictive status of the executions of the process by its parent
+	Status string `json:"Status,omitempty"`
+	// Unusual is the reason why the chain leading to this process is unusual, if it is
+	Unusual string `json:"Unusual,omitempty"`
+	// Findings are the IDs of the rules the execution of the process by its parent matches
+	Findings []string `json:"Findings,omitempty"`
+	// Techniques are the MITRE ATT&CK technique IDs of the execution of the process by its parent
+	Techniques []string `json:"Techniques,omitempty"`
+	// Ref is set when the process was expanded earlier in the tree, or is on a cycle,
+	// its children are not repeated
+	Ref      bool           `json:"Ref,omitempty"`
+	Children []*ProcessNode `json:"Children,omitempty"`
+}
+
+// ProcessTree is the process execution tree of a workload
+type ProcessTree struct {
+	Workload WorkloadID     `json:"Workload"`
+	Roots    []*ProcessNode `json:"Roots"`
+}
+
+// UnusualChain is a chain of processes ending with an unusual process
+type UnusualChain struct {
+	Workload WorkloadID `json:"Workload"`
+	Chain    []string   `json:"Chain"`
+	Reason   string     `json:"Reason"`
+}
+
+// String returns the chain as a -> b -> c
+func (c UnusualChain) String() string {
+	return strings.Join(c.Chain, " -> ")
+}
+
+// execEdge is the executions of a child process by a parent process
+type execEdge struct {
+	count  Count
+	status string
+}
+
+// expansion identifies the subtree of a process: the unusual chains below the process depend on
+// the runtime that spawned the nearest shell above it, see shellRuntime
+type expansion struct {
+	path    string
+	runtime string
+}
+
+// BuildProcessTrees rebuilds the process execution trees from the ProcessData
+// Source -> Destination pairs, one tree per workload.
+// Only the workloads and processes selected by the filter are included.
+func BuildProcessTrees(summaryDatas []*SummaryData, f *filter.Filter) []*ProcessTree {
+	edges := make(map[WorkloadID]map[string]map[string]execEdge)
+	var ids []WorkloadID
+	for _, sd := range summaryDatas {
+		if !f.MatchWorkload(filterWorkload(sd)) {
+			continue
+		}
+		id := WorkloadOf(sd)
+		if _, ok := edges[id]; !ok {
+			edges[id] = make(map[string]map[string]execEdge)
+			ids = append(ids, id)
+		}
+		for _, ps := range sd.ProcessData {
+			status := NormalizeStatus(ps.Status)
+			if !f.MatchExec(ps.Source, ps.Destination) || !f.MatchStatus(status) {
+				continue
+			}
+			if _, ok := edges[id][ps.Source]; !ok {
+				edges[id][ps.Source] = make(map[string]execEdge)
+			}
+			e := edges[id][ps.Source][ps.Destination]
+			edges[id][ps.Source][ps.Destination] = execEdge{count: e.count + ps.Count, status: worseStatus(e.status, status)}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+
+	trees := make([]*ProcessTree, 0, len(ids))
+	for _, id := range ids {
+		trees = append(trees, buildProcessTree(id, edges[id]))
+	}
+	return trees
+}
+
+// buildProcessTree builds the tree of a workload from its parent -> child edges
+func buildProcessTree(id WorkloadID, edges map[string]map[string]execEdge) *ProcessTree {
+	spawned := make(map[string]bool)
+	for parent, children := range edges {
+		for child := range children {
+			if child != parent {
+				spawned[child] = true
+			}
+		}
+	}
+	parents := make([]string, 0, len(edges))
+	for parent := range edges {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+
+	tree := &ProcessTree{Workload: id}
+	b := &treeBuilder{edges: edges, visited: make(map[string]bool), expanded: make(map[expansion]bool), onChain: make(map[string]bool)}
+	for _, parent := range parents {
+		if spawned[parent] {
+			continue
+		}
+		tree.Roots = append(tree.Roots, b.expand(parent, execEdge{}, nil))
+	}
+	// processes only reachable through a cycle have no root, start from them
+	for _, parent := range parents {
+		if b.visited[parent] {
+			continue
+		}
+		tree.Roots = append(tree.Roots, b.expand(parent, execEdge{}, nil))
+	}
+	return tree
+}
+
+// treeBuilder expands the processes of a workload. Each process is expanded once (per runtime
+// that spawned the nearest shell above it), the other executions of the process are reference
+// nodes, so that diamond shaped exec graphs do not blow up the tree.
+type treeBuilder struct {
+	edges    map[string]map[string]execEdge
+	visited  map[string]bool
+	expanded map[expansion]bool
+	onChain  map[string]bool
+}
+
+// expand expands the process at the end of chain, reached through the edge e, the processes
+// already expanded or on the chain, eg.: bash -> bash, and the processes beyond maxTreeDepth
+// are not expanded
+func (b *treeBuilder) expand(p string, e execEdge, chain []string) *ProcessNode {
+	b.visited[p] = true
+	chain = append(chain, p)
+	node := newProcessNode(p, e, chain)
+	if len(chain) == 1 {
+		for _, c := range b.edges[p] {
+			node.Count += c.count
+		}
+	}
+	if len(chain) > maxTreeDepth || len(b.edges[p]) == 0 {
+		return node
+	}
+	key := expansion{path: p, runtime: shellRuntime(chain)}
+	if b.onChain[p] || b.expanded[key] {
+		node.Ref = true
+		return node
+	}
+	b.expanded[key] = true
+	b.onChain[p] = true
+	defer delete(b.onChain, p)
+
+	children := make([]string, 0, len(b.edges[p]))
+	for child := range b.edges[p] {
+		children = append(children, child)
+	}
+	sort.Strings(children)
+	for _, child := range children {
+		// copy the chain so that siblings do not share the backing array
+		node.Children = append(node.Children, b.expand(child, b.edges[p][child], append([]string(nil), chain...)))
+	}
+	return node
+}
+
+// newProcessNode returns the node of the process at the end of chain, reached through the edge e,
+// with its findings
+func newProcessNode(p string, e execEdge, chain []string) *ProcessNode {
+	node := &ProcessNode{Path: p, Count: e.count, Status: e.status, Depth: len(chain) - 1, Unusual: unusualChain(chain)}
+	if len(chain) > 1 {
+		b := Behavior{Kind: KindProcess, Source: chain[len(chain)-2], Destination: p}
+		for _, finding := range BuiltinClassifier().Classify(b) {
+			node.Findings = append(node.Findings, finding.RuleID)
+		}
+		node.Techniques = BuiltinAttackCatalog().Map(b, node.Findings...)
+	}
+	return node
+}
+
+// unusualChain returns why the chain of processes is unusual, or an empty string
+func unusualChain(chain []string) string {
+	if len(chain) < 2 {
+		return ""
+	}
+	last := binaryName(chain[len(chain)-1])
+	parent := binaryName(chain[len(chain)-2])
+	if shells[last] && appRuntimes[parent] {
+		return fmt.Sprintf("shell %s spawned by application runtime %s", last, parent)
+	}
+	if networkTools[last] {
+		if appRuntimes[parent] {
+			return fmt.Sprintf("network tool %s spawned by application runtime %s", last, parent)
+		}
+		// look for a shell spawned by a runtime up the chain, eg.: php -> sh -> curl
+		if runtime := shellRuntime(chain[:len(chain)-1]); runtime != "" {
+			return fmt.Sprintf("network tool %s run from a shell spawned by application runtime %s", last, runtime)
+		}
+	}
+	return ""
+}
+
+// shellRuntime returns the application runtime that spawned the nearest shell of the chain, or an empty string
+func shellRuntime(chain []string) string {
+	for i := len(chain) - 1; i > 0; i-- {
+		if shells[binaryName(chain[i])] && appRuntimes[binaryName(chain[i-1])] {
+			return binaryName(chain[i-1])
+		}
+	}
+	return ""
+}
+
+// versionSuffix is the version at the end of a binary name, eg.: 8.2 of php-fpm8.2
+var versionSuffix = regexp.MustCompile(`[0-9][0-9.]*$`)
+
+// binaryName returns the name of a binary without its directory and version suffix,
+// eg.: /usr/bin/python3.11 -> python, /usr/sbin/php-fpm8.2 -> php-fpm
+func binaryName(p string) string {
+	name := path.Base(strings.TrimSpace(p))
+	if trimmed := versionSuffix.ReplaceAllString(name, ""); trimmed != "" {
+		name = trimmed
+	}
+	return name
+}
+
+// UnusualChains returns the chains of the tree that end with an unusual process
+func (t *ProcessTree) UnusualChains() []UnusualChain {
+	var chains []UnusualChain
+	var walk func(n *ProcessNode, chain []string)
+	walk = func(n *ProcessNode, chain []string) {
+		chain = append(append([]string(nil), chain...), n.Path)
+		if n.Unusual != "" {
+			chains = append(chains, UnusualChain{Workload: t.Workload, Chain: chain, Reason: n.Unusual})
+		}
+		for _, c := range n.Children {
+			walk(c, chain)
+		}
+	}
+	for _, r := range t.Roots {
+		walk(r, nil)
+	}
+	return chains
+}
+
+// ConvertProcessTreesToPlantUML converts the process trees to a PlantUML mind map,
+// one branch per workload, blocked and audited processes are colored with their status color,
+// the other unusual or sensitive processes orange
+func ConvertProcessTreesToPlantUML(trees []*ProcessTree) []byte {
+	var sb strings.Builder
+	sb.WriteString("@startmindmap\n")
+	sb.WriteString("* processes\n")
+	for _, t := range trees {
+		sb.WriteString(fmt.Sprintf("**[#Lightblue] %s\n", t.Workload))
+		var walk func(n *ProcessNode)
+		walk = func(n *ProcessNode) {
+			color := ""
+			if c, ok := statusColors[n.Status]; ok {
+				color = "[#" + c + "]"
+			} else if n.Unusual != "" || len(n.Findings) > 0 {
+				color = "[#Orange]"
+			}
+			status := ""
+			if n.Status != "" && n.Status != StatusAllow {
+				status = ", " + n.Status
+			}
+			if len(n.Findings) > 0 {
+				status += ", " + strings.Join(n.Findings, " ")
+			}
+			if len(n.Techniques) > 0 {
+				status += ", " + strings.Join(n.Techniques, " ")
+			}
+			if n.Ref {
+				status += ", see above"
+			}
+			sb.WriteString(fmt.Sprintf("%s%s %s (x%d, depth %d%s)\n", strings.Repeat("*", n.Depth+3), color, n.Path, n.Count, n.Depth, status))
+			for _, c := range n.Children {
+				walk(c)
+			}
+		}
+		for _, r := range t.Roots {
+			walk(r)
+		}
+	}
+	sb.WriteString("@endmindmap\n")
+	return []byte(sb.String())
+}
+
+// ConvertProcessTreeJSONToImage converts the summary process data to a plantuml process tree image
+// The summary data is parsed with p before it is visualised, see ParseOptions.
+func ConvertProcessTreeJSONToImage(jsonFile string, output string, f *filter.Filter, p ParseOptions) error {
+	err := checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	// get summary data from json file
+	klog.Infoln("Parsing Summary Data...")
+	sd, err := p.ParseSummaryData(jsonFile)
+	if err != nil {
+		return err
+	}
+	logSubstitutions(p.Normalizer)
+
+	klog.Infoln("Building Process Trees...")
+	trees := BuildProcessTrees(sd, f)
+	for _, t := range trees {
+		for _, c := range t.UnusualChains() {
+			klog.Warningf("%s: unusual process chain %s: %s", c.Workload, c, c.Reason)
+		}
+	}
+
+	err = renderPlantUML("proc", ConvertProcessTreesToPlantUML(trees), output)
+	if err != nil {
+		return err
+	}
+	klog.Infoln("Image Created Successfully!")
+	return nil
+}
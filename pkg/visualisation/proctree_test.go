@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// processTree builds the tree of a single workload from parent -> child pairs
+func processTree(t *testing.T, pairs ...[2]string) *ProcessTree {
+	t.Helper()
+	sd := &SummaryData{DeploymentName: "wordpress", PodName: "wordpress", Namespace: "wordpress-mysql"}
+	for _, p := range pairs {
+		sd.ProcessData = append(sd.ProcessData, ProcessData{Source: p[0], Destination: p[1], Count: 1, Status: StatusAllow})
+	}
+	trees := BuildProcessTrees([]*SummaryData{sd}, nil)
+	if len(trees) != 1 {
+		t.Fatalf("BuildProcessTrees() = %d trees, want 1", len(trees))
+	}
+	return trees[0]
+}
+
+// countNodes returns the number of nodes and of reference nodes of the tree
+func countNodes(tree *ProcessTree) (nodes, refs int) {
+	var walk func(n *ProcessNode)
+	walk = func(n *ProcessNode) {
+		nodes++
+		if n.Ref {
+			refs++
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, r := range tree.Roots {
+		walk(r)
+	}
+	return nodes, refs
+}
+
+func TestBuildProcessTreeDiamond(t *testing.T) {
+	// 7 stacked diamonds: /bin/s0 -> /bin/a0, /bin/b0 -> /bin/s1 -> ..., 2^7 chains
+	var pairs [][2]string
+	for i := 0; i < 7; i++ {
+		s, next := fmt.Sprintf("/bin/s%d", i), fmt.Sprintf("/bin/s%d", i+1)
+		a, b := fmt.Sprintf("/bin/a%d", i), fmt.Sprintf("/bin/b%d", i)
+		pairs = append(pairs, [2]string{s, a}, [2]string{s, b}, [2]string{a, next}, [2]string{b, next})
+	}
+	tree := processTree(t, pairs...)
+	nodes, refs := countNodes(tree)
+	// each process is expanded once, the bottom of each diamond is a reference below its second
+	// parent, but the last one which has no children
+	if nodes != 29 || refs != 6 {
+		t.Errorf("tree has %d nodes and %d references, want 29 and 6", nodes, refs)
+	}
+	if len(tree.Roots) != 1 || tree.Roots[0].Path != "/bin/s0" {
+		t.Errorf("Roots = %+v, want /bin/s0", tree.Roots)
+	}
+}
+
+func TestBuildProcessTreeCycle(t *testing.T) {
+	tree := processTree(t,
+		[2]string{"/bin/bash", "/bin/sh"},
+		[2]string{"/bin/sh", "/bin/bash"},
+		[2]string{"/bin/sh", "/bin/sh"},
+	)
+	// the processes are only reachable through the cycle, the first one is the root
+	if len(tree.Roots) != 1 || tree.Roots[0].Path != "/bin/bash" {
+		t.Fatalf("Roots = %+v, want /bin/bash", tree.Roots)
+	}
+	sh := tree.Roots[0].Children
+	if len(sh) != 1 || sh[0].Path != "/bin/sh" || sh[0].Ref {
+		t.Fatalf("Children = %+v, want /bin/sh expanded", sh)
+	}
+	var got []string
+	for _, c := range sh[0].Children {
+		got = append(got, fmt.Sprintf("%s ref=%v", c.Path, c.Ref))
+	}
+	if strings.Join(got, ", ") != "/bin/bash ref=true, /bin/sh ref=true" {
+		t.Errorf("/bin/sh children = %v, want references to /bin/bash and /bin/sh", got)
+	}
+}
+
+func TestBuildProcessTreeUnusualChains(t *testing.T) {
+	tree := processTree(t,
+		[2]string{"/usr/sbin/php-fpm8.2", "/bin/sh"},
+		[2]string{"/usr/sbin/apache2", "/bin/sh"},
+		[2]string{"/bin/sh", "/usr/bin/curl"},
+		[2]string{"/usr/bin/tini", "/bin/sh"},
+	)
+	var got []string
+	for _, c := range tree.UnusualChains() {
+		got = append(got, c.String()+": "+c.Reason)
+	}
+	// /bin/sh is expanded again below each runtime, the curl chains are kept
+	want := []string{
+		"/usr/sbin/apache2 -> /bin/sh: shell sh spawned by application runtime apache",
+		"/usr/sbin/apache2 -> /bin/sh -> /usr/bin/curl: network tool curl run from a shell spawned by application runtime apache",
+		"/usr/sbin/php-fpm8.2 -> /bin/sh: shell sh spawned by application runtime php-fpm",
+		"/usr/sbin/php-fpm8.2 -> /bin/sh -> /usr/bin/curl: network tool curl run from a shell spawned by application runtime php-fpm",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("UnusualChains() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// the versioned runtimes match the shell rule
+	for _, r := range tree.Roots {
+		if r.Path == "/usr/sbin/php-fpm8.2" && (len(r.Children) != 1 || strings.Join(r.Children[0].Findings, ",") != "KA-PROC-001") {
+			t.Errorf("php-fpm8.2 children = %+v, want /bin/sh with KA-PROC-001", r.Children)
+		}
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/usr/bin/php8.2", want: "php"},
+		{path: "/usr/sbin/php-fpm8.2", want: "php-fpm"},
+		{path: "/usr/bin/python3.11", want: "python"},
+		{path: "/usr/bin/python3", want: "python"},
+		{path: "/usr/sbin/apache2", want: "apache"},
+		{path: "/usr/bin/lua5.3", want: "lua"},
+		{path: "/bin/bash", want: "bash"},
+		{path: "/usr/sbin/redis-server", want: "redis-server"},
+		{path: " /usr/bin/node ", want: "node"},
+		{path: "/opt/bin/7", want: "7"},
+	}
+	for _, tt := range tests {
+		if got := binaryName(tt.path); got != tt.want {
+			t.Errorf("binaryName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,608 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	"k8s.io/klog"
+)
+
+// Report formats
+const (
+	FormatMarkdown = "markdown"
+)
+
+// Defaults of the Markdown report
+const (
+	DefaultReportTitle    = "KubeArmor Behavior Report"
+	DefaultReportMaxRows  = 50
+	DefaultReportMaxEdges = 100
+	// DefaultReportMaxLength is below the 65536 characters of a GitHub comment, with room for the
+	// marker of the comment
+	DefaultReportMaxLength = 60000
+)
+
+// ReportLink is an artifact linked at the end of the report, eg.: the images or the summaries
+type ReportLink struct {
+	Name string `json:"Name"`
+	URL  string `json:"URL"`
+}
+
+// ParseReportLink parses a link given as name=url, the name defaults to the file name of the URL
+func ParseReportLink(s string) (ReportLink, error) {
+	name, url := "", strings.TrimSpace(s)
+	if i := strings.Index(s, "="); i > 0 && !strings.Contains(s[:i], "://") {
+		name, url = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	}
+	if url == "" {
+		return ReportLink{}, fmt.Errorf("invalid artifact %q, must be [name=]url", s)
+	}
+	if name == "" {
+		name = path.Base(url)
+	}
+	return ReportLink{Name: name, URL: url}, nil
+}
+
+// ReportOptions configures the Markdown report
+type ReportOptions struct {
+	// Title is the heading of the report, DefaultReportTitle if empty
+	Title string
+	// Artifacts are linked at the end of the report, the images are shown inline
+	Artifacts []ReportLink
+	// MaxRows is the number of rows of every table, the rest is only counted, DefaultReportMaxRows if 0
+	MaxRows int
+	// MaxEdges is the number of connections of the network diagram, unchanged connections are
+	// left out first, DefaultReportMaxEdges if 0
+	MaxEdges int
+	// MaxLength is the number of characters of the report, the sections are truncated beyond it
+	// with a link to the artifacts, DefaultReportMaxLength if 0
+	MaxLength int
+	// Diff configures the comparison of the summaries of ReportJSONFiles
+	Diff DiffOptions
+}
+
+// withDefaults returns the options with the zero values set to the defaults
+func (o ReportOptions) withDefaults() ReportOptions {
+	if o.Title == "" {
+		o.Title = DefaultReportTitle
+	}
+	if o.MaxRows <= 0 {
+		o.MaxRows = DefaultReportMaxRows
+	}
+	if o.MaxEdges <= 0 {
+		o.MaxEdges = DefaultReportMaxEdges
+	}
+	if o.MaxLength <= 0 {
+		o.MaxLength = DefaultReportMaxLength
+	}
+	return o
+}
+
+// MarkdownReport renders the diff report and the network diff as a Markdown pull request comment:
+// the risk highlights, a table of the changes per workload with collapsible details,
+// a Mermaid diagram of the network diff and the links to the artifacts. vnd may be nil.
+func MarkdownReport(report *DiffReport, vnd *VisualNetworkData, opts ReportOptions) string {
+	opts = opts.withDefaults()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n\n", opts.Title))
+
+	added, removed, changed, volume, findings := 0, 0, 0, 0, 0
+	for _, wd := range report.Workloads {
+		added += len(wd.Added)
+		removed += len(wd.Removed)
+		changed += len(wd.Changed)
+		volume += len(wd.Volume)
+		findings += len(newFindings(wd))
+	}
+	sb.WriteString(fmt.Sprintf("**Risk: %s %s** · %d new findings · %d added, %d removed, %d changed behaviors in %d workloads",
+		gradeIcon(report.Risk.Grade), report.Risk, findings, added, removed, changed, len(report.Workloads)))
+	if volume > 0 {
+		sb.WriteString(fmt.Sprintf(" · %d volume changes", volume))
+	}
+	sb.WriteString("\n")
+	if top := report.Risk.Top; top != nil && top.Behavior.Risk > 0 {
+		sb.WriteString(fmt.Sprintf("\n> Riskiest change: %s %s %s, risk %d\n", top.Workload, top.Change, mdCode(top.Behavior.String()), top.Behavior.Risk))
+	}
+	if report.IsEmpty() {
+		sb.WriteString("\nNo behavior changed.\n")
+	}
+
+	writeRiskHighlights(&sb, report, opts.MaxRows)
+	writeWorkloads(&sb, report, opts.MaxRows)
+	if vnd != nil && len(vnd.Edges) > 0 {
+		sb.WriteString("\n### Network\n\n")
+		sb.WriteString("Added connections are red, removed ones dashed red, volume spikes ▲ bold red and drops ▼ bold orange; selected workloads are orange, sensitive peers red.\n\n")
+		sb.WriteString("```mermaid\n")
+		sb.WriteString(ConvertVndToMermaid(vnd, opts.MaxEdges))
+		sb.WriteString("```\n")
+	}
+
+	var tail strings.Builder
+	if len(opts.Artifacts) > 0 {
+		tail.WriteString("\n### Artifacts\n\n")
+		for _, a := range opts.Artifacts {
+			tail.WriteString(fmt.Sprintf("- [%s](%s)\n", a.Name, a.URL))
+		}
+		for _, a := range opts.Artifacts {
+			if isImage(a.URL) {
+				tail.WriteString(fmt.Sprintf("\n<details><summary>%s</summary>\n\n![%s](%s)\n\n</details>\n", a.Name, a.Name, a.URL))
+			}
+		}
+	}
+	tail.WriteString(fmt.Sprintf("\n<sub>ATT&CK techniques from %s</sub>\n", report.Attack))
+	return truncateReport(sb.String(), tail.String(), len(opts.Artifacts) > 0, opts.MaxLength)
+}
+
+// FirstRunReport renders the report of a run without baseline to compare with: why there is none,
+// the behaviors per workload and the sensitive behaviors. report is the diff with an empty baseline,
+// whose behaviors are all added, so it has no risk grade.
+func FirstRunReport(report *DiffReport, reason string, opts ReportOptions) string {
+	opts = opts.withDefaults()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n\n", opts.Title))
+
+	behaviors := 0
+	for _, wd := range report.Workloads {
+		behaviors += len(wd.Added)
+	}
+	findings := report.Findings()
+	sb.WriteString(fmt.Sprintf("**First run** · %d behaviors in %d workloads · %d sensitive behaviors\n\n", behaviors, len(report.Workloads), len(findings)))
+	sb.WriteString(fmt.Sprintf("> No baseline to compare with: %s. The summary of this run is the baseline of the next runs, no risk grade is given.\n", reason))
+
+	if len(report.Workloads) > 0 {
+		sb.WriteString("\n### Workloads\n\n")
+		sb.WriteString("| Workload | Processes | Files | Connections | Sensitive |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, wd := range report.Workloads {
+			counts := map[string]int{}
+			for _, b := range wd.Added {
+				counts[b.Kind]++
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d |\n", mdCell(wd.Workload.String()), counts[KindProcess], counts[KindFile],
+				counts[KindIngress]+counts[KindEgress]+counts[KindBind], len(wd.Findings)))
+		}
+	}
+	if len(findings) > 0 {
+		sb.WriteString("\n### Sensitive behaviors\n\n")
+		sb.WriteString("| Severity | Rule | Workload | Behavior | ATT&CK |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for i, finding := range findings {
+			if i == opts.MaxRows {
+				break
+			}
+			sb.WriteString(findingRow(finding))
+		}
+		writeMore(&sb, len(findings)-opts.MaxRows)
+	}
+
+	var tail strings.Builder
+	if len(opts.Artifacts) > 0 {
+		tail.WriteString("\n### Artifacts\n\n")
+		for _, a := range opts.Artifacts {
+			tail.WriteString(fmt.Sprintf("- [%s](%s)\n", a.Name, a.URL))
+		}
+	}
+	tail.WriteString(fmt.Sprintf("\n<sub>ATT&CK techniques from %s</sub>\n", report.Attack))
+	return truncateReport(sb.String(), tail.String(), len(opts.Artifacts) > 0, opts.MaxLength)
+}
+
+// truncateReport returns the body and the tail of a report, the body cut at a line so that the
+// report has at most maxLength characters. The code blocks and the details left open by the cut
+// are closed, and a note points at the artifacts, or at the report file if there are none.
+func truncateReport(body, tail string, artifacts bool, maxLength int) string {
+	if utf8.RuneCountInString(body)+utf8.RuneCountInString(tail) <= maxLength {
+		return body + tail
+	}
+	note := "\n> :scissors: The report is truncated to fit in a comment, see the full report in the workflow artifacts.\n"
+	if artifacts {
+		note = "\n> :scissors: The report is truncated to fit in a comment, see the full report and the images in the artifacts below.\n"
+	}
+	// room for the note and the closing of the code blocks and the details
+	keep := maxLength - utf8.RuneCountInString(tail) - utf8.RuneCountInString(note) - 64
+	lines := strings.SplitAfter(body, "\n")
+	var sb strings.Builder
+	fenced, details, length := false, 0, 0
+	for _, line := range lines {
+		length += utf8.RuneCountInString(line)
+		if length > keep {
+			break
+		}
+		sb.WriteString(line)
+		switch {
+		case strings.HasPrefix(line, "```"):
+			fenced = !fenced
+		case strings.HasPrefix(line, "<details>"):
+			details++
+		case strings.HasPrefix(line, "</details>") && details > 0:
+			details--
+		}
+	}
+	if fenced {
+		sb.WriteString("```\n")
+	}
+	for ; details > 0; details-- {
+		sb.WriteString("\n</details>\n")
+	}
+	sb.WriteString(note)
+	return sb.String() + tail
+}
+
+// writeRiskHighlights writes the new findings and the risky added behaviors without finding
+func writeRiskHighlights(sb *strings.Builder, report *DiffReport, maxRows int) {
+	var findings []Finding
+	for _, finding := range report.Findings() {
+		if finding.New {
+			findings = append(findings, finding)
+		}
+	}
+	var risky []RiskyChange
+	for _, wd := range report.Workloads {
+		flagged := make(map[behaviorKey]bool)
+		for _, finding := range wd.Findings {
+			flagged[finding.Behavior.key()] = true
+		}
+		for _, b := range wd.Added {
+			if b.Risk >= 40 && !flagged[b.key()] {
+				risky = append(risky, RiskyChange{Workload: wd.Workload, Change: "added", Behavior: b})
+			}
+		}
+	}
+	if len(findings) == 0 && len(risky) == 0 {
+		return
+	}
+	sort.SliceStable(risky, func(i, j int) bool { return risky[i].Behavior.Risk > risky[j].Behavior.Risk })
+
+	sb.WriteString("\n### Risk highlights\n\n")
+	sb.WriteString("| Severity | Rule | Workload | Behavior | ATT&CK |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	rows := 0
+	for _, finding := range findings {
+		if rows == maxRows {
+			break
+		}
+		rows++
+		sb.WriteString(findingRow(finding))
+	}
+	for _, ch := range risky {
+		if rows == maxRows {
+			break
+		}
+		rows++
+		sb.WriteString(fmt.Sprintf("| risk %d | new %s behavior | %s | %s | |\n", ch.Behavior.Risk, ch.Behavior.Kind,
+			mdCell(ch.Workload.String()), mdCode(ch.Behavior.String())))
+	}
+	writeMore(sb, len(findings)+len(risky)-rows)
+}
+
+// findingRow returns the row of a finding in the risk highlights table
+func findingRow(finding Finding) string {
+	var techniques []string
+	for _, id := range finding.Techniques {
+		if t, ok := BuiltinAttackCatalog().Technique(id); ok {
+			techniques = append(techniques, fmt.Sprintf("[%s](%s)", t.ID, t.URL))
+		} else {
+			techniques = append(techniques, id)
+		}
+	}
+	return fmt.Sprintf("| %s %s | %s %s | %s | %s | %s |\n", severityIcon(finding.Severity), finding.Severity, finding.RuleID, mdCell(finding.Title),
+		mdCell(finding.Workload.String()), mdCode(finding.Behavior.String()), strings.Join(techniques, " "))
+}
+
+// writeWorkloads writes the table of the changes per workload and their details
+func writeWorkloads(sb *strings.Builder, report *DiffReport, maxRows int) {
+	var changed []*WorkloadDiff
+	for _, wd := range report.Workloads {
+		if len(wd.Added) > 0 || len(wd.Removed) > 0 || len(wd.Changed) > 0 || len(wd.Volume) > 0 {
+			changed = append(changed, wd)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	sb.WriteString("\n### Workloads\n\n")
+	sb.WriteString("| Workload | Added | Removed | Changed | Volume | New findings | Max risk |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, wd := range changed {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %d | %d |\n", mdCell(wd.Workload.String()), len(wd.Added), len(wd.Removed), len(wd.Changed),
+			len(wd.Volume), len(newFindings(wd)), maxRisk(wd)))
+	}
+	for _, wd := range changed {
+		sb.WriteString(fmt.Sprintf("\n<details><summary><b>%s</b>: +%d -%d ~%d</summary>\n", htmlEscape(wd.Workload.String()), len(wd.Added), len(wd.Removed),
+			len(wd.Changed)+len(wd.Volume)))
+		writeBehaviors(sb, "Added", wd.Added, maxRows)
+		writeBehaviors(sb, "Removed", wd.Removed, maxRows)
+		if len(wd.Changed) > 0 {
+			sb.WriteString("\n#### Status changed\n\n")
+			sb.WriteString("| Kind | Behavior | Status | Risk |\n")
+			sb.WriteString("| --- | --- | --- | --- |\n")
+			for i, ch := range wd.Changed {
+				if i == maxRows {
+					break
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s → %s | %d |\n", ch.Kind, mdCode(ch.Behavior.String()), ch.OldStatus, ch.Status, ch.Risk))
+			}
+			writeMore(sb, len(wd.Changed)-maxRows)
+		}
+		if len(wd.Volume) > 0 {
+			sb.WriteString("\n#### Volume changed\n\n")
+			sb.WriteString("| Kind | Behavior | Count | Change | Risk |\n")
+			sb.WriteString("| --- | --- | --- | --- | --- |\n")
+			for i, v := range wd.Volume {
+				if i == maxRows {
+					break
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s → %s | %s %s %s | %d |\n", v.Kind, mdCode(v.Behavior.String()), v.OldCount, v.Count,
+					volumeIcons[v.Volume], v.Volume, v.Factor(), v.Risk))
+			}
+			writeMore(sb, len(wd.Volume)-maxRows)
+		}
+		sb.WriteString("\n</details>\n")
+	}
+}
+
+// writeBehaviors writes a table of added or removed behaviors
+func writeBehaviors(sb *strings.Builder, title string, bs []Behavior, maxRows int) {
+	if len(bs) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n#### %s\n\n", title))
+	sb.WriteString("| Kind | Behavior | Status | Count | Risk |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	for i, b := range bs {
+		if i == maxRows {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d |\n", b.Kind, mdCode(b.String()), b.Status, b.Count, b.Risk))
+	}
+	writeMore(sb, len(bs)-maxRows)
+}
+
+// writeMore writes the number of rows left out, if any
+func writeMore(sb *strings.Builder, more int) {
+	if more > 0 {
+		sb.WriteString(fmt.Sprintf("\n_... and %d more, see the diff report_\n", more))
+	}
+}
+
+// ConvertVndToMermaid converts the network diff to a Mermaid flowchart, with a subgraph per namespace.
+// At most maxEdges connections are drawn, the unchanged ones without volume change are left out first.
+func ConvertVndToMermaid(vnd *VisualNetworkData, maxEdges int) string {
+	edges := vnd.Edges
+	omitted := 0
+	if maxEdges > 0 && len(edges) > maxEdges {
+		var changes []NetworkEdge
+		for _, e := range edges {
+			if e.Change != "" || e.Volume != "" {
+				changes = append(changes, e)
+			}
+		}
+		if len(changes) == 0 {
+			// a snapshot has no changes, the first connections are shown
+			changes = edges
+		}
+		if len(changes) > maxEdges {
+			changes = changes[:maxEdges]
+		}
+		omitted = len(edges) - len(changes)
+		edges = changes
+	}
+
+	// nodes get short ids, in order of appearance
+	ids := make(map[string]string)
+	var nodes []string
+	for _, e := range edges {
+		for _, node := range []string{e.Source, e.Destination} {
+			if _, ok := ids[node]; !ok {
+				ids[node] = fmt.Sprintf("n%d", len(ids))
+				nodes = append(nodes, node)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	sb.WriteString("  classDef selected fill:#FFA500,stroke:#333,color:#000\n")
+	sb.WriteString("  classDef finding fill:#FF0000,stroke:#333,color:#fff\n")
+
+	declared := make(map[string]bool)
+	namespaces := make([]string, 0, len(vnd.NsIps))
+	for ns := range vnd.NsIps {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for i, ns := range namespaces {
+		var members []string
+		for _, ip := range dedupeSorted(append([]string(nil), vnd.NsIps[ns]...)) {
+			if _, ok := ids[ip]; ok && !declared[ip] {
+				members = append(members, ip)
+				declared[ip] = true
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  subgraph ns%d[\"namespace: %s\"]\n", i, mermaidText(ns)))
+		for _, ip := range members {
+			sb.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", ids[ip], mermaidText(ip)))
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, node := range nodes {
+		if !declared[node] {
+			sb.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[node], mermaidText(node)))
+		}
+	}
+
+	for _, e := range edges {
+		label := e.Protocol + "/" + e.Port
+		switch e.Change {
+		case "added":
+			sb.WriteString(fmt.Sprintf("  %s -->|\"+%s\"| %s\n", ids[e.Source], mermaidText(label), ids[e.Destination]))
+		case "removed":
+			sb.WriteString(fmt.Sprintf("  %s -.->|\"-%s\"| %s\n", ids[e.Source], mermaidText(label), ids[e.Destination]))
+		default:
+			if e.Volume != "" {
+				label += volumeLabel(e.Volume, e.OldCount, e.Count)
+			} else if e.Count > 0 {
+				label += fmt.Sprintf(" (%d)", e.Count)
+			}
+			sb.WriteString(fmt.Sprintf("  %s -->|\"%s\"| %s\n", ids[e.Source], mermaidText(label), ids[e.Destination]))
+		}
+	}
+	for i, e := range edges {
+		color := getEdgeColor(e.Protocol)
+		if e.Change != "" {
+			color = "red"
+		}
+		if e.Volume != "" {
+			sb.WriteString(fmt.Sprintf("  linkStyle %d stroke:%s,stroke-width:3px\n", i, volumeColors[e.Volume]))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  linkStyle %d stroke:%s\n", i, color))
+	}
+
+	for _, node := range nodes {
+		if vnd.Findings[node] {
+			sb.WriteString(fmt.Sprintf("  class %s finding\n", ids[node]))
+		} else if vnd.Highlights[node] {
+			sb.WriteString(fmt.Sprintf("  class %s selected\n", ids[node]))
+		}
+	}
+	if omitted > 0 {
+		sb.WriteString(fmt.Sprintf("  omitted[\"%d more connections not shown\"]\n", omitted))
+	}
+	return sb.String()
+}
+
+// ReportJSONFiles parses two summaries with p, compares them and renders the report in the format,
+// or a first run report if the old summary is a URL that is not found, eg.: not published yet
+func ReportJSONFiles(jsonFileOld, jsonFileNew, format string, f *filter.Filter, p ParseOptions, opts ReportOptions) (string, error) {
+	if format != FormatMarkdown {
+		return "", fmt.Errorf("invalid report format %q, must be %s", format, FormatMarkdown)
+	}
+	klog.Infoln("Parsing Old Summary Data...")
+	sdOlds, err := p.Older().ParseSummaryData(jsonFileOld)
+	var httpErr *HTTPError
+	firstRun := errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+	if err != nil && !firstRun {
+		return "", err
+	}
+	klog.Infoln("Parsing New Summary Data...")
+	sdNews, err := p.ParseSummaryData(jsonFileNew)
+	if err != nil {
+		return "", err
+	}
+	logSubstitutions(p.Normalizer)
+	if firstRun {
+		// the baseline of the first run is not published yet
+		klog.Infof("%v, rendering a first run report", httpErr)
+		return FirstRunReport(Diff(nil, sdNews, f, BuiltinClassifier()), fmt.Sprintf("%s was not found", httpErr.URL), opts), nil
+	}
+	report := opts.Diff.Diff(sdOlds, sdNews, f, BuiltinClassifier())
+	vnd := opts.Diff.ParseNetworkData(sdOlds, sdNews, f)
+	return MarkdownReport(report, vnd, opts), nil
+}
+
+// newFindings returns the findings of the added behaviors of the workload
+func newFindings(wd *WorkloadDiff) []Finding {
+	var findings []Finding
+	for _, finding := range wd.Findings {
+		if finding.New {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// maxRisk returns the highest risk of the changes of the workload
+func maxRisk(wd *WorkloadDiff) int {
+	risk := 0
+	for _, b := range wd.Added {
+		if b.Risk > risk {
+			risk = b.Risk
+		}
+	}
+	for _, b := range wd.Removed {
+		if b.Risk > risk {
+			risk = b.Risk
+		}
+	}
+	for _, ch := range wd.Changed {
+		if ch.Risk > risk {
+			risk = ch.Risk
+		}
+	}
+	for _, v := range wd.Volume {
+		if v.Risk > risk {
+			risk = v.Risk
+		}
+	}
+	return risk
+}
+
+// gradeIcon returns the icon of a risk grade
+func gradeIcon(grade string) string {
+	switch grade {
+	case "A", "B":
+		return "🟢"
+	case "C":
+		return "🟡"
+	case "D":
+		return "🟠"
+	default:
+		return "🔴"
+	}
+}
+
+// severityIcon returns the icon of a finding severity
+func severityIcon(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return "🔴"
+	case SeverityHigh:
+		return "🟠"
+	case SeverityMedium:
+		return "🟡"
+	default:
+		return "⚪"
+	}
+}
+
+// isImage reports whether the URL is an image GitHub renders inline
+func isImage(url string) bool {
+	switch strings.ToLower(path.Ext(url)) {
+	case ".png", ".svg", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// mdCell escapes a value for a Markdown table cell
+func mdCell(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\n", " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// mdCode formats a value as inline code in a Markdown table cell
+func mdCode(s string) string {
+	return "`" + strings.ReplaceAll(mdCell(s), "`", "'") + "`"
+}
+
+// htmlEscape escapes a value for the HTML of the collapsible sections
+func htmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// mermaidText escapes a value for a quoted Mermaid label
+func mermaidText(s string) string {
+	return strings.NewReplacer("\"", "#quot;", "<", "#lt;", ">", "#gt;").Replace(strings.TrimSpace(s))
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateReport(t *testing.T) {
+	rows := strings.Repeat("| a | b |\n", 200)
+	tests := []struct {
+		name      string
+		body      string
+		maxLength int
+		wantCut   bool
+	}{
+		{name: "fits", body: "## Report\n\n" + rows, maxLength: 10000},
+		{name: "table", body: "## Report\n\n" + rows, maxLength: 1000, wantCut: true},
+		{name: "code block", body: "## Report\n\n```mermaid\n" + rows + "```\n", maxLength: 1000, wantCut: true},
+		{name: "details", body: "## Report\n\n<details><summary>w</summary>\n\n" + rows + "\n</details>\n", maxLength: 1000, wantCut: true},
+		{name: "wide characters", body: "## Report\n\n" + strings.Repeat("| ▲ | █ |\n", 200), maxLength: 1000, wantCut: true},
+	}
+	tail := "\n### Artifacts\n\n- [summary](https://example.com/summary.json)\n"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateReport(tt.body, tail, true, tt.maxLength)
+			if n := utf8.RuneCountInString(got); n > tt.maxLength {
+				t.Errorf("length = %d, want at most %d", n, tt.maxLength)
+			}
+			if !strings.HasSuffix(got, tail) {
+				t.Errorf("the artifacts are cut:\n%s", got)
+			}
+			if cut := strings.Contains(got, "truncated"); cut != tt.wantCut {
+				t.Errorf("truncated = %v, want %v", cut, tt.wantCut)
+			}
+			if strings.Count(got, "```")%2 != 0 {
+				t.Errorf("a code block is left open:\n%s", got)
+			}
+			if strings.Count(got, "<details>") != strings.Count(got, "</details>") {
+				t.Errorf("a details block is left open:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Grades of the overall risk of a change, from the safest to the riskiest
+var riskGrades = []string{"A", "B", "C", "D", "F"}
+
+// riskGradeScores are the lowest scores of the grades B, C, D and F
+var riskGradeScores = []int{20, 40, 60, 80}
+
+// categoryScores are the base scores of the behavior categories
+var categoryScores = map[string]int{
+	"egress-external":         40,
+	"egress-cross-namespace":  25,
+	"egress-namespace":        10,
+	"ingress-external":        30,
+	"ingress-cross-namespace": 20,
+	"ingress-namespace":       5,
+	"bind":                    20,
+	"process":                 15,
+	"file":                    5,
+}
+
+// severityScores are added to the base score for the most severe finding of a behavior
+var severityScores = map[Severity]int{
+	SeverityLow:      10,
+	SeverityMedium:   20,
+	SeverityHigh:     35,
+	SeverityCritical: 50,
+}
+
+// privilegedNamespaces are the namespaces whose workloads can affect the whole cluster
+var privilegedNamespaces = map[string]bool{
+	"kube-system": true,
+	"kubearmor":   true,
+}
+
+// RiskSummary is the overall risk of the changes of a diff report
+type RiskSummary struct {
+	// Score is the highest risk of a change, plus one point per other risky change (40 or more), capped at 100
+	Score int `json:"Score"`
+	// Grade is A for the safest changes to F for the riskiest, see GradeOf
+	Grade string `json:"Grade"`
+	// Risky is the number of changes scored 40 or more
+	Risky int `json:"Risky"`
+	// Top is the riskiest change
+	Top *RiskyChange `json:"Top,omitempty"`
+}
+
+// RiskyChange is a scored change of a workload
+type RiskyChange struct {
+	Workload DiffWorkload `json:"Workload"`
+	// Change is added, removed, changed, or spike or drop for a volume change
+	Change   string   `json:"Change"`
+	Behavior Behavior `json:"Behavior"`
+}
+
+// String returns the summary as "grade C (score 55)"
+func (s RiskSummary) String() string {
+	return fmt.Sprintf("grade %s (score %d)", s.Grade, s.Score)
+}
+
+// GradeOf returns the grade of a risk score: A below 20, B below 40, C below 60, D below 80, F otherwise
+func GradeOf(score int) string {
+	for i, min := range riskGradeScores {
+		if score < min {
+			return riskGrades[i]
+		}
+	}
+	return riskGrades[len(riskGrades)-1]
+}
+
+// ParseGrade checks that the grade is one of A, B, C, D or F, case insensitive
+func ParseGrade(grade string) (string, error) {
+	g := strings.ToUpper(strings.TrimSpace(grade))
+	for _, valid := range riskGrades {
+		if g == valid {
+			return g, nil
+		}
+	}
+	return "", fmt.Errorf("invalid risk grade %q: must be A, B, C, D or F", grade)
+}
+
+// GradeWorse reports whether grade a is worse than grade b
+func GradeWorse(a, b string) bool {
+	return gradeRank(a) > gradeRank(b)
+}
+
+// gradeRank returns the position of the grade, from 0 for A
+func gradeRank(grade string) int {
+	for i, g := range riskGrades {
+		if g == grade {
+			return i
+		}
+	}
+	return 0
+}
+
+// ScoreBehavior scores an added behavior of a workload from 0 to 100 and returns the factors of the score:
+//   - the category: egress to external peers ranks above cross-namespace and intra-namespace traffic,
+//     then new listening ports, processes and files
+//   - the sensitivity: the severity of the most severe finding on the behavior
+//   - the blast radius: the number of workloads the behavior appears in, and privileged namespaces
+//
+// Blocked behaviors score half, the policy already stops them.
+func ScoreBehavior(b Behavior, w DiffWorkload, findings []Finding, spread int) (int, []string) {
+	category := behaviorCategory(b, w)
+	score := categoryScores[category]
+	factors := []string{fmt.Sprintf("%s +%d", category, score)}
+
+	var worst *Finding
+	for i := range findings {
+		if worst == nil || findings[i].Severity.Rank() > worst.Severity.Rank() {
+			worst = &findings[i]
+		}
+	}
+	if worst != nil {
+		s := severityScores[worst.Severity]
+		score += s
+		factors = append(factors, fmt.Sprintf("%s %s +%d", worst.Severity, worst.RuleID, s))
+	}
+
+	if spread > 1 {
+		s := 5 * (spread - 1)
+		if s > 20 {
+			s = 20
+		}
+		score += s
+		factors = append(factors, fmt.Sprintf("in %d workloads +%d", spread, s))
+	}
+	if privilegedNamespaces[w.Namespace] {
+		score += 15
+		factors = append(factors, fmt.Sprintf("privileged namespace %s +15", w.Namespace))
+	}
+
+	if b.Status == StatusBlock {
+		score /= 2
+		factors = append(factors, "blocked /2")
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, factors
+}
+
+// behaviorCategory returns the risk category of a behavior
+func behaviorCategory(b Behavior, w DiffWorkload) string {
+	switch b.Kind {
+	case KindEgress, KindIngress:
+		switch {
+		case isExternalPeer(b.Destination):
+			return b.Kind + "-external"
+		case b.Namespace != "" && b.Namespace != w.Namespace:
+			return b.Kind + "-cross-namespace"
+		default:
+			return b.Kind + "-namespace"
+		}
+	default:
+		return b.Kind
+	}
+}
+
+// isExternalPeer reports whether a connection peer is outside the cluster: a public IP or a host name,
+// pods and services (pod/..., svc/...) and private IPs are in the cluster
+func isExternalPeer(peer string) bool {
+	peer = strings.TrimSpace(peer)
+	if strings.HasPrefix(peer, "pod/") || strings.HasPrefix(peer, "svc/") || peer == "" {
+		return false
+	}
+	ip := net.ParseIP(peer)
+	if ip == nil {
+		return true
+	}
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// score scores the changes of the report and sets its overall risk, removed behaviors and
+// status changes to a less restrictive status score a third of an added behavior, volume spikes
+// score as much as an added behavior and volume drops a third
+func (r *DiffReport) score(c *Classifier) {
+	spread := make(map[behaviorKey]int)
+	for _, wd := range r.Workloads {
+		for _, b := range wd.Added {
+			spread[b.key()]++
+		}
+	}
+	var changes []RiskyChange
+	for _, wd := range r.Workloads {
+		for i := range wd.Added {
+			b := &wd.Added[i]
+			b.Risk, b.RiskFactors = ScoreBehavior(*b, wd.Workload, c.Classify(*b), spread[b.key()])
+			changes = append(changes, RiskyChange{Workload: wd.Workload, Change: "added", Behavior: *b})
+		}
+		for i := range wd.Removed {
+			b := &wd.Removed[i]
+			b.Risk, b.RiskFactors = ScoreBehavior(*b, wd.Workload, c.Classify(*b), 1)
+			b.Risk /= 3
+			b.RiskFactors = append(b.RiskFactors, "removed /3")
+			changes = append(changes, RiskyChange{Workload: wd.Workload, Change: "removed", Behavior: *b})
+		}
+		for i := range wd.Changed {
+			ch := &wd.Changed[i]
+			ch.Risk, ch.RiskFactors = ScoreBehavior(ch.Behavior, wd.Workload, c.Classify(ch.Behavior), 1)
+			if statusRanks[ch.Status] > statusRanks[ch.OldStatus] {
+				ch.Risk /= 3
+				ch.RiskFactors = append(ch.RiskFactors, "more restrictive /3")
+			}
+			changes = append(changes, RiskyChange{Workload: wd.Workload, Change: "changed", Behavior: ch.Behavior})
+		}
+		for i := range wd.Volume {
+			v := &wd.Volume[i]
+			v.Risk, v.RiskFactors = ScoreBehavior(v.Behavior, wd.Workload, c.Classify(v.Behavior), 1)
+			if v.Volume == VolumeDrop {
+				v.Risk /= 3
+				v.RiskFactors = append(v.RiskFactors, "volume drop /3")
+			} else {
+				v.RiskFactors = append(v.RiskFactors, "volume spike "+v.Factor())
+			}
+			changes = append(changes, RiskyChange{Workload: wd.Workload, Change: v.Volume, Behavior: v.Behavior})
+		}
+	}
+
+	summary := RiskSummary{}
+	for i := range changes {
+		ch := &changes[i]
+		if ch.Behavior.Risk >= 40 {
+			summary.Risky++
+		}
+		if summary.Top == nil || ch.Behavior.Risk > summary.Top.Behavior.Risk {
+			summary.Top = ch
+		}
+	}
+	if summary.Top != nil {
+		summary.Score = summary.Top.Behavior.Risk
+		if summary.Risky > 1 {
+			summary.Score += summary.Risky - 1
+		}
+	}
+	if summary.Score > 100 {
+		summary.Score = 100
+	}
+	summary.Grade = GradeOf(summary.Score)
+	r.Risk = summary
+}
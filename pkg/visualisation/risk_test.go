@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreBehavior(t *testing.T) {
+	web := DiffWorkload{Namespace: "default", Deployment: "web"}
+	tests := []struct {
+		name     string
+		b        Behavior
+		w        DiffWorkload
+		findings []Finding
+		spread   int
+		want     int
+		factors  string
+	}{
+		{
+			name:    "process",
+			b:       Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/bin/ls"},
+			w:       web,
+			spread:  1,
+			want:    15,
+			factors: "process +15",
+		},
+		{
+			name:     "most severe finding and spread",
+			b:        Behavior{Kind: KindProcess, Source: "/usr/sbin/apache2", Destination: "/bin/sh"},
+			w:        web,
+			findings: []Finding{{RuleID: "KA-PROC-004", Severity: SeverityLow}, {RuleID: "KA-PROC-001", Severity: SeverityHigh}},
+			spread:   3,
+			want:     60,
+			factors:  "process +15; high KA-PROC-001 +35; in 3 workloads +10",
+		},
+		{
+			name:     "capped",
+			b:        Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: "169.254.169.254"},
+			w:        DiffWorkload{Namespace: "kube-system", Deployment: "coredns"},
+			findings: []Finding{{RuleID: "KA-NET-001", Severity: SeverityCritical}},
+			spread:   10,
+			want:     100,
+			factors:  "egress-external +40; critical KA-NET-001 +50; in 10 workloads +20; privileged namespace kube-system +15",
+		},
+		{
+			name:     "blocked",
+			b:        Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/proc/1/environ", Status: StatusBlock},
+			w:        web,
+			findings: []Finding{{RuleID: "KA-FILE-003", Severity: SeverityMedium}},
+			spread:   1,
+			want:     12,
+			factors:  "file +5; medium KA-FILE-003 +20; blocked /2",
+		},
+		{
+			name:    "external egress",
+			b:       Behavior{Kind: KindEgress, Source: "/usr/bin/curl", Destination: "example.com", Protocol: "TCP", Port: "443"},
+			w:       web,
+			spread:  0,
+			want:    40,
+			factors: "egress-external +40",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, factors := ScoreBehavior(tt.b, tt.w, tt.findings, tt.spread)
+			if got != tt.want {
+				t.Errorf("ScoreBehavior() = %d, want %d", got, tt.want)
+			}
+			if f := strings.Join(factors, "; "); f != tt.factors {
+				t.Errorf("ScoreBehavior() factors = %q, want %q", f, tt.factors)
+			}
+		})
+	}
+}
+
+func TestBehaviorCategory(t *testing.T) {
+	web := DiffWorkload{Namespace: "default", Deployment: "web"}
+	tests := []struct {
+		b    Behavior
+		want string
+	}{
+		{b: Behavior{Kind: KindEgress, Destination: "8.8.8.8"}, want: "egress-external"},
+		{b: Behavior{Kind: KindEgress, Destination: "2001:4860:4860::8888"}, want: "egress-external"},
+		{b: Behavior{Kind: KindEgress, Destination: "api.example.com"}, want: "egress-external"},
+		{b: Behavior{Kind: KindEgress, Destination: "10.0.0.1"}, want: "egress-namespace"},
+		{b: Behavior{Kind: KindEgress, Destination: "fd00::1"}, want: "egress-namespace"},
+		{b: Behavior{Kind: KindEgress, Destination: ""}, want: "egress-namespace"},
+		{b: Behavior{Kind: KindEgress, Destination: "svc/mysql", Namespace: "default"}, want: "egress-namespace"},
+		{b: Behavior{Kind: KindEgress, Destination: "svc/mysql", Namespace: "db"}, want: "egress-cross-namespace"},
+		{b: Behavior{Kind: KindIngress, Destination: " 1.1.1.1 "}, want: "ingress-external"},
+		{b: Behavior{Kind: KindIngress, Destination: "pod/frontend", Namespace: "ingress-nginx"}, want: "ingress-cross-namespace"},
+		{b: Behavior{Kind: KindIngress, Destination: "127.0.0.1"}, want: "ingress-namespace"},
+		{b: Behavior{Kind: KindBind, Destination: "0.0.0.0", Port: "8080"}, want: "bind"},
+		{b: Behavior{Kind: KindProcess, Destination: "/bin/sh"}, want: "process"},
+		{b: Behavior{Kind: KindFile, Destination: "/etc/passwd"}, want: "file"},
+	}
+	for _, tt := range tests {
+		if got := behaviorCategory(tt.b, web); got != tt.want {
+			t.Errorf("behaviorCategory(%s %s) = %q, want %q", tt.b.Kind, tt.b, got, tt.want)
+		}
+		if _, ok := categoryScores[tt.want]; !ok {
+			t.Errorf("category %q has no score", tt.want)
+		}
+	}
+}
+
+func TestGrades(t *testing.T) {
+	for score, want := range map[int]string{0: "A", 19: "A", 20: "B", 39: "B", 40: "C", 59: "C", 60: "D", 79: "D", 80: "F", 100: "F"} {
+		if got := GradeOf(score); got != want {
+			t.Errorf("GradeOf(%d) = %q, want %q", score, got, want)
+		}
+	}
+
+	parse := []struct {
+		grade   string
+		want    string
+		wantErr bool
+	}{
+		{grade: "A", want: "A"},
+		{grade: " c ", want: "C"},
+		{grade: "E", wantErr: true},
+		{grade: "", wantErr: true},
+	}
+	for _, tt := range parse {
+		got, err := ParseGrade(tt.grade)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseGrade(%q) = %q, %v, want %q, wantErr %v", tt.grade, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	worse := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "C", b: "B", want: true},
+		{a: "F", b: "D", want: true},
+		{a: "B", b: "B", want: false},
+		{a: "A", b: "F", want: false},
+	}
+	for _, tt := range worse {
+		if got := GradeWorse(tt.a, tt.b); got != tt.want {
+			t.Errorf("GradeWorse(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
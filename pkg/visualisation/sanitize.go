@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SanitizeContainerName returns a clean container name.
+// Some karmor versions fill ContainerName with a protobuf encoded process record, eg.:
+// "\n\t/bin/bash\u0012\n/bin/sleep\u001a\u00012\"\u001cMon Jul  3 08:26:31 UTC 2023*\u0005Allow",
+// such values carry no container name and are dropped, see RecoverLeakedProcess to recover
+// the process. Other values are stripped of control characters.
+func SanitizeContainerName(name string) string {
+	if strings.IndexFunc(name, unicode.IsControl) < 0 {
+		return strings.TrimSpace(name)
+	}
+	if _, ok := DecodeLeakedRecord(name); ok {
+		return ""
+	}
+	return SanitizeString(name)
+}
+
+// RecoverLeakedProcess decodes a container name holding a leaked process record into the process
+// entry it describes: its fields are the Source, Destination, Count, UpdatedTime and Status.
+// It reports false if the name is not a leaked process record.
+func RecoverLeakedProcess(name string) (ProcessData, bool) {
+	fields, ok := DecodeLeakedRecord(name)
+	if !ok || fields[1] == "" || fields[2] == "" {
+		return ProcessData{}, false
+	}
+	ps := ProcessData{Source: fields[1], Destination: fields[2], Status: fields[5]}
+	// an invalid count or time is left unset, the execution is still worth reporting
+	ps.Count, _ = ParseCount(fields[3])
+	ps.UpdatedTime, _ = ParseTimestamp(fields[4])
+	return ps, true
+}
+
+// SanitizeString removes the control and invalid UTF-8 characters from s and trims the white space
+func SanitizeString(s string) string {
+	if s == "" {
+		return s
+	}
+	s = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// DecodeLeakedRecord decodes a string that holds a protobuf encoded message made of
+// string fields only, and returns the fields by field number.
+// It reports false if s is not such a message.
+func DecodeLeakedRecord(s string) (map[int]string, bool) {
+	b := []byte(s)
+	fields := make(map[int]string)
+	for len(b) > 0 {
+		key, n := decodeVarint(b)
+		if n == 0 {
+			return nil, false
+		}
+		b = b[n:]
+		// only length-delimited fields (wire type 2) are expected
+		if key&0x7 != 2 {
+			return nil, false
+		}
+		length, n := decodeVarint(b)
+		if n == 0 || uint64(len(b)-n) < length {
+			return nil, false
+		}
+		b = b[n:]
+		value := string(b[:length])
+		if !utf8.ValidString(value) {
+			return nil, false
+		}
+		fields[int(key>>3)] = value
+		b = b[length:]
+	}
+	if len(fields) == 0 {
+		return nil, false
+	}
+	return fields, true
+}
+
+// decodeVarint decodes a protobuf base 128 varint and returns it with the number of bytes read,
+// or 0 bytes if b does not start with a valid varint
+func decodeVarint(b []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		x |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i] < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// leakedRecord is the container name of the test summaries, a process record leaked by karmor
+const leakedRecord = "\n\t/bin/bash\u0012\n/bin/sleep\u001a\u00012\"\u001cMon Jul  3 08:26:31 UTC 2023*\u0005Allow"
+
+func TestSanitizeContainerName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "wordpress", want: "wordpress"},
+		{name: " mysql \n", want: "mysql"},
+		{name: leakedRecord, want: ""},
+		{name: "php\u0000-fpm", want: "php-fpm"},
+		{name: "\u0007", want: ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeContainerName(tt.name); got != tt.want {
+			t.Errorf("SanitizeContainerName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecoverLeakedProcess(t *testing.T) {
+	ps, ok := RecoverLeakedProcess(leakedRecord)
+	want := ProcessData{Source: "/bin/bash", Destination: "/bin/sleep", Count: 2, Status: "Allow",
+		UpdatedTime: Timestamp{Time: time.Date(2023, 7, 3, 8, 26, 31, 0, time.UTC)}}
+	if !ok || ps.Source != want.Source || ps.Destination != want.Destination || ps.Count != want.Count ||
+		ps.Status != want.Status || !ps.UpdatedTime.Equal(want.UpdatedTime.Time) {
+		t.Errorf("RecoverLeakedProcess() = %+v, %v, want %+v", ps, ok, want)
+	}
+
+	// a record without a destination is not a process
+	if _, ok := RecoverLeakedProcess("\n\t/bin/bash"); ok {
+		t.Errorf("RecoverLeakedProcess() of a record without destination = true, want false")
+	}
+	for _, name := range []string{"wordpress", "", "\n\u0009/bin"} {
+		if _, ok := RecoverLeakedProcess(name); ok {
+			t.Errorf("RecoverLeakedProcess(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestDecodeLeakedRecord(t *testing.T) {
+	fields, ok := DecodeLeakedRecord(leakedRecord)
+	if !ok || len(fields) != 5 || fields[1] != "/bin/bash" || fields[3] != "2" || fields[5] != "Allow" {
+		t.Errorf("DecodeLeakedRecord() = %q, %v", fields, ok)
+	}
+	// a varint field (wire type 0) is not a leaked record
+	if _, ok := DecodeLeakedRecord("\u0008\u0001"); ok {
+		t.Errorf("DecodeLeakedRecord() of a varint field = true, want false")
+	}
+}
+
+func TestParseLeakedContainerNames(t *testing.T) {
+	sds, err := ParseSummaryData(filepath.Join("..", "..", "test", "testdata", "new-summary-data.json"))
+	if err != nil {
+		t.Fatalf("ParseSummaryData() error = %v", err)
+	}
+	dropped := 0
+	for _, sd := range sds {
+		if !sd.droppedContainerName {
+			continue
+		}
+		dropped++
+		if sd.ContainerName != "" || !sd.recoveredProcess {
+			t.Errorf("%s: ContainerName = %q, recovered %v, want the process recovered", sd.PodName, sd.ContainerName, sd.recoveredProcess)
+		}
+		found := false
+		for _, ps := range sd.ProcessData {
+			found = found || ps.Source == "/bin/bash" && ps.Destination == "/bin/sleep" && ps.Count == 2 && ps.Status == "Allow"
+		}
+		if !found {
+			t.Errorf("%s: ProcessData = %+v, want /bin/bash -> /bin/sleep", sd.PodName, sd.ProcessData)
+		}
+	}
+	if dropped != 2 {
+		t.Errorf("dropped %d container names, want 2", dropped)
+	}
+}
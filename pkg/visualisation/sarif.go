@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/klog"
+)
+
+// SARIF schema and version
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// riskyBehaviorRule is the rule of the risky added behaviors that match no rule of the pack
+var riskyBehaviorRule = Rule{
+	ID:        "KA-RISK-001",
+	Title:     "New risky behavior",
+	Severity:  SeverityMedium,
+	Rationale: "The behavior was not observed before the change and its risk score is above the threshold, review whether the workload needs it.",
+}
+
+// SARIFLog is a SARIF 2.1.0 log, only the properties written by visual are modelled
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a run of the tool
+type SARIFRun struct {
+	Tool        SARIFTool              `json:"tool"`
+	Results     []SARIFResult          `json:"results"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Invocations []SARIFInvocation      `json:"invocations,omitempty"`
+}
+
+// SARIFInvocation tells whether the run succeeded, eg.: whether the risk gate passed
+type SARIFInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+// SARIFTool is the tool and the rules it reports
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver describes the tool
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is a reporting descriptor
+type SARIFRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     SARIFMessage           `json:"shortDescription"`
+	FullDescription      SARIFMessage           `json:"fullDescription"`
+	Help                 *SARIFMessage          `json:"help,omitempty"`
+	DefaultConfiguration SARIFConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SARIFConfiguration is the default level of a rule
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFMessage is a text, with an optional Markdown rendering
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFResult is a finding or a risky behavior
+type SARIFResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             SARIFMessage           `json:"message"`
+	Locations           []SARIFLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SARIFLocation is the logical location of a behavior, and the manifest of its workload if known
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// SARIFPhysicalLocation is a location in a file
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is a file, relative to the source root
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// SARIFRegion is a region of a file
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// SARIFLogicalLocation is a namespace/workload/process location
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// SARIFOptions configures the SARIF conversion of a diff report
+type SARIFOptions struct {
+	// MinRisk is the lowest risk score of the added behaviors without findings that are reported, 0 reports none
+	MinRisk int
+	// Manifests locates the manifests declaring the workloads, the results are located in them
+	Manifests *ManifestIndex
+	// Fallback is the file the results of workloads without manifest are located in, eg.: the summary
+	// or the workflow file. GitHub code scanning rejects the results without location, they are
+	// dropped if there is no fallback.
+	Fallback string
+	// MaxGrade is the risk gate, if set the run is successful only if the risk grade is not worse
+	MaxGrade string
+}
+
+// severityLevels are the SARIF levels of the severities
+var severityLevels = map[Severity]string{
+	SeverityLow:      "note",
+	SeverityMedium:   "warning",
+	SeverityHigh:     "error",
+	SeverityCritical: "error",
+}
+
+// securitySeverities are the scores GitHub code scanning ranks the severities with
+var securitySeverities = map[Severity]string{
+	SeverityLow:      "3.0",
+	SeverityMedium:   "5.5",
+	SeverityHigh:     "8.0",
+	SeverityCritical: "9.5",
+}
+
+// SARIF converts the report to a SARIF log: every new finding, and every added behavior
+// without finding whose risk reaches opts.MinRisk, is a result. The results that cannot be
+// located in a manifest or in opts.Fallback are dropped with a warning.
+func (r *DiffReport) SARIF(opts SARIFOptions) *SARIFLog {
+	rules := make(map[string]Rule)
+	for _, rule := range BuiltinRules {
+		rules[rule.ID] = rule
+	}
+	used := make(map[string]bool)
+	results := []SARIFResult{}
+	dropped := 0
+	add := func(result SARIFResult) {
+		if result.Locations[0].PhysicalLocation == nil {
+			dropped++
+			return
+		}
+		used[result.RuleID] = true
+		results = append(results, result)
+	}
+
+	for _, wd := range r.Workloads {
+		flagged := make(map[behaviorKey]bool)
+		for _, finding := range wd.Findings {
+			if !finding.New {
+				continue
+			}
+			flagged[finding.Behavior.key()] = true
+			if _, ok := rules[finding.RuleID]; !ok {
+				rules[finding.RuleID] = Rule{ID: finding.RuleID, Title: finding.Title, Severity: finding.Severity, Rationale: finding.Rationale}
+			}
+			risk := riskOf(wd.Added, finding.Behavior)
+			msg := fmt.Sprintf("%s in %s: %s", finding.Title, wd.Workload, finding.Behavior)
+			add(sarifResult(finding.RuleID, finding.Severity, msg, wd.Workload, finding.Behavior, risk, finding.Techniques, opts))
+		}
+		if opts.MinRisk <= 0 {
+			continue
+		}
+		for _, b := range wd.Added {
+			if flagged[b.key()] || b.Risk < opts.MinRisk {
+				continue
+			}
+			severity := SeverityMedium
+			if b.Risk >= 60 {
+				severity = SeverityHigh
+			}
+			msg := fmt.Sprintf("New %s behavior in %s: %s, risk %d (%s)", b.Kind, wd.Workload, b, b.Risk, strings.Join(b.RiskFactors, ", "))
+			add(sarifResult(riskyBehaviorRule.ID, severity, msg, wd.Workload, b, b.Risk, BuiltinAttackCatalog().Map(b), opts))
+		}
+	}
+	if dropped > 0 {
+		klog.Warningf("Dropped %d SARIF results of workloads without manifest, GitHub code scanning rejects the results without location: set the manifests or a fallback file", dropped)
+	}
+	rules[riskyBehaviorRule.ID] = riskyBehaviorRule
+
+	ids := make([]string, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	driver := SARIFDriver{
+		Name:           "kubearmor-action",
+		InformationURI: "https://github.com/kubearmor/kubearmor-action",
+		Rules:          []SARIFRule{},
+	}
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule(rules[id]))
+	}
+
+	run := SARIFRun{
+		Tool:    SARIFTool{Driver: driver},
+		Results: results,
+		Properties: map[string]interface{}{
+			"riskScore": r.Risk.Score,
+			"riskGrade": r.Risk.Grade,
+			"attack":    r.Attack,
+		},
+	}
+	if opts.MaxGrade != "" {
+		run.Properties["maxGrade"] = opts.MaxGrade
+		run.Invocations = []SARIFInvocation{{ExecutionSuccessful: !GradeWorse(r.Risk.Grade, opts.MaxGrade)}}
+	}
+	return &SARIFLog{Schema: SARIFSchema, Version: SARIFVersion, Runs: []SARIFRun{run}}
+}
+
+// WriteSARIF writes the SARIF log as indented JSON
+func WriteSARIF(w io.Writer, log *SARIFLog) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// sarifRule converts a rule to a SARIF reporting descriptor
+func sarifRule(rule Rule) SARIFRule {
+	tags := []string{"security", "kubearmor"}
+	var help []string
+	for _, m := range BuiltinAttackCatalog().Mappings {
+		for _, id := range m.Rules {
+			if id != rule.ID {
+				continue
+			}
+			for _, tid := range m.Techniques {
+				tags = append(tags, "external/mitre-attack/"+tid)
+				if t, ok := BuiltinAttackCatalog().Technique(tid); ok {
+					help = append(help, fmt.Sprintf("- [%s %s](%s)", t.ID, t.Name, t.URL))
+				}
+			}
+		}
+	}
+	sr := SARIFRule{
+		ID:                   rule.ID,
+		Name:                 ruleName(rule.Title),
+		ShortDescription:     SARIFMessage{Text: rule.Title},
+		FullDescription:      SARIFMessage{Text: rule.Rationale},
+		DefaultConfiguration: SARIFConfiguration{Level: severityLevels[rule.Severity]},
+		Properties: map[string]interface{}{
+			"tags":              tags,
+			"security-severity": securitySeverities[rule.Severity],
+		},
+	}
+	if len(help) > 0 {
+		text := rule.Rationale + "\n\nMITRE ATT&CK:\n" + strings.Join(help, "\n")
+		sr.Help = &SARIFMessage{Text: text, Markdown: text}
+	}
+	return sr
+}
+
+// sarifResult builds the result of a behavior of a workload
+func sarifResult(ruleID string, severity Severity, msg string, w DiffWorkload, b Behavior, risk int, techniques []string, opts SARIFOptions) SARIFResult {
+	process := strings.TrimSpace(b.Source)
+	if process == "" {
+		process = strings.TrimSpace(b.Destination)
+	}
+	loc := SARIFLocation{
+		LogicalLocations: []SARIFLogicalLocation{{
+			Name:               process,
+			FullyQualifiedName: w.String() + "/" + process,
+			Kind:               "process",
+		}},
+	}
+	if ml, ok := opts.Manifests.Locate(w); ok {
+		loc.PhysicalLocation = &SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: ml.Path, URIBaseID: "%SRCROOT%"},
+			Region:           &SARIFRegion{StartLine: ml.Line},
+		}
+	} else if opts.Fallback != "" {
+		loc.PhysicalLocation = &SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: RelativePath(opts.Fallback), URIBaseID: "%SRCROOT%"},
+		}
+	}
+
+	// the fingerprint identifies the alert across runs, so that dismissals stick
+	sum := sha256.Sum256([]byte(ruleID + "\x00" + w.String() + "\x00" + b.Kind + "\x00" + b.String()))
+	props := map[string]interface{}{
+		"workload":          w.String(),
+		"kind":              b.Kind,
+		"security-severity": securitySeverities[severity],
+	}
+	if risk > 0 {
+		props["risk"] = risk
+	}
+	if len(techniques) > 0 {
+		props["techniques"] = techniques
+	}
+	return SARIFResult{
+		RuleID:              ruleID,
+		Level:               severityLevels[severity],
+		Message:             SARIFMessage{Text: msg},
+		Locations:           []SARIFLocation{loc},
+		PartialFingerprints: map[string]string{"kubearmorBehavior/v1": hex.EncodeToString(sum[:16])},
+		Properties:          props,
+	}
+}
+
+// ruleName returns the title as a PascalCase name, eg.: NewRiskyBehavior
+func ruleName(title string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(title) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// riskOf returns the risk of the added behavior, 0 if it is not added
+func riskOf(added []Behavior, b Behavior) int {
+	for _, a := range added {
+		if a.key() == b.key() {
+			return a.Risk
+		}
+	}
+	return 0
+}
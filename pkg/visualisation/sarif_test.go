@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sarifReport returns a report of two workloads: web with a new finding, an old finding and
+// risky added behaviors, and api with a new finding
+func sarifReport() *DiffReport {
+	web := DiffWorkload{Namespace: "default", Deployment: "web", Container: "web"}
+	api := DiffWorkload{Namespace: "other", Deployment: "api", Container: "api"}
+	curl := Behavior{Kind: KindProcess, Source: "/bin/sh", Destination: "/usr/bin/curl", Risk: 25}
+	egress := Behavior{Kind: KindEgress, Source: "/usr/bin/node", Destination: "8.8.8.8", Protocol: "TCP", Port: "53", Risk: 40,
+		RiskFactors: []string{"egress-external +40"}}
+	file := Behavior{Kind: KindFile, Source: "/usr/bin/node", Destination: "/app/index.js", Risk: 5}
+	shadow := Behavior{Kind: KindFile, Source: "/bin/cat", Destination: "/etc/shadow"}
+	token := Behavior{Kind: KindFile, Source: "/bin/sh", Destination: "/run/secrets/kubernetes.io/serviceaccount/token"}
+	return &DiffReport{
+		Risk:   RiskSummary{Score: 45, Grade: "C"},
+		Attack: "14.1",
+		Workloads: []*WorkloadDiff{
+			{
+				Workload: web,
+				Added:    []Behavior{curl, egress, file},
+				Findings: []Finding{
+					{RuleID: "KA-PROC-004", Title: "Network tool executed", Severity: SeverityLow, Behavior: curl, Techniques: []string{"T1105"}, New: true},
+					{RuleID: "KA-FILE-001", Title: "Read of the shadow password file", Severity: SeverityHigh, Behavior: shadow},
+				},
+			},
+			{
+				Workload: api,
+				Added:    []Behavior{token},
+				Findings: []Finding{
+					{RuleID: "KA-FILE-002", Title: "Read of the service account token", Severity: SeverityHigh, Behavior: token, New: true},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffReportSARIF(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "web.yaml")
+	err := os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: default\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := IndexManifests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    SARIFOptions
+		results []string
+		rules   string
+	}{
+		{
+			name: "no location",
+			opts: SARIFOptions{MinRisk: 40},
+		},
+		{
+			name: "fallback",
+			opts: SARIFOptions{Fallback: "summary.json"},
+			results: []string{
+				"KA-PROC-004 note summary.json:0 default/web/web//bin/sh",
+				"KA-FILE-002 error summary.json:0 other/api/api//bin/sh",
+			},
+			rules: "KA-FILE-002,KA-PROC-004",
+		},
+		{
+			name: "min risk",
+			opts: SARIFOptions{Fallback: "summary.json", MinRisk: 40},
+			results: []string{
+				"KA-PROC-004 note summary.json:0 default/web/web//bin/sh",
+				"KA-RISK-001 warning summary.json:0 default/web/web//usr/bin/node",
+				"KA-FILE-002 error summary.json:0 other/api/api//bin/sh",
+			},
+			rules: "KA-FILE-002,KA-PROC-004,KA-RISK-001",
+		},
+		{
+			name: "manifests",
+			opts: SARIFOptions{Manifests: manifests},
+			results: []string{
+				fmt.Sprintf("KA-PROC-004 note %s:4 default/web/web//bin/sh", filepath.ToSlash(manifest)),
+			},
+			rules: "KA-PROC-004",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := sarifReport().SARIF(tt.opts)
+			if log.Version != SARIFVersion || log.Schema != SARIFSchema || len(log.Runs) != 1 {
+				t.Fatalf("SARIF() = %s %s with %d runs, want one run", log.Schema, log.Version, len(log.Runs))
+			}
+			run := log.Runs[0]
+			var results []string
+			for _, r := range run.Results {
+				loc := r.Locations[0]
+				line := 0
+				if loc.PhysicalLocation.Region != nil {
+					line = loc.PhysicalLocation.Region.StartLine
+				}
+				results = append(results, fmt.Sprintf("%s %s %s:%d %s", r.RuleID, r.Level, loc.PhysicalLocation.ArtifactLocation.URI, line,
+					loc.LogicalLocations[0].FullyQualifiedName))
+			}
+			if strings.Join(results, "\n") != strings.Join(tt.results, "\n") {
+				t.Errorf("SARIF() results =\n%s\nwant\n%s", strings.Join(results, "\n"), strings.Join(tt.results, "\n"))
+			}
+			var rules []string
+			for _, r := range run.Tool.Driver.Rules {
+				rules = append(rules, r.ID)
+			}
+			if got := strings.Join(rules, ","); got != tt.rules {
+				t.Errorf("SARIF() rules = %q, want %q", got, tt.rules)
+			}
+			if run.Properties["riskGrade"] != "C" || run.Properties["riskScore"] != 45 || run.Invocations != nil {
+				t.Errorf("SARIF() properties = %v, invocations = %v, want grade C, score 45 and no invocation", run.Properties, run.Invocations)
+			}
+		})
+	}
+}
+
+func TestDiffReportSARIFMaxGrade(t *testing.T) {
+	for maxGrade, want := range map[string]bool{"B": false, "C": true, "F": true} {
+		run := sarifReport().SARIF(SARIFOptions{MaxGrade: maxGrade}).Runs[0]
+		if len(run.Invocations) != 1 || run.Invocations[0].ExecutionSuccessful != want {
+			t.Errorf("SARIF(max grade %s) invocations = %+v, want successful %v", maxGrade, run.Invocations, want)
+		}
+		if run.Properties["maxGrade"] != maxGrade {
+			t.Errorf("SARIF(max grade %s) maxGrade = %v", maxGrade, run.Properties["maxGrade"])
+		}
+	}
+}
+
+func TestDiffReportSARIFFingerprints(t *testing.T) {
+	opts := SARIFOptions{Fallback: "summary.json", MinRisk: 40}
+	first, second := sarifReport().SARIF(opts).Runs[0], sarifReport().SARIF(opts).Runs[0]
+	seen := make(map[string]bool)
+	for i, r := range first.Results {
+		fp := r.PartialFingerprints["kubearmorBehavior/v1"]
+		if fp == "" || seen[fp] {
+			t.Errorf("result %d fingerprint %q is empty or repeated", i, fp)
+		}
+		seen[fp] = true
+		// the fingerprints are stable across runs, so that the dismissals stick
+		if other := second.Results[i].PartialFingerprints["kubearmorBehavior/v1"]; other != fp {
+			t.Errorf("result %d fingerprint = %q, then %q", i, fp, other)
+		}
+	}
+}
+
+func TestSARIFRule(t *testing.T) {
+	var shadow Rule
+	for _, r := range BuiltinRules {
+		if r.ID == "KA-FILE-001" {
+			shadow = r
+		}
+	}
+	sr := sarifRule(shadow)
+	if sr.Name != "ReadOfTheShadowPasswordFile" || sr.DefaultConfiguration.Level != "error" {
+		t.Errorf("sarifRule() = %s %s, want ReadOfTheShadowPasswordFile error", sr.Name, sr.DefaultConfiguration.Level)
+	}
+	tags, _ := sr.Properties["tags"].([]string)
+	if strings.Join(tags, ",") != "security,kubearmor,external/mitre-attack/T1003.008" {
+		t.Errorf("sarifRule() tags = %v, want the ATT&CK technique", tags)
+	}
+	if sr.Help == nil || !strings.Contains(sr.Help.Markdown, "[T1003.008 OS Credential Dumping: /etc/passwd and /etc/shadow](https://attack.mitre.org/techniques/T1003/008/)") {
+		t.Errorf("sarifRule() help = %+v, want a link to the technique", sr.Help)
+	}
+	// the rules without technique have no help
+	if sr := sarifRule(riskyBehaviorRule); sr.Help != nil || sr.Name != "NewRiskyBehavior" || sr.DefaultConfiguration.Level != "warning" {
+		t.Errorf("sarifRule(%s) = %+v", riskyBehaviorRule.ID, sr)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, sarifReport().SARIF(SARIFOptions{Fallback: "summary.json"})); err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("WriteSARIF() wrote invalid JSON: %v", err)
+	}
+	if log["$schema"] != SARIFSchema || log["version"] != SARIFVersion {
+		t.Errorf("WriteSARIF() $schema, version = %v, %v", log["$schema"], log["version"])
+	}
+	if strings.Contains(buf.String(), `\u0026`) || !strings.Contains(buf.String(), "ATT&CK") {
+		t.Errorf("WriteSARIF() escaped the HTML characters")
+	}
+}
@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SchemaDraft is the JSON Schema dialect of the summary schema
+	SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	// SchemaID is the published location of the summary schema
+	SchemaID = "https://raw.githubusercontent.com/kubearmor/kubearmor-action/main/docs/schema/summary.schema.json"
+)
+
+var (
+	// countPattern matches the strings of a karmor Count, eg.: "3", with the white space and the
+	// sign ParseCount accepts, or an empty string
+	countPattern = `^[[:space:]]*(\+?[0-9]+|-0+)?[[:space:]]*$`
+	// timestampPattern matches a karmor UpdatedTime, eg.: "Mon Jul  3 08:26:40 UTC 2023",
+	// an RFC 3339 time, eg.: "2023-07-03T08:26:40Z", or an empty string
+	timestampPattern = `^[[:space:]]*$|^[[:space:]]*[A-Z][a-z]{2} [A-Z][a-z]{2} +[0-9]{1,2} [0-9]{2}:[0-9]{2}:[0-9]{2} [A-Za-z+0-9-]+ [0-9]{4}[[:space:]]*$` +
+		`|^[[:space:]]*[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?(Z|[+-][0-9]{2}:[0-9]{2})[[:space:]]*$`
+
+	countType     = reflect.TypeOf(Count(0))
+	timestampType = reflect.TypeOf(Timestamp{})
+
+	// patterns caches the compiled schema patterns
+	patterns = map[string]*regexp.Regexp{
+		countPattern:     regexp.MustCompile(countPattern),
+		timestampPattern: regexp.MustCompile(timestampPattern),
+	}
+)
+
+// Schema is the subset of JSON Schema used to describe the summary data
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 SchemaType         `json:"type"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Minimum              *int64             `json:"minimum,omitempty"`
+
+	// check is the parser of the values, it rejects the values a pattern cannot, eg.: a count
+	// overflowing int64
+	check func(json.RawMessage) error
+}
+
+// SchemaType is the type of a schema, a single type or a type and null, eg.: ["string", "null"]
+type SchemaType []string
+
+// MarshalJSON encodes a single type as a string, several types as an array
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// String returns the types joined with " or "
+func (t SchemaType) String() string {
+	return strings.Join(t, " or ")
+}
+
+// nullable reports whether null is one of the types
+func (t SchemaType) nullable() bool {
+	for _, name := range t {
+		if name == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// of returns the type of a decoded value, a number is an integer as well
+func (t SchemaType) of(v interface{}) (string, bool) {
+	typ := jsonTypeName(v)
+	for _, name := range t {
+		if name == typ || (name == "integer" && typ == "number") {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Violation is a place where the summary data does not match the schema
+type Violation struct {
+	// Pointer is the JSON pointer (RFC 6901) of the offending value
+	Pointer string `json:"pointer"`
+	// Message describes the violation
+	Message string `json:"message"`
+}
+
+// String returns the violation in the form pointer: message
+func (v Violation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// SummarySchema generates the JSON Schema of a karmor summary, a list of SummaryData
+func SummarySchema() *Schema {
+	return &Schema{
+		Schema:      SchemaDraft,
+		ID:          SchemaID,
+		Title:       "KubeArmor summary",
+		Description: "Output of karmor summary -o json, generated from the SummaryData types",
+		Type:        SchemaType{"array"},
+		Items:       schemaOf(reflect.TypeOf(summaryData{})),
+	}
+}
+
+// schemaOf generates the schema of a Go type
+func schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == countType:
+		var zero int64
+		return &Schema{Type: SchemaType{"string", "integer", "null"}, Pattern: countPattern, Minimum: &zero,
+			Description: "number of occurrences, a decimal string or an integer, empty or null if unknown",
+			check:       func(data json.RawMessage) error { return new(Count).UnmarshalJSON(data) }}
+	case t == timestampType:
+		return &Schema{Type: SchemaType{"string", "null"}, Pattern: timestampPattern,
+			Description: "last occurrence, in the layout " + TimeLayout + " or RFC 3339, null if unknown",
+			check:       func(data json.RawMessage) error { return new(Timestamp).UnmarshalJSON(data) }}
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return &Schema{Type: SchemaType{"array"}, Items: schemaOf(t.Elem())}
+	case reflect.Struct:
+		// newer karmor versions add fields, so additional properties are allowed
+		s := &Schema{Type: SchemaType{"object"}, Properties: make(map[string]*Schema)}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			if tag[0] == "-" || f.PkgPath != "" {
+				continue
+			}
+			name := tag[0]
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = schemaOf(f.Type)
+			// karmor leaves out the counts and the times it does not know
+			optional := f.Type == countType || f.Type == timestampType
+			if !optional && (len(tag) == 1 || tag[1] != "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: SchemaType{"integer"}}
+	default:
+		return &Schema{Type: SchemaType{"string"}}
+	}
+}
+
+// Validate validates a decoded JSON value (as produced by a json.Decoder with UseNumber)
+// against the schema and returns every violation, pointers are prefixed with pointer
+func (s *Schema) Validate(v interface{}, pointer string) []Violation {
+	return s.validate(v, pointer, false)
+}
+
+// ValidateStrict validates a decoded JSON value like Validate, and also reports
+// the fields that are not described by the schema
+func (s *Schema) ValidateStrict(v interface{}, pointer string) []Violation {
+	return s.validate(v, pointer, true)
+}
+
+// validate validates a decoded JSON value, strict reports the unknown fields
+// even where the schema allows additional properties
+func (s *Schema) validate(v interface{}, pointer string, strict bool) []Violation {
+	if v == nil && s.Type.nullable() {
+		return nil
+	}
+	typ, ok := s.Type.of(v)
+	if !ok {
+		return []Violation{typeViolation(pointer, s.Type.String(), v)}
+	}
+	var violations []Violation
+	switch typ {
+	case "object":
+		obj := v.(map[string]interface{})
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("missing required field %q", name)})
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps, ok := s.Properties[k]
+			if !ok {
+				if strict || (s.AdditionalProperties != nil && !*s.AdditionalProperties) {
+					violations = append(violations, Violation{Pointer: pointer + "/" + escapePointer(k), Message: "unknown field"})
+				}
+				continue
+			}
+			violations = append(violations, ps.validate(obj[k], pointer+"/"+escapePointer(k), strict)...)
+		}
+	case "array":
+		arr := v.([]interface{})
+		if s.Items != nil {
+			for i, item := range arr {
+				violations = append(violations, s.Items.validate(item, pointer+"/"+strconv.Itoa(i), strict)...)
+			}
+		}
+	case "string":
+		str := v.(string)
+		if s.Pattern != "" && !compilePattern(s.Pattern).MatchString(str) {
+			violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("value %q does not match pattern %s", str, s.Pattern)})
+		}
+	case "integer":
+		n, _ := v.(json.Number)
+		i, err := n.Int64()
+		if err != nil {
+			violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("value %s is not an integer", n)})
+		} else if s.Minimum != nil && i < *s.Minimum {
+			violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("value %s is less than %d", n, *s.Minimum)})
+		}
+	}
+	if len(violations) == 0 && s.check != nil {
+		data, err := json.Marshal(v)
+		if err == nil {
+			err = s.check(data)
+		}
+		if err != nil {
+			violations = append(violations, Violation{Pointer: pointer, Message: err.Error()})
+		}
+	}
+	return violations
+}
+
+// ValidateSummaryData validates the summary data read from r against the summary schema
+// and returns every violation. Entries are validated one at a time to keep memory bounded.
+// The name is only used in error messages.
+func ValidateSummaryData(r io.Reader, name string) ([]Violation, error) {
+	return ParseOptions{}.ValidateSummaryData(r, name)
+}
+
+// ValidateSummaryData validates the summary data read from r with the options, see ValidateSummaryData.
+// Strict also reports the fields that are not described by the schema.
+func (o ParseOptions) ValidateSummaryData(r io.Reader, name string) ([]Violation, error) {
+	var violations []Violation
+	err := walkRawSummaryData(r, name, func(index int, offset int64, raw json.RawMessage) error {
+		vs, err := validateEntry(raw, index, o.Strict)
+		if err != nil {
+			return &JSONError{Path: name, Offset: offset, Err: err}
+		}
+		violations = append(violations, vs...)
+		return nil
+	})
+	return violations, err
+}
+
+// summaryItemSchema is the schema of a single SummaryData entry
+var summaryItemSchema = SummarySchema().Items
+
+// validateEntry validates a single raw SummaryData entry, strict reports the unknown fields
+func validateEntry(raw json.RawMessage, index int, strict bool) ([]Violation, error) {
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return summaryItemSchema.validate(v, "/"+strconv.Itoa(index), strict), nil
+}
+
+// typeViolation returns a violation for a value of the wrong type
+func typeViolation(pointer, want string, v interface{}) Violation {
+	return Violation{Pointer: pointer, Message: fmt.Sprintf("expected %s, got %s", want, jsonTypeName(v))}
+}
+
+// jsonTypeName returns the JSON type name of a decoded value
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// escapePointer escapes a JSON pointer reference token
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// compilePattern returns the compiled pattern, from the cache if possible
+func compilePattern(p string) *regexp.Regexp {
+	if re, ok := patterns[p]; ok {
+		return re
+	}
+	return regexp.MustCompile(p)
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValidateSummaryData(t *testing.T) {
+	const entry = `"DeploymentName": "carts", "PodName": "carts-5df4cd65d5-x8kqz", "ClusterName": "default", "Namespace": "sock-shop", "Label": "name=carts"`
+	tests := []struct {
+		name       string
+		input      string
+		want       []string
+		wantStrict []string
+	}{
+		{name: "valid", input: `[{` + entry + `}]`},
+		{
+			name:       "unknown fields",
+			input:      `[{` + entry + `, "Workload": {"Kind": "Deployment"}, "ProcessData": [{"Source": "/bin/sh", "Destination": "/bin/ls", "Status": "Allow", "Pid": 1}]}]`,
+			wantStrict: []string{"/0/ProcessData/0/Pid: unknown field", "/0/Workload: unknown field"},
+		},
+		{name: "karmor time", input: `[{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "UpdatedTime": "Mon Jul  3 08:26:40 UTC 2023", "Status": "Allow"}]}]`},
+		{name: "RFC 3339 time", input: `[{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "UpdatedTime": "2023-07-03T08:26:40.123Z", "Status": "Allow"}]}]`},
+		{name: "RFC 3339 offset", input: `[{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "UpdatedTime": "2023-07-03T10:26:40+02:00", "Status": "Allow"}]}]`},
+		{name: "null time", input: `[{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "UpdatedTime": null, "Status": "Allow"}]}]`},
+		{
+			name:       "invalid time",
+			input:      `[{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "UpdatedTime": "03/07/2023", "Status": "Allow"}]}]`,
+			want:       []string{"/0/FileData/0/UpdatedTime: value \"03/07/2023\" does not match pattern " + timestampPattern},
+			wantStrict: []string{"/0/FileData/0/UpdatedTime: value \"03/07/2023\" does not match pattern " + timestampPattern},
+		},
+		{
+			name:       "missing field",
+			input:      `[{"DeploymentName": "carts", "PodName": "carts", "ClusterName": "default", "Namespace": "sock-shop"}]`,
+			want:       []string{"/0: missing required field \"Label\""},
+			wantStrict: []string{"/0: missing required field \"Label\""},
+		},
+		{
+			name:       "wrong type",
+			input:      `[{` + entry + `, "ProcessData": [{"Source": "/bin/sh", "Destination": "/bin/ls", "Count": true, "Status": "Allow"}]}]`,
+			want:       []string{"/0/ProcessData/0/Count: expected string or integer or null, got boolean"},
+			wantStrict: []string{"/0/ProcessData/0/Count: expected string or integer or null, got boolean"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, strict := range []bool{false, true} {
+				want := tt.want
+				if strict {
+					want = tt.wantStrict
+				}
+				violations, err := ParseOptions{Strict: strict}.ValidateSummaryData(strings.NewReader(tt.input), "input")
+				if err != nil {
+					t.Fatalf("ValidateSummaryData() error = %v", err)
+				}
+				var got []string
+				for _, v := range violations {
+					got = append(got, v.String())
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("ValidateSummaryData(strict %v) = %q, want %q", strict, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSchemaMatchesParser runs the schema and the parser on the same counts and times,
+// the schema must accept the values the parser accepts and only them
+func TestSchemaMatchesParser(t *testing.T) {
+	const entry = `"DeploymentName": "carts", "PodName": "carts", "ClusterName": "default", "Namespace": "sock-shop", "Label": "name=carts"`
+	tests := []struct {
+		field string
+		value string
+		want  bool
+		// beyond says the published pattern cannot reject the value, only the validator does
+		beyond bool
+	}{
+		{field: "Count", value: `"3"`, want: true},
+		{field: "Count", value: `3`, want: true},
+		{field: "Count", value: `0`, want: true},
+		{field: "Count", value: `""`, want: true},
+		{field: "Count", value: `" 4\n"`, want: true},
+		{field: "Count", value: `"+5"`, want: true},
+		{field: "Count", value: `"-0"`, want: true},
+		{field: "Count", value: `null`, want: true},
+		{field: "Count", value: `-1`},
+		{field: "Count", value: `"-1"`},
+		{field: "Count", value: `3.5`},
+		{field: "Count", value: `1e3`},
+		{field: "Count", value: `"3.5"`},
+		{field: "Count", value: `"many"`},
+		{field: "Count", value: `true`},
+		{field: "Count", value: `"99999999999999999999"`, beyond: true},
+		{field: "UpdatedTime", value: `"Mon Jul  3 08:26:40 UTC 2023"`, want: true},
+		{field: "UpdatedTime", value: `"Mon Jul 3 08:26:40 UTC 2023"`, want: true},
+		{field: "UpdatedTime", value: `"Mon Jul 13 08:26:40 UTC 2023"`, want: true},
+		{field: "UpdatedTime", value: `"2023-07-03T10:26:40+02:00"`, want: true},
+		{field: "UpdatedTime", value: `" 2023-07-03T08:26:40Z "`, want: true},
+		{field: "UpdatedTime", value: `""`, want: true},
+		{field: "UpdatedTime", value: `null`, want: true},
+		{field: "UpdatedTime", value: `"03/07/2023"`},
+		{field: "UpdatedTime", value: `1688372800`},
+		{field: "UpdatedTime", value: `"Mon Jul 33 08:26:40 UTC 2023"`, beyond: true},
+	}
+	for _, tt := range tests {
+		raw := `{` + entry + `, "FileData": [{"Source": "/bin/sh", "Destination": "/etc/passwd", "Status": "Allow", "` + tt.field + `": ` + tt.value + `}]}`
+
+		_, err := ReadSummaryData(strings.NewReader(raw), "input")
+		if parsed := err == nil; parsed != tt.want {
+			t.Errorf("%s %s: parsed = %v (%v), want %v", tt.field, tt.value, parsed, err, tt.want)
+		}
+		violations, err := ValidateSummaryData(strings.NewReader(raw), "input")
+		if err != nil {
+			t.Fatalf("ValidateSummaryData() error = %v", err)
+		}
+		if valid := len(violations) == 0; valid != tt.want {
+			t.Errorf("%s %s: valid = %v (%v), want %v", tt.field, tt.value, valid, violations, tt.want)
+		}
+
+		// the published schema, used by other validators, agrees as well
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.value), &v); err != nil {
+			t.Fatal(err)
+		}
+		if str, ok := v.(string); ok && !tt.beyond {
+			pattern := countPattern
+			if tt.field == "UpdatedTime" {
+				pattern = timestampPattern
+			}
+			if matched := regexp.MustCompile(pattern).MatchString(str); matched != tt.want {
+				t.Errorf("%s %s: pattern matched = %v, want %v", tt.field, tt.value, matched, tt.want)
+			}
+		}
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	input := `[{"DeploymentName": "carts", "PodName": "carts", "ClusterName": "default", "Namespace": "sock-shop", "Label": "name=carts", "Workload": {}}]`
+	if _, err := ReadSummaryData(strings.NewReader(input), "input"); err != nil {
+		t.Errorf("ReadSummaryData() error = %v, want the unknown field ignored", err)
+	}
+	_, err := ParseOptions{Strict: true}.ReadSummaryData(strings.NewReader(input), "input")
+	var se *SchemaError
+	if !errors.As(err, &se) || se.Pointer != "/0/Workload" {
+		t.Errorf("ReadSummaryData(strict) error = %v, want a schema error at /0/Workload", err)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import "strings"
+
+// Statuses of the process and file entries, as set by the KubeArmor policies
+const (
+	StatusAllow = "Allow"
+	StatusAudit = "Audit"
+	StatusBlock = "Block"
+)
+
+// statusColors are the PlantUML colors of the audited and blocked behaviors, allowed ones are not colored
+var statusColors = map[string]string{
+	StatusAudit: "Gold",
+	StatusBlock: "Red",
+}
+
+// statusRanks orders the statuses from the least to the most restrictive
+var statusRanks = map[string]int{
+	StatusAllow: 0,
+	StatusAudit: 1,
+	StatusBlock: 2,
+}
+
+// StatusCounts counts the allowed, audited and blocked events of a workload
+type StatusCounts struct {
+	Allow Count `json:"Allow"`
+	Audit Count `json:"Audit"`
+	Block Count `json:"Block"`
+}
+
+// Add adds count events with the status
+func (c *StatusCounts) Add(status string, count Count) {
+	switch NormalizeStatus(status) {
+	case StatusBlock:
+		c.Block += count
+	case StatusAudit:
+		c.Audit += count
+	default:
+		c.Allow += count
+	}
+}
+
+// NormalizeStatus returns the status as Allow, Audit or Block. An empty or unknown status is Allow,
+// a status mentioning both, eg.: "Audit (Block)", is Audit since the behavior was not blocked.
+func NormalizeStatus(status string) string {
+	s := strings.ToLower(status)
+	switch {
+	case strings.Contains(s, "audit"):
+		return StatusAudit
+	case strings.Contains(s, "block"):
+		return StatusBlock
+	default:
+		return StatusAllow
+	}
+}
+
+// worseStatus returns the most restrictive of two statuses, Block before Audit before Allow
+func worseStatus(a, b string) string {
+	a, b = NormalizeStatus(a), NormalizeStatus(b)
+	if statusRanks[b] > statusRanks[a] {
+		return b
+	}
+	return a
+}
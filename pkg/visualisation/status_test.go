@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import "testing"
+
+func TestNormalizeStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: "Allow", want: StatusAllow},
+		{status: "", want: StatusAllow},
+		{status: "Unknown", want: StatusAllow},
+		{status: "audit", want: StatusAudit},
+		{status: "Audit (Block)", want: StatusAudit},
+		{status: "BLOCK", want: StatusBlock},
+		{status: " Block ", want: StatusBlock},
+	}
+	for _, tt := range tests {
+		if got := NormalizeStatus(tt.status); got != tt.want {
+			t.Errorf("NormalizeStatus(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestWorseStatus(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{a: "", b: "", want: StatusAllow},
+		{a: StatusAllow, b: StatusAudit, want: StatusAudit},
+		{a: StatusAudit, b: StatusAllow, want: StatusAudit},
+		{a: StatusAudit, b: StatusBlock, want: StatusBlock},
+		{a: "block", b: StatusAudit, want: StatusBlock},
+		{a: "Audit (Block)", b: StatusAllow, want: StatusAudit},
+	}
+	for _, tt := range tests {
+		if got := worseStatus(tt.a, tt.b); got != tt.want {
+			t.Errorf("worseStatus(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestStatusCountsAdd(t *testing.T) {
+	var c StatusCounts
+	for _, e := range []struct {
+		status string
+		count  Count
+	}{
+		{status: StatusAllow, count: 3},
+		{status: "", count: 1},
+		{status: "Audit (Block)", count: 2},
+		{status: "block", count: 5},
+		{status: StatusBlock, count: 1},
+	} {
+		c.Add(e.status, e.count)
+	}
+	if want := (StatusCounts{Allow: 4, Audit: 2, Block: 6}); c != want {
+		t.Errorf("StatusCounts = %+v, want %+v", c, want)
+	}
+}
@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	"github.com/kubearmor/kubearmor-action/utils"
+)
+
+// Presence marks of BehaviorTrend.Presence
+const (
+	PresenceSeen   = 'x'
+	PresenceAbsent = '.'
+)
+
+// DefaultTrendTitle is the title of the trend report
+const DefaultTrendTitle = "KubeArmor Behavior Trend"
+
+// TrendSummary is one of the ordered summaries of a trend, eg.: the summary of a run
+type TrendSummary struct {
+	// Name is the file, the URL or the baseline key of the summary
+	Name string
+	// Time is when the summary was collected, the newest UpdatedTime of its entries if zero
+	Time Timestamp
+	Data []*SummaryData
+}
+
+// TrendRun is a run of the trend report
+type TrendRun struct {
+	Name string    `json:"Name"`
+	Time Timestamp `json:"Time"`
+	// Behaviors is the number of behaviors of the run, Added and Removed compare it with the previous run
+	Behaviors int `json:"Behaviors"`
+	Added     int `json:"Added"`
+	Removed   int `json:"Removed"`
+}
+
+// BehaviorTrend is the presence of a behavior of a workload across the runs
+type BehaviorTrend struct {
+	Workload DiffWorkload `json:"Workload"`
+	// Behavior is the behavior as last seen
+	Behavior Behavior `json:"Behavior"`
+	// Presence has one mark per run, PresenceSeen or PresenceAbsent, eg.: "xx.x"
+	Presence string `json:"Presence"`
+	// FirstSeen and LastSeen are the indexes of the first and last runs the behavior was seen in
+	FirstSeen     int       `json:"FirstSeen"`
+	LastSeen      int       `json:"LastSeen"`
+	FirstSeenTime Timestamp `json:"FirstSeenTime"`
+	LastSeenTime  Timestamp `json:"LastSeenTime"`
+	// Stability is the share of the runs the behavior was seen in, from 0 to 1
+	Stability float64 `json:"Stability"`
+	// Nondeterministic is set if the behavior came and went more than once, eg.: a flaky health check
+	// that disappeared and reappeared, or a behavior only seen in runs in the middle of the trend
+	Nondeterministic bool `json:"Nondeterministic,omitempty"`
+}
+
+// TrendReport is the presence of the behaviors across ordered runs
+type TrendReport struct {
+	Runs      []TrendRun      `json:"Runs"`
+	Behaviors []BehaviorTrend `json:"Behaviors"`
+}
+
+// Trend computes the presence of the behaviors selected by the filter across the summaries,
+// oldest first. The behaviors are sorted by workload, the nondeterministic ones first, then the
+// least stable ones.
+func Trend(summaries []TrendSummary, f *filter.Filter) *TrendReport {
+	type trendKey struct {
+		workload DiffWorkload
+		behavior behaviorKey
+	}
+	report := &TrendReport{}
+	trends := make(map[trendKey]*BehaviorTrend)
+	var order []trendKey
+	var previous map[trendKey]bool
+	for i, s := range summaries {
+		run := TrendRun{Name: s.Name, Time: s.Time}
+		if run.Time.IsZero() {
+			run.Time = newestTime(s.Data)
+		}
+		byWorkload, workloads := behaviorsByWorkload(s.Data, f)
+		current := make(map[trendKey]bool)
+		for _, w := range workloads {
+			for k, b := range byWorkload[w] {
+				tk := trendKey{workload: w, behavior: k}
+				current[tk] = true
+				t, ok := trends[tk]
+				if !ok {
+					t = &BehaviorTrend{Workload: w, Presence: strings.Repeat(string(PresenceAbsent), len(summaries)), FirstSeen: i, FirstSeenTime: run.Time}
+					trends[tk] = t
+					order = append(order, tk)
+				}
+				t.Behavior = b
+				t.LastSeen, t.LastSeenTime = i, run.Time
+				t.Presence = t.Presence[:i] + string(PresenceSeen) + t.Presence[i+1:]
+			}
+		}
+		run.Behaviors = len(current)
+		if i > 0 {
+			for tk := range current {
+				if !previous[tk] {
+					run.Added++
+				}
+			}
+			for tk := range previous {
+				if !current[tk] {
+					run.Removed++
+				}
+			}
+		}
+		previous = current
+		report.Runs = append(report.Runs, run)
+	}
+
+	for _, tk := range order {
+		t := trends[tk]
+		seen := strings.Count(t.Presence, string(PresenceSeen))
+		t.Stability = float64(seen) / float64(len(summaries))
+		t.Nondeterministic = flapping(t.Presence)
+		report.Behaviors = append(report.Behaviors, *t)
+	}
+	sort.SliceStable(report.Behaviors, func(i, j int) bool {
+		a, b := report.Behaviors[i], report.Behaviors[j]
+		if a.Workload != b.Workload {
+			return a.Workload.String() < b.Workload.String()
+		}
+		if a.Nondeterministic != b.Nondeterministic {
+			return a.Nondeterministic
+		}
+		if a.Stability != b.Stability {
+			return a.Stability < b.Stability
+		}
+		return lessBehavior(a.Behavior, b.Behavior)
+	})
+	return report
+}
+
+// flapping reports whether the presence changes more than once, eg.: "x.x" or ".x.", while a
+// behavior that was added (".xx") or removed ("xx.") changes once
+func flapping(presence string) bool {
+	changes := 0
+	for i := 1; i < len(presence); i++ {
+		if presence[i] != presence[i-1] {
+			changes++
+		}
+	}
+	return changes > 1
+}
+
+// Nondeterministic returns the nondeterministic behaviors of the trend
+func (r *TrendReport) Nondeterministic() []BehaviorTrend {
+	var nd []BehaviorTrend
+	for _, t := range r.Behaviors {
+		if t.Nondeterministic {
+			nd = append(nd, t)
+		}
+	}
+	return nd
+}
+
+// Markdown renders the trend report: the runs with the behaviors added and removed from one run to
+// the next, and a heat matrix of the behaviors that were not seen in every run, the
+// nondeterministic ones first
+func (r *TrendReport) Markdown(opts ReportOptions) string {
+	if opts.Title == "" {
+		opts.Title = DefaultTrendTitle
+	}
+	opts = opts.withDefaults()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n\n", opts.Title))
+
+	var unstable []BehaviorTrend
+	for _, t := range r.Behaviors {
+		if t.Stability < 1 {
+			unstable = append(unstable, t)
+		}
+	}
+	sort.SliceStable(unstable, func(i, j int) bool {
+		if unstable[i].Nondeterministic != unstable[j].Nondeterministic {
+			return unstable[i].Nondeterministic
+		}
+		return unstable[i].Stability < unstable[j].Stability
+	})
+	sb.WriteString(fmt.Sprintf("**%d runs** · %d behaviors · %d stable · %d nondeterministic\n",
+		len(r.Runs), len(r.Behaviors), len(r.Behaviors)-len(unstable), len(r.Nondeterministic())))
+
+	sb.WriteString("\n### Runs\n\n")
+	sb.WriteString("| # | Run | Time | Behaviors | Added | Removed |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for i, run := range r.Runs {
+		sb.WriteString(fmt.Sprintf("| %d | %s | %s | %d | %d | %d |\n", i+1, mdCell(run.Name), run.Time, run.Behaviors, run.Added, run.Removed))
+	}
+
+	if len(unstable) > 0 {
+		sb.WriteString("\n### Unstable behaviors\n\n")
+		sb.WriteString("Each cell is a run, oldest first: █ seen, ░ not seen. Nondeterministic behaviors came and went more than once.\n\n")
+		sb.WriteString("| Workload | Kind | Behavior | Runs | First seen | Last seen | Stability | |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+		for i, t := range unstable {
+			if i == opts.MaxRows {
+				break
+			}
+			flag := ""
+			if t.Nondeterministic {
+				flag = "⚠️ nondeterministic"
+			}
+			cells := strings.NewReplacer(string(PresenceSeen), "█", string(PresenceAbsent), "░").Replace(t.Presence)
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | `%s` | %s | %s | %d%% | %s |\n", mdCell(t.Workload.String()), t.Behavior.Kind,
+				mdCode(t.Behavior.String()), cells, seenCell(t.FirstSeen, t.FirstSeenTime), seenCell(t.LastSeen, t.LastSeenTime),
+				int(t.Stability*100+0.5), flag))
+		}
+		if more := len(unstable) - opts.MaxRows; more > 0 {
+			sb.WriteString(fmt.Sprintf("\n_... and %d more, see the trend report_\n", more))
+		}
+	}
+	return truncateReport(sb.String(), "", false, opts.MaxLength)
+}
+
+// seenCell renders the run a behavior was first or last seen in, eg.: "#2 (Mon Jul  3 08:26:40 UTC 2023)"
+func seenCell(run int, at Timestamp) string {
+	if at.IsZero() {
+		return fmt.Sprintf("#%d", run+1)
+	}
+	return fmt.Sprintf("#%d (%s)", run+1, at)
+}
+
+// ReadTrendReport reads a JSON trend report from a file or a URL
+func ReadTrendReport(path string) (*TrendReport, error) {
+	data, err := utils.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading trend report %s: %w", path, err)
+	}
+	r := &TrendReport{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parsing trend report %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Ignore removes the behaviors of the trends from the changes and the new findings of the
+// report, eg.: the nondeterministic ones, and scores the report again with c. It returns the
+// number of changes removed.
+func (r *DiffReport) Ignore(trends []BehaviorTrend, c *Classifier) int {
+	ignored := make(map[DiffWorkload]map[behaviorKey]bool)
+	for _, t := range trends {
+		if ignored[t.Workload] == nil {
+			ignored[t.Workload] = make(map[behaviorKey]bool)
+		}
+		ignored[t.Workload][t.Behavior.key()] = true
+	}
+	removed := 0
+	keep := func(w DiffWorkload, b Behavior) bool {
+		if ignored[w][b.key()] {
+			removed++
+			return false
+		}
+		return true
+	}
+	for _, wd := range r.Workloads {
+		added := wd.Added[:0]
+		for _, b := range wd.Added {
+			if keep(wd.Workload, b) {
+				added = append(added, b)
+			}
+		}
+		wd.Added = added
+		removedBs := wd.Removed[:0]
+		for _, b := range wd.Removed {
+			if keep(wd.Workload, b) {
+				removedBs = append(removedBs, b)
+			}
+		}
+		wd.Removed = removedBs
+		changed := wd.Changed[:0]
+		for _, ch := range wd.Changed {
+			if keep(wd.Workload, ch.Behavior) {
+				changed = append(changed, ch)
+			}
+		}
+		wd.Changed = changed
+		volume := wd.Volume[:0]
+		for _, v := range wd.Volume {
+			if keep(wd.Workload, v.Behavior) {
+				volume = append(volume, v)
+			}
+		}
+		wd.Volume = volume
+		// the findings of ignored behaviors are kept, but no longer new
+		for i := range wd.Findings {
+			if ignored[wd.Workload][wd.Findings[i].Behavior.key()] {
+				wd.Findings[i].New = false
+			}
+		}
+	}
+	r.score(c)
+	return removed
+}
+
+// newestTime returns the newest UpdatedTime of the entries of the summary
+func newestTime(sds []*SummaryData) Timestamp {
+	var newest Timestamp
+	update := func(t Timestamp) {
+		if t.After(newest.Time) {
+			newest = t
+		}
+	}
+	for _, sd := range sds {
+		for _, e := range sd.ProcessData {
+			update(e.UpdatedTime)
+		}
+		for _, e := range sd.FileData {
+			update(e.UpdatedTime)
+		}
+		for _, e := range sd.IngressConnection {
+			update(e.UpdatedTime)
+		}
+		for _, e := range sd.EgressConnection {
+			update(e.UpdatedTime)
+		}
+		for _, e := range sd.BindConnection {
+			update(e.UpdatedTime)
+		}
+	}
+	return newest
+}
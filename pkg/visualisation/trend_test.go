@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlapping(t *testing.T) {
+	tests := []struct {
+		presence string
+		want     bool
+	}{
+		{presence: "xxx"},
+		{presence: ".xx"},
+		{presence: "xx."},
+		{presence: "x"},
+		{presence: "x.x", want: true},
+		{presence: ".x.", want: true},
+		{presence: "..x.", want: true},
+		{presence: "x..xx", want: true},
+		{presence: ".x.x", want: true},
+	}
+	for _, tt := range tests {
+		if got := flapping(tt.presence); got != tt.want {
+			t.Errorf("flapping(%q) = %v, want %v", tt.presence, got, tt.want)
+		}
+	}
+}
+
+func TestTrend(t *testing.T) {
+	entry := func(processes ...string) []*SummaryData {
+		sd := &SummaryData{DeploymentName: "carts", PodName: "carts", Namespace: "sock-shop", Label: "name=carts"}
+		for _, p := range processes {
+			sd.ProcessData = append(sd.ProcessData, ProcessData{Source: "/bin/sh", Destination: p, Count: 1, Status: "Allow"})
+		}
+		return []*SummaryData{sd}
+	}
+	start := time.Date(2023, 7, 3, 8, 0, 0, 0, time.UTC)
+	var summaries []TrendSummary
+	for i, data := range [][]*SummaryData{
+		entry("/bin/ls", "/bin/cat", "/bin/rm"),
+		entry("/bin/ls", "/bin/curl", "/bin/rm"),
+		entry("/bin/ls", "/bin/cat", "/bin/sed"),
+	} {
+		summaries = append(summaries, TrendSummary{Name: "run", Time: Timestamp{Time: start.Add(time.Duration(i) * time.Hour)}, Data: data})
+	}
+	report := Trend(summaries, nil)
+
+	want := map[string]struct {
+		presence         string
+		first, last      int
+		nondeterministic bool
+	}{
+		"/bin/ls":   {presence: "xxx", first: 0, last: 2},
+		"/bin/cat":  {presence: "x.x", first: 0, last: 2, nondeterministic: true},
+		"/bin/curl": {presence: ".x.", first: 1, last: 1, nondeterministic: true},
+		"/bin/rm":   {presence: "xx.", first: 0, last: 1},
+		"/bin/sed":  {presence: "..x", first: 2, last: 2},
+	}
+	if len(report.Behaviors) != len(want) {
+		t.Fatalf("Trend() = %d behaviors, want %d", len(report.Behaviors), len(want))
+	}
+	for _, b := range report.Behaviors {
+		w := want[b.Behavior.Destination]
+		if b.Presence != w.presence || b.FirstSeen != w.first || b.LastSeen != w.last || b.Nondeterministic != w.nondeterministic {
+			t.Errorf("%s: presence %q, seen %d-%d, nondeterministic %v, want %q, %d-%d, %v", b.Behavior.Destination,
+				b.Presence, b.FirstSeen, b.LastSeen, b.Nondeterministic, w.presence, w.first, w.last, w.nondeterministic)
+		}
+		if !b.FirstSeenTime.Equal(summaries[w.first].Time.Time) || !b.LastSeenTime.Equal(summaries[w.last].Time.Time) {
+			t.Errorf("%s: seen from %s to %s", b.Behavior.Destination, b.FirstSeenTime, b.LastSeenTime)
+		}
+	}
+	if runs := report.Runs; runs[1].Added != 1 || runs[1].Removed != 1 || runs[2].Added != 2 || runs[2].Removed != 2 {
+		t.Errorf("Runs = %+v, want 1 added and 1 removed, then 2 and 2", runs)
+	}
+
+	md := report.Markdown(ReportOptions{})
+	for _, want := range []string{
+		"| First seen | Last seen |",
+		"`░█░` | #2 (Mon Jul  3 09:00:00 UTC 2023) | #2 (Mon Jul  3 09:00:00 UTC 2023) | 33% | ⚠️ nondeterministic |",
+		"`█░█` | #1 (Mon Jul  3 08:00:00 UTC 2023) | #3 (Mon Jul  3 10:00:00 UTC 2023) | 67% | ⚠️ nondeterministic |",
+		"`██░` | #1 (Mon Jul  3 08:00:00 UTC 2023) | #2 (Mon Jul  3 09:00:00 UTC 2023) | 67% |  |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() does not contain %q:\n%s", want, md)
+		}
+	}
+}
@@ -3,6 +3,126 @@
 
 package visualisation
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the layout karmor uses for UpdatedTime, eg.: Mon Jul  3 08:26:40 UTC 2023
+const TimeLayout = time.UnixDate
+
+// Count is the number of times a behavior was observed.
+// karmor serialises it as a decimal string, eg.: "Count": "3"
+type Count int64
+
+// asciiSpace is the white space trimmed from counts and times, the [[:space:]] of the schema patterns
+const asciiSpace = " \t\n\v\f\r"
+
+// ParseCount parses a karmor count string
+func ParseCount(s string) (Count, error) {
+	s = strings.Trim(s, asciiSpace)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid Count %q: must be a non-negative integer", s)
+	}
+	return Count(n), nil
+}
+
+// String returns the count as a decimal string
+func (c Count) String() string {
+	return strconv.FormatInt(int64(c), 10)
+}
+
+// MarshalJSON encodes the count as a decimal string, the same way karmor does
+func (c Count) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON decodes the count from a decimal string or a JSON number
+func (c *Count) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, "\"") {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	n, err := ParseCount(s)
+	if err != nil {
+		return err
+	}
+	*c = n
+	return nil
+}
+
+// Timestamp is the last time a behavior was observed.
+// karmor serialises it in TimeLayout, eg.: "UpdatedTime": "Mon Jul  3 08:26:40 UTC 2023"
+type Timestamp struct {
+	time.Time
+}
+
+// ParseTimestamp parses a karmor timestamp string, RFC 3339 is accepted as well
+func ParseTimestamp(s string) (Timestamp, error) {
+	s = strings.Trim(s, asciiSpace)
+	if s == "" {
+		return Timestamp{}, nil
+	}
+	t, err := time.Parse(TimeLayout, s)
+	if err != nil {
+		var err2 error
+		t, err2 = time.Parse(time.RFC3339, s)
+		if err2 != nil {
+			return Timestamp{}, fmt.Errorf("invalid UpdatedTime %q: expected layout %q", s, TimeLayout)
+		}
+	}
+	return Timestamp{Time: t}, nil
+}
+
+// String returns the timestamp in TimeLayout in UTC, or an empty string if it is not set.
+// The times of other zones are converted, their numeric zone, eg.: +0200, could not be parsed back.
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(TimeLayout)
+}
+
+// MarshalJSON encodes the timestamp in TimeLayout in UTC, the same way karmor does, or null if it is not
+// set: omitempty never omits a struct, and an empty string is not a time
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes the timestamp from a TimeLayout or RFC 3339 string
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid UpdatedTime %s: must be a string", data)
+	}
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = ts
+	return nil
+}
+
 // SummaryData Structure
 type SummaryData struct {
 	DeploymentName    string              `json:"DeploymentName"`
@@ -10,52 +130,181 @@ type SummaryData struct {
 	ClusterName       string              `json:"ClusterName"`
 	Namespace         string              `json:"Namespace"`
 	Label             string              `json:"Label"`
+	ContainerName     string              `json:"ContainerName,omitempty"`
+	ContainerImage    string              `json:"ContainerImage,omitempty"`
 	ProcessData       []ProcessData       `json:"ProcessData,omitempty"`
 	FileData          []FileData          `json:"FileData,omitempty"`
 	IngressConnection []IngressConnection `json:"IngressConnection,omitempty"`
 	EgressConnection  []EgressConnection  `json:"EgressConnection,omitempty"`
+	BindConnection    []BindConnection    `json:"BindConnection,omitempty"`
+	// NormalizedBy is the comma separated names of the normalization rules that rewrote the pod
+	// or deployment name
+	NormalizedBy string `json:"NormalizedBy,omitempty"`
+	// Extra holds the discovery-engine fields that are not modelled above,
+	// they are kept as is so that a parsed summary can be written back without loss
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// droppedContainerName and recoveredProcess are set when the container name held a leaked
+	// record instead of a name, and when it was a process recovered into ProcessData
+	droppedContainerName bool
+	recoveredProcess     bool
+}
+
+// summaryData has the fields of SummaryData without its (un)marshalling methods
+type summaryData SummaryData
+
+// summaryDataFields are the JSON field names of SummaryData
+var summaryDataFields = jsonFieldNames(summaryData{})
+
+// UnmarshalJSON decodes the summary data, sanitizes the container fields
+// and keeps unknown fields in Extra
+func (sd *SummaryData) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*summaryData)(sd)); err != nil {
+		return err
+	}
+	sd.Extra = nil
+	for k, v := range raw {
+		if _, ok := summaryDataFields[k]; ok {
+			continue
+		}
+		if sd.Extra == nil {
+			sd.Extra = make(map[string]json.RawMessage)
+		}
+		sd.Extra[k] = v
+	}
+	name := sd.ContainerName
+	ps, recovered := RecoverLeakedProcess(name)
+	if recovered {
+		sd.addProcess(ps)
+	}
+	sd.ContainerName = SanitizeContainerName(name)
+	sd.ContainerImage = SanitizeString(sd.ContainerImage)
+	sd.droppedContainerName = strings.TrimSpace(name) != "" && sd.ContainerName == ""
+	sd.recoveredProcess = recovered
+	return nil
+}
+
+// addProcess adds the process entry, unless an entry of the same execution and status is listed
+func (sd *SummaryData) addProcess(ps ProcessData) {
+	for _, p := range sd.ProcessData {
+		if p.Source == ps.Source && p.Destination == ps.Destination && p.Status == ps.Status {
+			return
+		}
+	}
+	sd.ProcessData = append(sd.ProcessData, ps)
+}
+
+// MarshalJSON encodes the summary data together with the fields kept in Extra
+func (sd SummaryData) MarshalJSON() ([]byte, error) {
+	data, err := marshalUnescaped(summaryData(sd))
+	if err != nil || len(sd.Extra) == 0 {
+		return data, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for k, v := range sd.Extra {
+		if _, ok := raw[k]; !ok {
+			raw[k] = v
+		}
+	}
+	return marshalUnescaped(raw)
+}
+
+// marshalUnescaped marshals v without escaping <, > and &, so that the
+// placeholders of the normalized values, eg.: <pid>, stay readable
+func marshalUnescaped(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonFieldNames returns the JSON field names of a struct value
+func jsonFieldNames(v interface{}) map[string]struct{} {
+	fields := make(map[string]struct{})
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" || t.Field(i).PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields[name] = struct{}{}
+	}
+	return fields
 }
 
 // ProcessData Structure
 type ProcessData struct {
-	Source      string `json:"Source,omitempty"`
-	Destination string `json:"Destination,omitempty"`
-	Count       string `json:"Count,omitempty"`
-	UpdatedTime string `json:"UpdatedTime,omitempty"`
-	Status      string `json:"Status,omitempty"`
+	Source      string    `json:"Source,omitempty"`
+	Destination string    `json:"Destination,omitempty"`
+	Count       Count     `json:"Count"`
+	UpdatedTime Timestamp `json:"UpdatedTime"`
+	Status      string    `json:"Status,omitempty"`
+	// NormalizedBy is the comma separated names of the normalization rules that rewrote the entry
+	NormalizedBy string `json:"NormalizedBy,omitempty"`
 }
 
 // FileData Structure
 type FileData struct {
-	Source      string `json:"Source,omitempty"`
-	Destination string `json:"Destination,omitempty"`
-	Count       string `json:"Count,omitempty"`
-	UpdatedTime string `json:"UpdatedTime,omitempty"`
-	Status      string `json:"Status,omitempty"`
+	Source      string    `json:"Source,omitempty"`
+	Destination string    `json:"Destination,omitempty"`
+	Count       Count     `json:"Count"`
+	UpdatedTime Timestamp `json:"UpdatedTime"`
+	Status      string    `json:"Status,omitempty"`
+	// NormalizedBy is the comma separated names of the normalization rules that rewrote the entry
+	NormalizedBy string `json:"NormalizedBy,omitempty"`
 }
 
 // IngressConnection Structure
 type IngressConnection struct {
-	Protocol    string `json:"Protocol,omitempty"`
-	Command     string `json:"Command,omitempty"`
-	IP          string `json:"IP,omitempty"`
-	Port        string `json:"Port,omitempty"`
-	Labels      string `json:"Labels,omitempty"`
-	Namespace   string `json:"Namespace,omitempty"`
-	Count       string `json:"Count,omitempty"`
-	UpdatedTime string `json:"UpdatedTime,omitempty"`
+	Protocol    string    `json:"Protocol,omitempty"`
+	Command     string    `json:"Command,omitempty"`
+	IP          string    `json:"IP,omitempty"`
+	Port        string    `json:"Port,omitempty"`
+	Labels      string    `json:"Labels,omitempty"`
+	Namespace   string    `json:"Namespace,omitempty"`
+	Count       Count     `json:"Count"`
+	UpdatedTime Timestamp `json:"UpdatedTime"`
+	// NormalizedBy is the comma separated names of the normalization rules that rewrote the entry
+	NormalizedBy string `json:"NormalizedBy,omitempty"`
 }
 
 // EgressConnection Structure
 type EgressConnection struct {
-	Protocol    string `json:"Protocol,omitempty"`
-	Command     string `json:"Command,omitempty"`
-	IP          string `json:"IP,omitempty"`
-	Port        string `json:"Port,omitempty"`
-	Labels      string `json:"Labels,omitempty"`
-	Namespace   string `json:"Namespace,omitempty"`
-	Count       string `json:"Count,omitempty"`
-	UpdatedTime string `json:"UpdatedTime,omitempty"`
+	Protocol    string    `json:"Protocol,omitempty"`
+	Command     string    `json:"Command,omitempty"`
+	IP          string    `json:"IP,omitempty"`
+	Port        string    `json:"Port,omitempty"`
+	Labels      string    `json:"Labels,omitempty"`
+	Namespace   string    `json:"Namespace,omitempty"`
+	Count       Count     `json:"Count"`
+	UpdatedTime Timestamp `json:"UpdatedTime"`
+	// NormalizedBy is the comma separated names of the normalization rules that rewrote the entry
+	NormalizedBy string `json:"NormalizedBy,omitempty"`
+}
+
+// BindConnection Structure
+type BindConnection struct {
+	Protocol    string    `json:"Protocol,omitempty"`
+	Command     string    `json:"Command,omitempty"`
+	BindPort    string    `json:"BindPort,omitempty"`
+	BindAddress string    `json:"BindAddress,omitempty"`
+	Count       Count     `json:"Count"`
+	UpdatedTime Timestamp `json:"UpdatedTime"`
+	// NormalizedBy is the comma separated names of the normalization rules that rewrote the entry
+	NormalizedBy string `json:"NormalizedBy,omitempty"`
 }
 
 // VisualSysData Structure
@@ -63,10 +312,40 @@ type VisualSysData struct {
 	Name        string                       `json:"Name"`
 	Namespace   string                       `json:"Namespace"`
 	AppName     string                       `json:"AppName"`
+	Deployment  string                       `json:"Deployment,omitempty"`
+	Pod         string                       `json:"Pod,omitempty"`
+	Container   string                       `json:"Container,omitempty"`
 	Labels      []string                     `json:"Labels,omitempty"`
+	Containers  []string                     `json:"Containers,omitempty"`
+	Statuses    StatusCounts                 `json:"Statuses"`
 	ProcessData map[string]map[string]string `json:"Process,omitempty"`
 	FileData    map[string]string            `json:"File,omitempty"`
 	NetworkData map[string]map[string]string `json:"Network,omitempty"`
+	// Findings are the sensitive behaviors, eg.: "high KA-FILE-001 Read of the shadow password file: /bin/cat -> /etc/shadow"
+	Findings []string `json:"Findings,omitempty"`
+
+	// findings are the findings behind Findings, to highlight their behaviors
+	findings []Finding
+	// files are the selected file accesses, kept to aggregate them
+	files []FileData
+	// fileStatuses are the statuses of the FileData entries
+	fileStatuses map[string]string
+}
+
+// NetworkEdge is a connection between two nodes of the network diff
+type NetworkEdge struct {
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+	Protocol    string `json:"Protocol"`
+	Port        string `json:"Port"`
+	// Count is the number of connections, of the new summary unless the connection was removed
+	Count Count `json:"Count,omitempty"`
+	// Change is added, removed or empty if the connection is in both summaries
+	Change string `json:"Change,omitempty"`
+	// Volume is VolumeSpike or VolumeDrop if the connection is in both summaries and its count
+	// changed beyond the thresholds, OldCount is the count of the old summary
+	Volume   string `json:"Volume,omitempty"`
+	OldCount Count  `json:"OldCount,omitempty"`
 }
 
 // VisualNetworkData Structure
@@ -85,4 +364,10 @@ type VisualNetworkData struct {
 		[pod/calico-node-ztkhd] -[#blue]-> [pod/sd-ran-consensus-2] : TCP/3550
 	*/
 	Connections []string // Array of: [source] -[#blue]-> [destination] : protocol/port
+	// Edges are the connections behind Connections, sorted, to render them in other formats
+	Edges []NetworkEdge
+	// Highlights are the nodes of the workloads selected by the filter
+	Highlights map[string]bool
+	// Findings are the peers of sensitive connections, eg.: the cloud metadata service
+	Findings map[string]bool
 }
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCountJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    Count
+		wantErr bool
+	}{
+		{data: `"3"`, want: 3},
+		{data: `" 42 "`, want: 42},
+		{data: `7`, want: 7},
+		{data: `"0"`, want: 0},
+		{data: `""`, want: 0},
+		{data: `null`, want: 0},
+		{data: `"-1"`, wantErr: true},
+		{data: `"three"`, wantErr: true},
+		{data: `1.5`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Count
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+	for c, want := range map[Count]string{0: `"0"`, 3: `"3"`} {
+		if data, _ := json.Marshal(c); string(data) != want {
+			t.Errorf("Marshal(%d) = %s, want %s", c, data, want)
+		}
+	}
+}
+
+func TestTimestampJSON(t *testing.T) {
+	at := time.Date(2023, 7, 3, 8, 26, 40, 0, time.UTC)
+	tests := []struct {
+		data    string
+		want    time.Time
+		wantErr bool
+	}{
+		{data: `"Mon Jul  3 08:26:40 UTC 2023"`, want: at},
+		{data: `"2023-07-03T08:26:40Z"`, want: at},
+		{data: `""`},
+		{data: `null`},
+		{data: `"03/07/2023"`, wantErr: true},
+		{data: `1688372800`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Timestamp
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+	offset := at.In(time.FixedZone("CEST", 2*60*60))
+	for ts, want := range map[Timestamp]string{{}: `null`, {Time: at}: `"Mon Jul  3 08:26:40 UTC 2023"`, {Time: offset}: `"Mon Jul  3 08:26:40 UTC 2023"`} {
+		if data, _ := json.Marshal(ts); string(data) != want {
+			t.Errorf("Marshal(%v) = %s, want %s", ts, data, want)
+		}
+	}
+
+	// an RFC 3339 time with an offset is written in UTC and read back
+	var ts Timestamp
+	if err := json.Unmarshal([]byte(`"2023-07-03T10:26:40+02:00"`), &ts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Timestamp
+	if err := json.Unmarshal(data, &again); err != nil || !again.Equal(at) {
+		t.Errorf("Unmarshal(%s) = %v, %v, want %v", data, again, err, at)
+	}
+}
+
+func TestSummaryDataRoundTrip(t *testing.T) {
+	input := `[{
+		"DeploymentName": "carts", "PodName": "carts-5df4cd65d5-x8kqz", "ClusterName": "default",
+		"Namespace": "sock-shop", "Label": "name=carts",
+		"ProcessData": [
+			{"Source": "/bin/sh", "Destination": "/bin/ls", "Count": "0", "Status": "Allow"},
+			{"Source": "/bin/sh", "Destination": "/bin/cat", "Count": "2", "UpdatedTime": "Mon Jul  3 08:26:40 UTC 2023", "Status": "Allow"}
+		],
+		"EgressConnection": [{"Protocol": "TCP", "Command": "/usr/bin/java", "IP": "svc/catalogue", "Port": "80"}]
+	}]`
+	sds, err := ReadSummaryData(bytes.NewReader([]byte(input)), "input")
+	if err != nil {
+		t.Fatalf("ReadSummaryData() error = %v", err)
+	}
+	data, err := json.Marshal(sds)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"Count":"0"`, `"UpdatedTime":null`} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Marshal() = %s, want %s", data, want)
+		}
+	}
+
+	// the output is valid and reads back as the input
+	violations, err := ValidateSummaryData(bytes.NewReader(data), "output")
+	if err != nil || len(violations) > 0 {
+		t.Errorf("ValidateSummaryData() = %v, %v, want no violation", violations, err)
+	}
+	again, err := ReadSummaryData(bytes.NewReader(data), "output")
+	if err != nil {
+		t.Fatalf("ReadSummaryData() error = %v", err)
+	}
+	if !reflect.DeepEqual(sds, again) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", again[0], sds[0])
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/kubearmor/kubearmor-action/common"
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
 	"github.com/kubearmor/kubearmor-action/utils"
 	exe "github.com/kubearmor/kubearmor-action/utils/exec"
 	osi "github.com/kubearmor/kubearmor-action/utils/os"
@@ -21,104 +23,203 @@ var (
 	PWD = common.GetWorkDir() + "/pkg/visualisation/"
 )
 
-// ParseSummaryData parses the summary data and returns a slice of SummaryData objects
-func ParseSummaryData(path string) []*SummaryData {
-	// data is a byte array that will hold the content of the JSON file
-	var data []byte
-
-	// err is an error variable that will store any error that occurs during the reading or parsing process
-	var err error
-
-	// ReadFile reads the file from the given address and returns it as a []byte array.
-	// It can handle both remote urls and local paths.
-	data, err = utils.ReadFile(path) // #nosec
-
-	// if err is not nil, it means there was an error during the reading process
-	if err != nil {
-		// print the error message and exit the program
-		klog.Errorf("Error: %v", err)
-		return nil
-	}
-
-	// summaryData is a slice of SummaryData objects that will hold the parsed JSON data
-	var summaryDatas []*SummaryData
-
-	// json.Unmarshal is a function that takes a JSON byte array and a pointer to a variable and parses the JSON data into that variable
-	err = json.Unmarshal(data, &summaryDatas)
-
-	// if err is not nil, it means there was an error during the parsing process
-	if err != nil {
-		// print the error message and exit the program
-		klog.Errorf("Error: %v", err)
-		return nil
-	}
-
-	// if err is nil, it means the parsing process was successful
-	return summaryDatas
-}
-
-// ParseSysData parses the summary data and returns a VisualSysData object
-func ParseSysData(summaryDatas []*SummaryData, appName string) *VisualSysData {
+// ParseSysData parses the summary data and returns a VisualSysData object,
+// only the workloads and behaviors selected by the filter are visualised
+func ParseSysData(summaryDatas []*SummaryData, f *filter.Filter) *VisualSysData {
 	if len(summaryDatas) == 0 {
 		return nil
 	}
 	vs := &VisualSysData{
 		Name:      "sys-" + utils.GetUUID(),
 		Namespace: summaryDatas[0].Namespace,
+		AppName:   f.App(),
 		Labels:    make([]string, 0),
 	}
 	vs.ProcessData = make(map[string]map[string]string)
 	vs.FileData = make(map[string]string)
 	vs.NetworkData = make(map[string]map[string]string)
 	for _, sd := range summaryDatas {
-		// filter by appName
-		if appName != "" {
-			if !strings.Contains(sd.PodName, appName) {
-				continue
-			}
-			vs.AppName = appName
+		// filter by workload
+		if !f.MatchWorkload(filterWorkload(sd)) {
+			continue
 		}
 		getLabel(sd, vs)
+		getContainer(sd, vs)
 		// Get Processes Produced
-		handlePsfileSet(sd, vs, "Process")
+		handlePsfileSet(sd, vs, "Process", f)
 		// Get Files Accessed
-		handlePsfileSet(sd, vs, "File")
+		handlePsfileSet(sd, vs, "File", f)
 		// Get Network Data
-		handleNetworkSet(sd, vs)
+		handleNetworkSet(sd, vs, f)
+		// Classify the sensitive behaviors
+		handleFindings(sd, vs, f)
 	}
 	return vs
 }
 
+// filterWorkload returns the workload of the summary data the filter matches
+func filterWorkload(sd *SummaryData) filter.Workload {
+	return filter.Workload{
+		Namespace:  sd.Namespace,
+		Deployment: sd.DeploymentName,
+		Pod:        sd.PodName,
+		Container:  sd.ContainerName,
+		Labels:     sd.Label,
+	}
+}
+
+// peerWorkloadOf returns the filter workload of a connection peer, eg.: pod/wordpress-5df4cd65d5-l2zl2
+func peerWorkloadOf(ip, namespace, labels string) filter.Workload {
+	return filter.Workload{
+		Namespace: namespace,
+		Pod:       strings.TrimPrefix(ip, "pod/"),
+		Labels:    labels,
+	}
+}
+
 // getLabel gets the label from the summary data and appends it to the VisualSysData object
 func getLabel(summaryData *SummaryData, vs *VisualSysData) {
 	vs.Labels = append(vs.Labels, summaryData.Label)
 }
 
+// getContainer gets the container name from the summary data and appends it to the VisualSysData object
+func getContainer(summaryData *SummaryData, vs *VisualSysData) {
+	if summaryData.ContainerName == "" {
+		return
+	}
+	for _, c := range vs.Containers {
+		if c == summaryData.ContainerName {
+			return
+		}
+	}
+	vs.Containers = append(vs.Containers, summaryData.ContainerName)
+}
+
 // handlePsfileSet handles the process and file data and appends it to the VisualSysData object
-func handlePsfileSet(summaryData *SummaryData, vs *VisualSysData, kind string) {
+func handlePsfileSet(summaryData *SummaryData, vs *VisualSysData, kind string, f *filter.Filter) {
 	if kind == "Process" {
 		for _, ps := range summaryData.ProcessData {
+			status := NormalizeStatus(ps.Status)
+			if !f.MatchExec(ps.Source, ps.Destination) || !f.MatchStatus(status) {
+				continue
+			}
 			if _, ok := vs.ProcessData[ps.Source]; !ok {
 				vs.ProcessData[ps.Source] = make(map[string]string)
 			}
-			vs.ProcessData[ps.Source][ps.Destination] = "o"
+			vs.ProcessData[ps.Source][ps.Destination] = worseStatus(vs.ProcessData[ps.Source][ps.Destination], status)
+			vs.Statuses.Add(status, ps.Count)
 		}
 	} else if kind == "File" {
 		for _, file := range summaryData.FileData {
-			vs.FileData[file.Destination] = "o"
+			status := NormalizeStatus(file.Status)
+			if !f.MatchFileAccess(file.Source, file.Destination) || !f.MatchStatus(status) {
+				continue
+			}
+			if vs.fileStatuses == nil {
+				vs.fileStatuses = make(map[string]string)
+			}
+			vs.files = append(vs.files, file)
+			vs.fileStatuses[file.Destination] = worseStatus(vs.fileStatuses[file.Destination], status)
+			vs.FileData[file.Destination] = vs.fileStatuses[file.Destination]
+			vs.Statuses.Add(status, file.Count)
+		}
+	}
+}
+
+// fileEntry returns the FileData entry of a file, which is the file itself or
+// the collapsed directory holding it, eg.: /usr/share/zoneinfo/**
+func (vs *VisualSysData) fileEntry(file string) (string, bool) {
+	if _, ok := vs.FileData[file]; ok {
+		return file, true
+	}
+	file = strings.TrimSpace(file)
+	for entry := range vs.FileData {
+		dir := strings.TrimSuffix(entry, "**")
+		if dir != entry && strings.HasPrefix(file, dir) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// dedupeSorted sorts the strings and removes the duplicates
+func dedupeSorted(ss []string) []string {
+	sort.Strings(ss)
+	out := ss[:0]
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// AggregateFiles replaces the accessed files of the VisualSysData object with the leaves of
+// their directory tree collapsed with the options, each with its number of files and accesses
+func (vs *VisualSysData) AggregateFiles(opts FileTreeOptions) error {
+	if opts.IsZero() {
+		return nil
+	}
+	tree, err := BuildFileTree(vs.files, nil, opts)
+	if err != nil {
+		return err
+	}
+	vs.FileData = make(map[string]string)
+	vs.fileStatuses = make(map[string]string)
+	for _, leaf := range tree.Leaves() {
+		if leaf == tree {
+			continue
+		}
+		p := leaf.Path
+		if leaf.Collapsed && !strings.HasSuffix(p, "/**") {
+			p += "/**"
+		}
+		vs.FileData[p] = leaf.Label()
+		vs.fileStatuses[p] = leaf.Status
+	}
+	return nil
+}
+
+// handleFindings classifies the behaviors with the built-in rule pack and appends the findings to the VisualSysData object
+func handleFindings(summaryData *SummaryData, vs *VisualSysData, f *filter.Filter) {
+	for _, b := range BehaviorsOf(summaryData, f) {
+		for _, finding := range BuiltinClassifier().Classify(b) {
+			s := finding.String()
+			found := false
+			for _, existing := range vs.Findings {
+				if existing == s {
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+			vs.Findings = append(vs.Findings, s)
+			vs.findings = append(vs.findings, finding)
 		}
 	}
 }
 
 // handleNetworkSet handles the network data and appends it to the VisualSysData object
-func handleNetworkSet(summaryData *SummaryData, vs *VisualSysData) {
+func handleNetworkSet(summaryData *SummaryData, vs *VisualSysData, f *filter.Filter) {
+	// connections carry no status, they were allowed
+	if !f.MatchStatus(StatusAllow) {
+		return
+	}
 	for _, net := range summaryData.IngressConnection {
+		if !f.MatchProcess(net.Command) {
+			continue
+		}
 		if _, ok := vs.NetworkData[net.Protocol]; !ok {
 			vs.NetworkData[net.Protocol] = make(map[string]string)
 		}
 		vs.NetworkData[net.Protocol][net.Command] = "o"
 	}
 	for _, net := range summaryData.EgressConnection {
+		if !f.MatchProcess(net.Command) {
+			continue
+		}
 		if _, ok := vs.NetworkData[net.Protocol]; !ok {
 			vs.NetworkData[net.Protocol] = make(map[string]string)
 		}
@@ -127,7 +228,7 @@ func handleNetworkSet(summaryData *SummaryData, vs *VisualSysData) {
 }
 
 // ConvertVndToPlantUML Convert a VisualNetworkData object to a PlantUML format and save it in net.puml
-func ConvertVndToPlantUML(vnd *VisualNetworkData, appName string) error {
+func ConvertVndToPlantUML(vnd *VisualNetworkData) error {
 	// Create a file named net.puml
 	file, err := os.Create(PWD + "net.puml") // #nosec
 	if err != nil {
@@ -149,7 +250,9 @@ func ConvertVndToPlantUML(vnd *VisualNetworkData, appName string) error {
 		}
 		for _, ip := range ips {
 			color := "Lightblue"
-			if strings.Contains(ip, appName) {
+			if vnd.Findings[ip] {
+				color = "Red"
+			} else if vnd.Highlights[ip] {
 				color = "Orange"
 			}
 			_, err = file.WriteString(fmt.Sprintf("[%s] #%s\n", ip, color))
@@ -191,10 +294,24 @@ type connectionValue struct {
 	edgeColor string
 	// -1: deleted, 0: no change, 1: added
 	kind int
+	// count is the number of connections, summed over the entries
+	count Count
+	// volume is VolumeSpike or VolumeDrop if the count of an unchanged connection changed
+	// beyond the thresholds, oldCount the count of the old summary
+	volume   string
+	oldCount Count
 }
 
-// ParseNetworkData parses the summary data and returns a VisualNetworkData object
-func ParseNetworkData(sdOlds, sdNews []*SummaryData, appName string) *VisualNetworkData {
+// ParseNetworkData parses the summary data and returns a VisualNetworkData object,
+// only the connections of the workloads and processes selected by the filter are visualised.
+// The volume changes are classified with the default thresholds, see DiffOptions.ParseNetworkData.
+func ParseNetworkData(sdOlds, sdNews []*SummaryData, f *filter.Filter) *VisualNetworkData {
+	return DiffOptions{}.ParseNetworkData(sdOlds, sdNews, f)
+}
+
+// ParseNetworkData parses the summary data and returns a VisualNetworkData object, the connections
+// in both summaries whose count spiked or dropped beyond the volume thresholds are marked
+func (o DiffOptions) ParseNetworkData(sdOlds, sdNews []*SummaryData, f *filter.Filter) *VisualNetworkData {
 	if len(sdNews) == 0 {
 		return nil
 	}
@@ -207,40 +324,52 @@ func ParseNetworkData(sdOlds, sdNews []*SummaryData, appName string) *VisualNetw
 
 	vn := &VisualNetworkData{}
 	vn.NsIps = make(map[string][]string)
+	vn.Highlights = make(map[string]bool)
+	vn.Findings = make(map[string]bool)
 	for _, sdOld := range sdOlds {
 		// Get Namespace Labels
 		getNsIps(sdOld, nsipsOld)
 		// Get Different Network Connections
-		getDiffConnectionData(sdOld, cdsOld, appName)
+		getDiffConnectionData(sdOld, cdsOld, f, vn.Highlights)
 	}
 
 	for _, sdNew := range sdNews {
 		// Get Namespace Labels
 		getNsIps(sdNew, nsipsNew)
 		// Get Different Network Connections
-		getDiffConnectionData(sdNew, cdsNew, appName)
+		getDiffConnectionData(sdNew, cdsNew, f, vn.Highlights)
+		// Get the peers of sensitive connections
+		for _, b := range BehaviorsOf(sdNew, f) {
+			if (b.Kind == KindEgress || b.Kind == KindIngress) && len(BuiltinClassifier().Classify(b)) > 0 {
+				vn.Findings[b.Destination] = true
+			}
+		}
 	}
 
 	// merge the connections
 	for k, v := range cdsNew {
 		// fmt.Println(k, v)
-		if _, ok := cdsOld[k]; ok {
-			// if exists in both, means unchanged
-			cdsMerged[k] = connectionValue{edgeColor: v.edgeColor, kind: 0}
+		if old, ok := cdsOld[k]; ok {
+			// if exists in both, means unchanged, but its volume may have changed
+			cv := connectionValue{edgeColor: v.edgeColor, kind: 0, count: v.count, oldCount: old.count}
+			if cv.volume = o.Volume.Classify(old.count, v.count); cv.volume != "" {
+				cv.edgeColor = volumeColors[cv.volume]
+			}
+			cdsMerged[k] = cv
 		} else {
 			// if exists in new, means added
-			cdsMerged[k] = connectionValue{edgeColor: "red", kind: 1}
+			cdsMerged[k] = connectionValue{edgeColor: "red", kind: 1, count: v.count}
 		}
 		// add ips to the ips map, to filter nsips
 		ips[k.src] = true
 		ips[k.dst] = true
 	}
 	// check for deleted connections
-	for k := range cdsOld {
+	for k, v := range cdsOld {
 		// fmt.Println(k, v)
 		if _, ok := cdsNew[k]; !ok {
 			// if exists in old, but not in new, means deleted
-			cdsMerged[k] = connectionValue{edgeColor: "red", kind: -1}
+			cdsMerged[k] = connectionValue{edgeColor: "red", kind: -1, count: v.count}
 		}
 		// add ips to the ips map, to filter nsips
 		ips[k.src] = true
@@ -248,8 +377,13 @@ func ParseNetworkData(sdOlds, sdNews []*SummaryData, appName string) *VisualNetw
 	}
 	// write the connections to the vn
 	for k, v := range cdsMerged {
-		// unchanged
-		if v.kind == 0 {
+		vn.Edges = append(vn.Edges, NetworkEdge{Source: k.src, Destination: k.dst, Protocol: k.protocol, Port: k.port, Count: v.count,
+			Change: edgeChanges[v.kind], Volume: v.volume, OldCount: v.oldCount})
+		// unchanged, the volume changes are bold
+		if v.kind == 0 && v.volume != "" {
+			edge := fmt.Sprintf("[%s] -[#%s,bold]-> [%s] : %s/%s%s\n", k.src, v.edgeColor, k.dst, k.protocol, k.port, volumeLabel(v.volume, v.oldCount, v.count))
+			vn.Connections = append(vn.Connections, edge)
+		} else if v.kind == 0 {
 			edge := fmt.Sprintf("[%s] -[#%s]-> [%s] : %s/%s\n", k.src, v.edgeColor, k.dst, k.protocol, k.port)
 			vn.Connections = append(vn.Connections, edge)
 		}
@@ -264,24 +398,109 @@ func ParseNetworkData(sdOlds, sdNews []*SummaryData, appName string) *VisualNetw
 			vn.Connections = append(vn.Connections, edge)
 		}
 	}
-	// filter nsips by ips
-	for ns, ipss := range nsipsOld {
-		for _, ip := range ipss {
-			if _, ok := ips[ip]; ok {
-				vn.NsIps[ns] = append(vn.NsIps[ns], ip)
+	sort.Slice(vn.Edges, func(i, j int) bool { return lessEdge(vn.Edges[i], vn.Edges[j]) })
+	// filter nsips by ips, the pods in both summaries are listed once
+	for _, nsips := range []map[string][]string{nsipsOld, nsipsNew} {
+		for ns, ipss := range nsips {
+			for _, ip := range ipss {
+				if ips[ip] {
+					vn.NsIps[ns] = append(vn.NsIps[ns], ip)
+					delete(ips, ip)
+				}
 			}
 		}
 	}
-	for ns, ipss := range nsipsNew {
-		for _, ip := range ipss {
-			if _, ok := ips[ip]; ok {
-				vn.NsIps[ns] = append(vn.NsIps[ns], ip)
+	return vn
+}
+
+// edgeChanges are the changes of the connection kinds
+var edgeChanges = map[int]string{-1: "removed", 0: "", 1: "added"}
+
+// ParseNetworkSnapshot parses the connections of a single summary into a VisualNetworkData object,
+// without changes: the connections are colored by protocol and labeled with their counts, and the
+// pods and the peers of known namespaces are grouped by namespace
+func ParseNetworkSnapshot(sds []*SummaryData, f *filter.Filter) *VisualNetworkData {
+	if len(sds) == 0 {
+		return nil
+	}
+	nsips := make(map[string][]string)
+	cds := make(map[connectionKey]connectionValue)
+	vn := &VisualNetworkData{
+		NsIps:      make(map[string][]string),
+		Highlights: make(map[string]bool),
+		Findings:   make(map[string]bool),
+	}
+	for _, sd := range sds {
+		getNsIps(sd, nsips)
+		getPeerNsIps(sd, nsips)
+		getDiffConnectionData(sd, cds, f, vn.Highlights)
+		for _, b := range BehaviorsOf(sd, f) {
+			if (b.Kind == KindEgress || b.Kind == KindIngress) && len(BuiltinClassifier().Classify(b)) > 0 {
+				vn.Findings[b.Destination] = true
+			}
+		}
+	}
+
+	ips := make(map[string]bool)
+	for k, v := range cds {
+		vn.Edges = append(vn.Edges, NetworkEdge{Source: k.src, Destination: k.dst, Protocol: k.protocol, Port: k.port, Count: v.count})
+		ips[k.src] = true
+		ips[k.dst] = true
+	}
+	sort.Slice(vn.Edges, func(i, j int) bool { return lessEdge(vn.Edges[i], vn.Edges[j]) })
+	for _, e := range vn.Edges {
+		label := e.Protocol + "/" + e.Port
+		if e.Count > 0 {
+			label += fmt.Sprintf(" (%d)", e.Count)
+		}
+		vn.Connections = append(vn.Connections, fmt.Sprintf("[%s] -[#%s]-> [%s] : %s\n", e.Source, getEdgeColor(e.Protocol), e.Destination, label))
+	}
+	// only the nodes with connections are shown
+	for ns, nodes := range nsips {
+		for _, node := range nodes {
+			if ips[node] {
+				vn.NsIps[ns] = append(vn.NsIps[ns], node)
 			}
 		}
 	}
 	return vn
 }
 
+// getPeerNsIps adds the connection peers whose namespace is known to their namespace
+func getPeerNsIps(sd *SummaryData, nsips map[string][]string) {
+	add := func(ns, ip string) {
+		if ns == "" || ip == "" || ip == common.LOCALHOST {
+			return
+		}
+		for _, node := range nsips[ns] {
+			if node == ip {
+				return
+			}
+		}
+		nsips[ns] = append(nsips[ns], ip)
+	}
+	for _, net := range sd.IngressConnection {
+		add(net.Namespace, net.IP)
+	}
+	for _, net := range sd.EgressConnection {
+		add(net.Namespace, net.IP)
+	}
+}
+
+// lessEdge orders connections by source, destination, protocol and port
+func lessEdge(a, b NetworkEdge) bool {
+	if a.Source != b.Source {
+		return a.Source < b.Source
+	}
+	if a.Destination != b.Destination {
+		return a.Destination < b.Destination
+	}
+	if a.Protocol != b.Protocol {
+		return a.Protocol < b.Protocol
+	}
+	return a.Port < b.Port
+}
+
 func getNsIps(summaryData *SummaryData, nsips map[string][]string) {
 	if summaryData.PodName == "" {
 		return
@@ -315,10 +534,18 @@ func getEdgeColor(protocol string) string {
 	}
 }
 
-func getDiffConnectionData(sd *SummaryData, cds map[connectionKey]connectionValue, appName string) {
+// getDiffConnectionData collects the connections of the summary data selected by the filter,
+// a connection is selected if its own workload or its peer is selected, the nodes of the
+// selected workloads are recorded in highlights
+func getDiffConnectionData(sd *SummaryData, cds map[connectionKey]connectionValue, f *filter.Filter, highlights map[string]bool) {
 	if sd.PodName == "" {
 		return
 	}
+	// connections carry no status, they were allowed
+	if !f.MatchStatus(StatusAllow) {
+		return
+	}
+	own := f.MatchWorkload(filterWorkload(sd))
 
 	for _, net := range sd.IngressConnection {
 		if net.IP == "" || net.IP == common.LOCALHOST {
@@ -328,10 +555,14 @@ func getDiffConnectionData(sd *SummaryData, cds map[connectionKey]connectionValu
 		src := net.IP
 		ck := connectionKey{src: src, dst: dst, protocol: net.Protocol, port: net.Port}
 		cv := connectionValue{edgeColor: getEdgeColor(net.Protocol), kind: 0}
-		// filter by appName
-		if appName != "" && !strings.Contains(src, appName) && !strings.Contains(dst, appName) {
+		// filter by workload and process
+		peer := f.MatchWorkload(peerWorkloadOf(net.IP, net.Namespace, net.Labels))
+		if (!own && !peer) || !f.MatchProcess(net.Command) {
 			continue
 		}
+		highlights[dst] = highlights[dst] || own
+		highlights[src] = highlights[src] || peer
+		cv.count = cds[ck].count + net.Count
 		cds[ck] = cv
 	}
 	for _, net := range sd.EgressConnection {
@@ -342,73 +573,151 @@ func getDiffConnectionData(sd *SummaryData, cds map[connectionKey]connectionValu
 		src := "pod/" + sd.PodName
 		ck := connectionKey{src: src, dst: dst, protocol: net.Protocol, port: net.Port}
 		cv := connectionValue{edgeColor: getEdgeColor(net.Protocol), kind: 0}
-		// filter by appName
-		if appName != "" && !strings.Contains(src, appName) && !strings.Contains(dst, appName) {
+		// filter by workload and process
+		peer := f.MatchWorkload(peerWorkloadOf(net.IP, net.Namespace, net.Labels))
+		if (!own && !peer) || !f.MatchProcess(net.Command) {
 			continue
 		}
+		highlights[src] = highlights[src] || own
+		highlights[dst] = highlights[dst] || peer
+		cv.count = cds[ck].count + net.Count
 		cds[ck] = cv
 	}
 }
 
-// ConvertSysJSONToImage converts the summary system JSON data to a plantuml image
-func ConvertSysJSONToImage(jsonFile string, output string, appName string) error {
+// checkDependencies checks that java and plantuml.jar are installed
+func checkDependencies() error {
 	klog.Infoln("Cheking Dependencies...")
 	// Check if java is installed
 	_, b := exe.CheckCmdIsExist("java")
 	if !b {
-		return fmt.Errorf("Error: java not installed")
+		return fmt.Errorf("java not installed")
 	}
 	// Check if plantuml.jar is installed
 	b = osi.IsFileExist(PWD + "/plantuml.jar")
 	if !b {
-		return fmt.Errorf("Error: plantuml.jar not installed")
-	}
-
-	// get summary data from json file
-	klog.Infoln("Parsing Summary Data...")
-	sd := ParseSummaryData(jsonFile)
-	if sd == nil {
-		return fmt.Errorf("Error: SummaryData is nil")
+		return fmt.Errorf("plantuml.jar not installed")
 	}
+	return nil
+}
 
-	// parse visual sys data from summary data
-	klog.Infoln("Parsing Visual System Data...")
-	vsd := ParseSysData(sd, appName)
-	if vsd == nil {
-		return fmt.Errorf("Error: VisualSysData is nil")
-	}
+// convertVsdToPlantUML converts a VisualSysData object to a PlantUML json diagram,
+// the audited and blocked processes and files are highlighted with their status color,
+// the other sensitive behaviors in orange
+func convertVsdToPlantUML(vsd *VisualSysData) ([]byte, error) {
 	jsonData, err := json.MarshalIndent(vsd, "", "    ")
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var sb strings.Builder
+	sb.WriteString("@startjson\n")
+	sb.WriteString("<style>\n")
+	for _, status := range []string{StatusAudit, StatusBlock} {
+		sb.WriteString(fmt.Sprintf(".%s {\n  BackGroundColor %s\n}\n", status, statusColors[status]))
+	}
+	sb.WriteString(".Finding {\n  BackGroundColor Orange\n}\n")
+	sb.WriteString("</style>\n")
+	var highlights []string
+	for src, dsts := range vsd.ProcessData {
+		for dst, status := range dsts {
+			if statusColors[status] != "" {
+				highlights = append(highlights, fmt.Sprintf("#highlight \"Process\" / %q / %q <<%s>>\n", src, dst, status))
+			}
+		}
 	}
-	// fmt.Printf("JSON: %+v", string(jsonData))
-	start := []byte("@startjson\n")
-	end := []byte("\n@endjson")
-	sysPuml := append(start, jsonData...)
-	sysPuml = append(sysPuml, end...)
-	// fmt.Printf("%+v\n", string(sysPuml))
+	for file := range vsd.FileData {
+		if status := vsd.fileStatuses[file]; statusColors[status] != "" {
+			highlights = append(highlights, fmt.Sprintf("#highlight \"File\" / %q <<%s>>\n", file, status))
+		}
+	}
+	// the sensitive behaviors that were not blocked nor audited
+	for _, finding := range vsd.findings {
+		b := finding.Behavior
+		switch b.Kind {
+		case KindProcess:
+			if status, ok := vsd.ProcessData[b.Source][b.Destination]; ok && statusColors[status] == "" {
+				highlights = append(highlights, fmt.Sprintf("#highlight \"Process\" / %q / %q <<Finding>>\n", b.Source, b.Destination))
+			}
+		case KindFile:
+			if file, ok := vsd.fileEntry(b.Destination); ok && statusColors[vsd.fileStatuses[file]] == "" {
+				highlights = append(highlights, fmt.Sprintf("#highlight \"File\" / %q <<Finding>>\n", file))
+			}
+		default:
+			if _, ok := vsd.NetworkData[b.Protocol][b.Source]; ok {
+				highlights = append(highlights, fmt.Sprintf("#highlight \"Network\" / %q / %q <<Finding>>\n", b.Protocol, b.Source))
+			}
+		}
+	}
+	if len(vsd.Findings) > 0 {
+		highlights = append(highlights, "#highlight \"Findings\" <<Finding>>\n")
+	}
+	highlights = dedupeSorted(highlights)
+	sb.WriteString(strings.Join(highlights, ""))
+	sb.Write(jsonData)
+	sb.WriteString("\n@endjson")
+	return []byte(sb.String()), nil
+}
 
+// renderPlantUML writes the PlantUML diagram to name.puml, renders it to an image
+// and moves the image to output in the working directory
+func renderPlantUML(name string, puml []byte, output string) error {
 	klog.Infoln("Creating PlantUML File...")
 	// Create plantuml file
-	fw := osi.NewFileWriter(PWD + "/sys.puml")
-	err = fw.WriteFile(sysPuml)
+	fw := osi.NewFileWriter(PWD + "/" + name + ".puml")
+	err := fw.WriteFile(puml)
 	if err != nil {
 		return err
 	}
 
 	klog.Infoln("Creating Image...")
-	s, err := exe.RunSimpleCmd("java -jar -DPLANTUML_LIMIT_SIZE=100000 -Xmx8096m " + PWD + "./plantuml.jar " + PWD + "/sys.puml -output ./")
+	s, err := exe.RunSimpleCmd("java -jar -DPLANTUML_LIMIT_SIZE=100000 -Xmx8096m " + PWD + "./plantuml.jar " + PWD + "/" + name + ".puml -output ./")
 	klog.Infoln(s)
 	if err != nil {
 		return err
 	}
 
 	klog.Infoln("Removing PlantUML File...")
-	err = osi.RemoveFile(PWD + "/sys.puml")
+	err = osi.RemoveFile(PWD + "/" + name + ".puml")
 	if err != nil {
 		return err
 	}
-	_, err = exe.RunSimpleCmd("mv " + PWD + "/sys.png " + common.GetWorkDir() + "/" + output)
+	_, err = exe.RunSimpleCmd("mv " + PWD + "/" + name + ".png " + common.GetWorkDir() + "/" + output)
+	return err
+}
+
+// ConvertSysJSONToImage converts the summary system JSON data to a plantuml image,
+// the accessed files are aggregated into directories with the file tree options
+// The summary data is parsed with p before it is visualised, see ParseOptions.
+func ConvertSysJSONToImage(jsonFile string, output string, f *filter.Filter, p ParseOptions, files FileTreeOptions) error {
+	err := checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	// get summary data from json file
+	klog.Infoln("Parsing Summary Data...")
+	sd, err := p.ParseSummaryData(jsonFile)
+	if err != nil {
+		return err
+	}
+	logSubstitutions(p.Normalizer)
+
+	// parse visual sys data from summary data
+	klog.Infoln("Parsing Visual System Data...")
+	vsd := ParseSysData(sd, f)
+	if vsd == nil {
+		return fmt.Errorf("VisualSysData is nil")
+	}
+	err = vsd.AggregateFiles(files)
+	if err != nil {
+		return err
+	}
+	sysPuml, err := convertVsdToPlantUML(vsd)
+	if err != nil {
+		return err
+	}
+
+	err = renderPlantUML("sys", sysPuml, output)
 	if err != nil {
 		return err
 	}
@@ -417,43 +726,72 @@ func ConvertSysJSONToImage(jsonFile string, output string, appName string) error
 }
 
 // ConvertNetworkJSONToImage converts the summary network JSON data to a plantuml image
-func ConvertNetworkJSONToImage(jsonFileOld string, jsonFileNew string, output string, appName string) error {
-	klog.Info("Cheking Dependencies...")
-	// Check if java is installed
-	_, b := exe.CheckCmdIsExist("java")
-	if !b {
-		return fmt.Errorf("Error: java not installed")
-	}
-	// Check if plantuml.jar is installed
-	b = osi.IsFileExist(PWD + "/plantuml.jar")
-	if !b {
-		return fmt.Errorf("Error: plantuml.jar not installed")
+// The summary data is parsed with p before it is visualised, see ParseOptions.
+func ConvertNetworkJSONToImage(jsonFileOld string, jsonFileNew string, output string, f *filter.Filter, p ParseOptions) error {
+	return DiffOptions{}.ConvertNetworkJSONToImage(jsonFileOld, jsonFileNew, output, f, p)
+}
+
+// ConvertNetworkJSONToImage converts the summary network JSON data to a plantuml image, the
+// volume changes are classified with the thresholds of the options
+func (o DiffOptions) ConvertNetworkJSONToImage(jsonFileOld string, jsonFileNew string, output string, f *filter.Filter, p ParseOptions) error {
+	err := checkDependencies()
+	if err != nil {
+		return err
 	}
 
 	// get old summary data from old json file
 	klog.Infoln("Parsing Old Summary Data...")
-	sdOlds := ParseSummaryData(jsonFileOld)
-	if sdOlds == nil {
-		return fmt.Errorf("Error: Old SummaryData is nil")
+	sdOlds, err := p.Older().ParseSummaryData(jsonFileOld)
+	if err != nil {
+		return err
 	}
 
 	// get new summary data from new json file
 	klog.Infoln("Parsing New Summary Data...")
-	sdNews := ParseSummaryData(jsonFileNew)
-	if sdNews == nil {
-		return fmt.Errorf("Error: New SummaryData is nil")
+	sdNews, err := p.ParseSummaryData(jsonFileNew)
+	if err != nil {
+		return err
 	}
+	logSubstitutions(p.Normalizer)
 
 	// parse visual network connections data from summary data
 	klog.Infoln("Parsing Visual Network Connections Data...")
-	vnd := ParseNetworkData(sdOlds, sdNews, appName)
+	vnd := o.ParseNetworkData(sdOlds, sdNews, f)
+	if vnd == nil {
+		return fmt.Errorf("VisualNetworkData is nil")
+	}
+	return convertVndToImage(vnd, output)
+}
+
+// ConvertNetworkSnapshotToImage converts the network connections of a single summary to a plantuml
+// image, without changes, see ParseNetworkSnapshot.
+// The summary data is parsed with p before it is visualised, see ParseOptions.
+func ConvertNetworkSnapshotToImage(jsonFile string, output string, f *filter.Filter, p ParseOptions) error {
+	err := checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	klog.Infoln("Parsing Summary Data...")
+	sds, err := p.ParseSummaryData(jsonFile)
+	if err != nil {
+		return err
+	}
+	logSubstitutions(p.Normalizer)
+
+	klog.Infoln("Parsing Visual Network Connections Data...")
+	vnd := ParseNetworkSnapshot(sds, f)
 	if vnd == nil {
-		return fmt.Errorf("Error: VisualNetworkData is nil")
+		return fmt.Errorf("VisualNetworkData is nil")
 	}
+	return convertVndToImage(vnd, output)
+}
 
+// convertVndToImage renders the VisualNetworkData object to the output image
+func convertVndToImage(vnd *VisualNetworkData, output string) error {
 	// Create plantuml file
 	klog.Infoln("Creating PlantUML File...")
-	err := ConvertVndToPlantUML(vnd, appName)
+	err := ConvertVndToPlantUML(vnd)
 	if err != nil {
 		return err
 	}
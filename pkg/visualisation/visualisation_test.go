@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+)
+
+func TestParseNetworkSnapshot(t *testing.T) {
+	sds := []*SummaryData{
+		{
+			DeploymentName: "wordpress", PodName: "wordpress-1", Namespace: "wordpress-mysql", Label: "app=wordpress",
+			EgressConnection: []EgressConnection{
+				{Protocol: "TCP", Command: "/usr/sbin/apache2", IP: "pod/mysql-1", Port: "3306", Labels: "app=mysql", Namespace: "wordpress-mysql", Count: 3},
+				{Protocol: "UDP", Command: "/usr/sbin/apache2", IP: "10.96.0.10", Port: "53", Namespace: "kube-system", Count: 2},
+				{Protocol: "TCP", Command: "/usr/bin/curl", IP: "169.254.169.254", Port: "80", Count: 1},
+				{Protocol: "TCP", Command: "/usr/sbin/apache2", IP: "127.0.0.1", Port: "9000", Count: 5},
+			},
+		},
+		{
+			DeploymentName: "mysql", PodName: "mysql-1", Namespace: "wordpress-mysql", Label: "app=mysql",
+			IngressConnection: []IngressConnection{{Protocol: "TCP", Command: "/usr/sbin/mysqld", IP: "10.0.0.5", Port: "3306", Count: 1}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		opts        filter.Options
+		connections []string
+		nsIps       string
+		highlights  string
+		findings    string
+	}{
+		{
+			name: "all",
+			connections: []string{
+				"[10.0.0.5] -[#blue]-> [pod/mysql-1] : TCP/3306 (1)",
+				"[pod/wordpress-1] -[#green]-> [10.96.0.10] : UDP/53 (2)",
+				"[pod/wordpress-1] -[#blue]-> [169.254.169.254] : TCP/80 (1)",
+				"[pod/wordpress-1] -[#blue]-> [pod/mysql-1] : TCP/3306 (3)",
+			},
+			nsIps:      "kube-system: 10.96.0.10; wordpress-mysql: pod/wordpress-1, pod/mysql-1",
+			highlights: "10.0.0.5, 10.96.0.10, 169.254.169.254, pod/mysql-1, pod/wordpress-1",
+			findings:   "169.254.169.254",
+		},
+		{
+			name: "app and its peers",
+			opts: filter.Options{App: "mysql"},
+			connections: []string{
+				"[10.0.0.5] -[#blue]-> [pod/mysql-1] : TCP/3306 (1)",
+				"[pod/wordpress-1] -[#blue]-> [pod/mysql-1] : TCP/3306 (3)",
+			},
+			nsIps:      "wordpress-mysql: pod/wordpress-1, pod/mysql-1",
+			highlights: "pod/mysql-1",
+		},
+		{
+			name:  "connections are allowed",
+			opts:  filter.Options{Statuses: []string{"Block"}},
+			nsIps: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := filter.New(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vn := ParseNetworkSnapshot(sds, f)
+			var connections []string
+			for _, c := range vn.Connections {
+				connections = append(connections, strings.TrimSpace(c))
+			}
+			if strings.Join(connections, "\n") != strings.Join(tt.connections, "\n") {
+				t.Errorf("Connections =\n%s\nwant\n%s", strings.Join(connections, "\n"), strings.Join(tt.connections, "\n"))
+			}
+			nodes := make(map[string]bool)
+			for _, e := range vn.Edges {
+				if e.Change != "" {
+					t.Errorf("edge %+v has a change, want none in a snapshot", e)
+				}
+				nodes[e.Source], nodes[e.Destination] = true, true
+			}
+			var namespaces []string
+			for ns, ips := range vn.NsIps {
+				namespaces = append(namespaces, ns+": "+strings.Join(ips, ", "))
+			}
+			sort.Strings(namespaces)
+			if got := strings.Join(namespaces, "; "); got != tt.nsIps {
+				t.Errorf("NsIps = %q, want %q", got, tt.nsIps)
+			}
+			if got := trueKeys(vn.Highlights); got != tt.highlights {
+				t.Errorf("Highlights = %q, want %q", got, tt.highlights)
+			}
+			// the findings of the nodes that are not shown do not matter
+			findings := make(map[string]bool)
+			for node := range vn.Findings {
+				findings[node] = nodes[node]
+			}
+			if got := trueKeys(findings); got != tt.findings {
+				t.Errorf("Findings = %q, want %q", got, tt.findings)
+			}
+		})
+	}
+
+	if vn := ParseNetworkSnapshot(nil, nil); vn != nil {
+		t.Errorf("ParseNetworkSnapshot(nil) = %+v, want nil", vn)
+	}
+}
+
+// trueKeys returns the keys of a set that are true, sorted and comma separated
+func trueKeys(set map[string]bool) string {
+	var keys []string
+	for k, v := range set {
+		if v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"math"
+)
+
+// Volume changes of a behavior whose count changed beyond the thresholds
+const (
+	VolumeSpike = "spike"
+	VolumeDrop  = "drop"
+)
+
+// volumeColors are the edge colors of the volume changes
+var volumeColors = map[string]string{VolumeSpike: "red", VolumeDrop: "orange"}
+
+// volumeIcons mark the volume changes in the edge labels and the reports
+var volumeIcons = map[string]string{VolumeSpike: "▲", VolumeDrop: "▼"}
+
+// Defaults of the volume thresholds
+const (
+	// DefaultVolumeRatio is the factor a count must grow or shrink by to be a volume change
+	DefaultVolumeRatio = 10
+	// DefaultVolumeMinDelta is the difference of counts below which no volume change is reported
+	DefaultVolumeMinDelta = 100
+	// NoVolumeMinDelta disables the absolute threshold, only the ratio is checked
+	NoVolumeMinDelta Count = -1
+)
+
+// VolumeThresholds classify the count changes of the behaviors in both summaries: a count that
+// grows by Ratio or more and by MinDelta or more is a spike, one that shrinks as much is a drop.
+// Both thresholds must be met, so that 1 to 20 calls or 10000 to 10500 calls are not reported.
+type VolumeThresholds struct {
+	// Ratio is the relative threshold, DefaultVolumeRatio if 0, volume changes are not reported if negative
+	Ratio float64
+	// MinDelta is the absolute threshold, DefaultVolumeMinDelta if 0, disabled if negative, eg.: NoVolumeMinDelta
+	MinDelta Count
+}
+
+// NewVolumeThresholds returns the thresholds given by a user, where a minimum delta of 0 or
+// less disables the absolute threshold instead of selecting the default
+func NewVolumeThresholds(ratio float64, minDelta Count) VolumeThresholds {
+	if minDelta <= 0 {
+		minDelta = NoVolumeMinDelta
+	}
+	return VolumeThresholds{Ratio: ratio, MinDelta: minDelta}
+}
+
+// withDefaults returns the thresholds with the zero values set to the defaults
+func (t VolumeThresholds) withDefaults() VolumeThresholds {
+	if t.Ratio == 0 {
+		t.Ratio = DefaultVolumeRatio
+	}
+	switch {
+	case t.MinDelta == 0:
+		t.MinDelta = DefaultVolumeMinDelta
+	case t.MinDelta < 0:
+		t.MinDelta = 0
+	}
+	return t
+}
+
+// Classify returns VolumeSpike or VolumeDrop if the count changed beyond the thresholds, or an
+// empty string. Counts of 0 are unknown, they are never a volume change.
+func (t VolumeThresholds) Classify(old, new Count) string {
+	t = t.withDefaults()
+	if t.Ratio < 0 || old <= 0 || new <= 0 {
+		return ""
+	}
+	switch {
+	case float64(new) >= float64(old)*t.Ratio && new-old >= t.MinDelta:
+		return VolumeSpike
+	case float64(old) >= float64(new)*t.Ratio && old-new >= t.MinDelta:
+		return VolumeDrop
+	}
+	return ""
+}
+
+// VolumeChange is a behavior in both summaries whose count spiked or dropped
+type VolumeChange struct {
+	Behavior
+	OldCount Count `json:"OldCount"`
+	// Volume is VolumeSpike or VolumeDrop
+	Volume string `json:"Volume"`
+	// Ratio is the new count divided by the old one
+	Ratio float64 `json:"Ratio"`
+}
+
+// String returns the volume change as "spike 3 -> 30000 (x10000)" or "drop 500 -> 5 (/100)"
+func (v VolumeChange) String() string {
+	return fmt.Sprintf("%s %d -> %d (%s)", v.Volume, v.OldCount, v.Count, v.Factor())
+}
+
+// Factor returns the ratio of the counts as "x10000" for a spike or "/100" for a drop
+func (v VolumeChange) Factor() string {
+	if v.Ratio > 0 && v.Ratio < 1 {
+		return "/" + formatRatio(1/v.Ratio)
+	}
+	return "x" + formatRatio(v.Ratio)
+}
+
+// newVolumeChange returns the volume change of the behavior from the old count, nil if there is none
+func newVolumeChange(b Behavior, old Count, t VolumeThresholds) *VolumeChange {
+	volume := t.Classify(old, b.Count)
+	if volume == "" {
+		return nil
+	}
+	return &VolumeChange{Behavior: b, OldCount: old, Volume: volume, Ratio: float64(b.Count) / float64(old)}
+}
+
+// formatRatio formats a ratio rounded to an integer from 10, to 2 significant digits below, eg.: 2.5 or 10000
+func formatRatio(r float64) string {
+	if r >= 10 {
+		return fmt.Sprintf("%d", int64(math.Round(r)))
+	}
+	return fmt.Sprintf("%.2g", r)
+}
+
+// volumeLabel returns the edge label suffix of a volume change, eg.: " ▲3→30000"
+func volumeLabel(volume string, old, new Count) string {
+	if volume == "" {
+		return ""
+	}
+	return fmt.Sprintf(" %s%d→%d", volumeIcons[volume], old, new)
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import "testing"
+
+func TestVolumeThresholdsClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds VolumeThresholds
+		old, new   Count
+		want       string
+	}{
+		{name: "spike", old: 3, new: 30000, want: VolumeSpike},
+		{name: "drop", old: 5000, new: 5, want: VolumeDrop},
+		{name: "ratio not met", old: 10000, new: 10500},
+		{name: "delta not met", old: 1, new: 20},
+		{name: "unknown count", old: 0, new: 30000},
+		{name: "exact thresholds", old: 20, new: 200, want: VolumeSpike},
+		{name: "custom thresholds", thresholds: VolumeThresholds{Ratio: 2, MinDelta: 5}, old: 5, new: 10, want: VolumeSpike},
+		{name: "disabled", thresholds: VolumeThresholds{Ratio: -1}, old: 3, new: 30000},
+		{name: "no min delta", thresholds: VolumeThresholds{MinDelta: NoVolumeMinDelta}, old: 1, new: 20, want: VolumeSpike},
+		{name: "no min delta drop", thresholds: VolumeThresholds{MinDelta: NoVolumeMinDelta}, old: 20, new: 1, want: VolumeDrop},
+		{name: "no min delta ratio not met", thresholds: VolumeThresholds{MinDelta: NoVolumeMinDelta}, old: 2, new: 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.thresholds.Classify(tt.old, tt.new); got != tt.want {
+				t.Errorf("Classify(%d, %d) = %q, want %q", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVolumeThresholds(t *testing.T) {
+	tests := []struct {
+		ratio    float64
+		minDelta Count
+		want     VolumeThresholds
+	}{
+		{ratio: DefaultVolumeRatio, minDelta: DefaultVolumeMinDelta, want: VolumeThresholds{Ratio: DefaultVolumeRatio, MinDelta: DefaultVolumeMinDelta}},
+		{ratio: 5, minDelta: 0, want: VolumeThresholds{Ratio: 5, MinDelta: NoVolumeMinDelta}},
+		{ratio: -1, minDelta: -3, want: VolumeThresholds{Ratio: -1, MinDelta: NoVolumeMinDelta}},
+	}
+	for _, tt := range tests {
+		if got := NewVolumeThresholds(tt.ratio, tt.minDelta); got != tt.want {
+			t.Errorf("NewVolumeThresholds(%v, %d) = %+v, want %+v", tt.ratio, tt.minDelta, got, tt.want)
+		}
+	}
+	// 0 disables the threshold instead of selecting the default
+	if got := NewVolumeThresholds(DefaultVolumeRatio, 0).Classify(1, 20); got != VolumeSpike {
+		t.Errorf("Classify(1, 20) = %q, want %q", got, VolumeSpike)
+	}
+}
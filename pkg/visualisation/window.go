@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeBound is a bound of a time window, absolute or relative to the newest entry of the summary
+type TimeBound struct {
+	// Time is the absolute bound, if set
+	Time time.Time
+	// Before is how long before the newest entry of the summary the bound is, if Time is not set
+	Before time.Duration
+}
+
+// ParseTimeBound parses a time bound: a karmor or RFC 3339 time, or a duration before the newest
+// entry of the summary, eg.: 10m or -10m. It returns nil for an empty string.
+func ParseTimeBound(s string) (*TimeBound, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(s, "-")); err == nil {
+		if d < 0 {
+			return nil, fmt.Errorf("invalid time bound %q: the duration before the newest entry must be positive", s)
+		}
+		return &TimeBound{Before: d}, nil
+	}
+	t, err := ParseTimestamp(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time bound %q: must be a duration before the newest entry, eg.: 10m, "+
+			"or a time in RFC 3339 or in the karmor layout %q", s, TimeLayout)
+	}
+	return &TimeBound{Time: t.Time}, nil
+}
+
+// IsRelative reports whether the bound is relative to the newest entry of the summary
+func (b *TimeBound) IsRelative() bool {
+	return b != nil && b.Time.IsZero()
+}
+
+// String returns the bound as a time or as "newest-10m0s"
+func (b *TimeBound) String() string {
+	switch {
+	case b == nil:
+		return ""
+	case b.IsRelative():
+		return "newest-" + b.Before.String()
+	default:
+		return b.Time.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// resolve returns the time of the bound for the newest entry of a summary
+func (b *TimeBound) resolve(newest time.Time) time.Time {
+	if b.IsRelative() {
+		return newest.Add(-b.Before)
+	}
+	return b.Time
+}
+
+// TimeWindow selects the process, file and connection entries of the summaries by their
+// UpdatedTime, the bounds are inclusive and a nil bound is open. The entries without UpdatedTime
+// are kept, they cannot be placed in the window.
+type TimeWindow struct {
+	Since *TimeBound
+	Until *TimeBound
+}
+
+// IsZero reports whether the window is open on both ends, it selects every entry
+func (w TimeWindow) IsZero() bool {
+	return w.Since == nil && w.Until == nil
+}
+
+// IsRelative reports whether a bound of the window is relative to the newest entry
+func (w TimeWindow) IsRelative() bool {
+	return w.Since.IsRelative() || w.Until.IsRelative()
+}
+
+// Relative returns the window with its relative bounds only. The absolute bounds and the phases
+// are times of a single run, so only the relative bounds select the entries of the other runs,
+// eg.: of the baseline.
+func (w TimeWindow) Relative() TimeWindow {
+	if !w.Since.IsRelative() {
+		w.Since = nil
+	}
+	if !w.Until.IsRelative() {
+		w.Until = nil
+	}
+	return w
+}
+
+// String returns the window as "since .. until"
+func (w TimeWindow) String() string {
+	since, until := w.Since.String(), w.Until.String()
+	if since == "" {
+		since = "start"
+	}
+	if until == "" {
+		until = "end"
+	}
+	return since + " .. " + until
+}
+
+// Apply removes the entries of the summaries outside the window, the relative bounds are
+// resolved against the newest entry of all the summaries. It returns the summaries.
+func (w TimeWindow) Apply(summaryDatas []*SummaryData) []*SummaryData {
+	if w.IsZero() {
+		return summaryDatas
+	}
+	var newest Timestamp
+	if w.IsRelative() {
+		for _, sd := range summaryDatas {
+			newest = laterTimestamp(newest, latestUpdate(sd))
+		}
+	}
+	for _, sd := range summaryDatas {
+		w.apply(sd, newest.Time)
+	}
+	return summaryDatas
+}
+
+// apply removes the entries of sd outside the window, newest resolves the relative bounds
+func (w TimeWindow) apply(sd *SummaryData, newest time.Time) {
+	var since, until time.Time
+	if w.Since != nil {
+		since = w.Since.resolve(newest)
+	}
+	if w.Until != nil {
+		until = w.Until.resolve(newest)
+	}
+	in := func(t Timestamp) bool {
+		if t.IsZero() {
+			return true
+		}
+		return !(w.Since != nil && t.Before(since)) && !(w.Until != nil && t.After(until))
+	}
+
+	processes := sd.ProcessData[:0]
+	for _, ps := range sd.ProcessData {
+		if in(ps.UpdatedTime) {
+			processes = append(processes, ps)
+		}
+	}
+	sd.ProcessData = processes
+	files := sd.FileData[:0]
+	for _, file := range sd.FileData {
+		if in(file.UpdatedTime) {
+			files = append(files, file)
+		}
+	}
+	sd.FileData = files
+	ingress := sd.IngressConnection[:0]
+	for _, net := range sd.IngressConnection {
+		if in(net.UpdatedTime) {
+			ingress = append(ingress, net)
+		}
+	}
+	sd.IngressConnection = ingress
+	egress := sd.EgressConnection[:0]
+	for _, net := range sd.EgressConnection {
+		if in(net.UpdatedTime) {
+			egress = append(egress, net)
+		}
+	}
+	sd.EgressConnection = egress
+	binds := sd.BindConnection[:0]
+	for _, bind := range sd.BindConnection {
+		if in(bind.UpdatedTime) {
+			binds = append(binds, bind)
+		}
+	}
+	sd.BindConnection = binds
+}
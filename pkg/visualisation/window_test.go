@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	at := time.Date(2023, 7, 3, 8, 26, 40, 0, time.UTC)
+	tests := []struct {
+		s       string
+		want    *TimeBound
+		wantErr bool
+	}{
+		{s: ""},
+		{s: "10m", want: &TimeBound{Before: 10 * time.Minute}},
+		{s: "-90s", want: &TimeBound{Before: 90 * time.Second}},
+		{s: "2023-07-03T08:26:40Z", want: &TimeBound{Time: at}},
+		{s: "Mon Jul  3 08:26:40 UTC 2023", want: &TimeBound{Time: at}},
+		{s: "2023-07-03T08:26:40.5Z", want: &TimeBound{Time: at.Add(500 * time.Millisecond)}},
+		{s: "--10m", wantErr: true},
+		{s: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeBound(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimeBound(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && (!got.Time.Equal(tt.want.Time) || got.Before != tt.want.Before)) {
+			t.Errorf("ParseTimeBound(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTimeWindowApply(t *testing.T) {
+	at := func(sec int) Timestamp {
+		return Timestamp{Time: time.Date(2023, 7, 3, 8, 0, sec, 0, time.UTC)}
+	}
+	bound := func(sec int) *TimeBound {
+		return &TimeBound{Time: at(sec).Time}
+	}
+	tests := []struct {
+		name   string
+		window TimeWindow
+		want   []string
+	}{
+		{name: "open", want: []string{"/bin/a", "/bin/b", "/bin/c", "/bin/d"}},
+		{name: "since", window: TimeWindow{Since: bound(20)}, want: []string{"/bin/b", "/bin/c", "/bin/d"}},
+		{name: "until", window: TimeWindow{Until: bound(20)}, want: []string{"/bin/a", "/bin/b", "/bin/d"}},
+		{name: "inclusive", window: TimeWindow{Since: bound(20), Until: bound(20)}, want: []string{"/bin/b", "/bin/d"}},
+		{name: "relative", window: TimeWindow{Since: &TimeBound{Before: 5 * time.Second}}, want: []string{"/bin/c", "/bin/d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd := &SummaryData{ProcessData: []ProcessData{
+				{Source: "/bin/sh", Destination: "/bin/a", UpdatedTime: at(10)},
+				{Source: "/bin/sh", Destination: "/bin/b", UpdatedTime: at(20)},
+				{Source: "/bin/sh", Destination: "/bin/c", UpdatedTime: at(30)},
+				// the entries without UpdatedTime are kept
+				{Source: "/bin/sh", Destination: "/bin/d"},
+			}}
+			tt.window.Apply([]*SummaryData{sd})
+			var got []string
+			for _, ps := range sd.ProcessData {
+				got = append(got, ps.Destination)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Apply() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPhases(t *testing.T) {
+	file := filepath.Join(t.TempDir(), DefaultPhasesFile)
+	start := time.Date(2023, 7, 3, 8, 26, 40, 700000000, time.UTC)
+	end := start.Add(2*time.Minute + 500*time.Millisecond)
+	for _, mark := range []struct {
+		name, mark string
+		at         time.Time
+	}{
+		{name: "load-test", mark: PhaseStart, at: start},
+		{name: "load-test", mark: PhaseEnd, at: end},
+		{name: "warm-up", mark: PhaseEnd, at: start},
+	} {
+		if err := RecordPhase(file, mark.name, mark.mark, mark.at); err != nil {
+			t.Fatalf("RecordPhase() error = %v", err)
+		}
+	}
+	phases, err := ReadPhases(file)
+	if err != nil {
+		t.Fatalf("ReadPhases() error = %v", err)
+	}
+	p, err := phases.Get("load-test")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	// the marks are recorded with nanoseconds
+	if !p.Start.Equal(start) || !p.End.Equal(end) {
+		t.Errorf("load-test = %s .. %s, want %s .. %s", p.Start, p.End, start, end)
+	}
+	if _, err := phases.Get("soak-test"); err == nil {
+		t.Errorf("Get(soak-test) error = nil, want an error")
+	}
+
+	// an entry of the last second of the phase is kept, karmor records the seconds only
+	sd := &SummaryData{ProcessData: []ProcessData{
+		{Source: "/bin/sh", Destination: "/bin/before", UpdatedTime: Timestamp{Time: start.Add(-time.Second).Truncate(time.Second)}},
+		{Source: "/bin/sh", Destination: "/bin/first", UpdatedTime: Timestamp{Time: start.Truncate(time.Second)}},
+		{Source: "/bin/sh", Destination: "/bin/last", UpdatedTime: Timestamp{Time: end.Truncate(time.Second)}},
+		{Source: "/bin/sh", Destination: "/bin/after", UpdatedTime: Timestamp{Time: end.Add(time.Second).Truncate(time.Second)}},
+	}}
+	p.Window().Apply([]*SummaryData{sd})
+	if len(sd.ProcessData) != 2 || sd.ProcessData[0].Destination != "/bin/first" || sd.ProcessData[1].Destination != "/bin/last" {
+		t.Errorf("Window().Apply() = %+v, want the entries of the first and last seconds", sd.ProcessData)
+	}
+
+	for _, text := range []string{"load-test begin 2023-07-03T08:26:40Z\n", "load-test start tomorrow\n"} {
+		if err := os.WriteFile(file, []byte(text), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadPhases(file); err == nil {
+			t.Errorf("ReadPhases(%q) error = nil, want an error", text)
+		}
+	}
+}
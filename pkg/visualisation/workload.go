@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kubearmor/kubearmor-action/common"
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+	"github.com/kubearmor/kubearmor-action/utils"
+	osi "github.com/kubearmor/kubearmor-action/utils/os"
+	"k8s.io/klog"
+)
+
+// WorkloadID identifies the workload a summary data entry belongs to
+type WorkloadID struct {
+	Namespace  string `json:"Namespace"`
+	Deployment string `json:"Deployment,omitempty"`
+	Pod        string `json:"Pod,omitempty"`
+	Container  string `json:"Container,omitempty"`
+}
+
+// WorkloadOf returns the workload of a summary data entry
+func WorkloadOf(sd *SummaryData) WorkloadID {
+	return WorkloadID{
+		Namespace:  sd.Namespace,
+		Deployment: sd.DeploymentName,
+		Pod:        sd.PodName,
+		Container:  sd.ContainerName,
+	}
+}
+
+// String returns the workload as namespace/deployment/pod[/container]
+func (w WorkloadID) String() string {
+	s := w.Namespace + "/" + w.Deployment + "/" + w.Pod
+	if w.Container != "" {
+		s += "/" + w.Container
+	}
+	return s
+}
+
+// FileName returns the workload as a string that is safe to use in a file name
+func (w WorkloadID) FileName() string {
+	parts := []string{w.Namespace, w.Deployment, w.Pod}
+	if w.Container != "" {
+		parts = append(parts, w.Container)
+	}
+	name := strings.Join(parts, "-")
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// less orders workloads by namespace, deployment, pod and container
+func (w WorkloadID) less(o WorkloadID) bool {
+	if w.Namespace != o.Namespace {
+		return w.Namespace < o.Namespace
+	}
+	if w.Deployment != o.Deployment {
+		return w.Deployment < o.Deployment
+	}
+	if w.Pod != o.Pod {
+		return w.Pod < o.Pod
+	}
+	return w.Container < o.Container
+}
+
+// VisualSysIndex groups the per-workload system views by namespace and deployment
+type VisualSysIndex struct {
+	Namespaces []*VisualSysNamespace `json:"Namespaces"`
+}
+
+// VisualSysNamespace holds the deployments of a namespace
+type VisualSysNamespace struct {
+	Name        string                 `json:"Name"`
+	Deployments []*VisualSysDeployment `json:"Deployments"`
+}
+
+// VisualSysDeployment holds the system views of the pods and containers of a deployment
+type VisualSysDeployment struct {
+	Name      string           `json:"Name"`
+	Workloads []*VisualSysData `json:"Workloads"`
+}
+
+// Workloads returns all the per-workload system views, in index order
+func (idx *VisualSysIndex) Workloads() []*VisualSysData {
+	var vsds []*VisualSysData
+	for _, ns := range idx.Namespaces {
+		for _, d := range ns.Deployments {
+			vsds = append(vsds, d.Workloads...)
+		}
+	}
+	return vsds
+}
+
+// ParseWorkloadSysData parses the summary data and returns one VisualSysData object per
+// pod and container, grouped by namespace and deployment.
+// Only the workloads and behaviors selected by the filter are visualised.
+func ParseWorkloadSysData(summaryDatas []*SummaryData, f *filter.Filter) *VisualSysIndex {
+	views := make(map[WorkloadID]*VisualSysData)
+	var ids []WorkloadID
+	for _, sd := range summaryDatas {
+		if !f.MatchWorkload(filterWorkload(sd)) {
+			continue
+		}
+		id := WorkloadOf(sd)
+		vs, ok := views[id]
+		if !ok {
+			vs = &VisualSysData{
+				Name:        "sys-" + utils.GetUUID(),
+				Namespace:   sd.Namespace,
+				AppName:     f.App(),
+				Deployment:  sd.DeploymentName,
+				Pod:         sd.PodName,
+				Container:   sd.ContainerName,
+				Labels:      make([]string, 0),
+				ProcessData: make(map[string]map[string]string),
+				FileData:    make(map[string]string),
+				NetworkData: make(map[string]map[string]string),
+			}
+			views[id] = vs
+			ids = append(ids, id)
+			getLabel(sd, vs)
+		}
+		handlePsfileSet(sd, vs, "Process", f)
+		handlePsfileSet(sd, vs, "File", f)
+		handleNetworkSet(sd, vs, f)
+		handleFindings(sd, vs, f)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+
+	idx := &VisualSysIndex{}
+	var ns *VisualSysNamespace
+	var d *VisualSysDeployment
+	for _, id := range ids {
+		if ns == nil || ns.Name != id.Namespace {
+			ns = &VisualSysNamespace{Name: id.Namespace}
+			idx.Namespaces = append(idx.Namespaces, ns)
+			d = nil
+		}
+		if d == nil || d.Name != id.Deployment {
+			d = &VisualSysDeployment{Name: id.Deployment}
+			ns.Deployments = append(ns.Deployments, d)
+		}
+		d.Workloads = append(d.Workloads, views[id])
+	}
+	return idx
+}
+
+// ConvertSysJSONToImages converts the summary system JSON data to one plantuml image per workload,
+// and writes a Markdown index linking them. The images and the index are named after output,
+// eg.: sys.png gives sys-<namespace>-<deployment>-<pod>.png and sys-index.md.
+// The accessed files are aggregated into directories with the file tree options.
+// It returns the file names of the images.
+// The summary data is parsed with p before it is visualised, see ParseOptions.
+func ConvertSysJSONToImages(jsonFile string, output string, f *filter.Filter, p ParseOptions, files FileTreeOptions) ([]string, error) {
+	err := checkDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	// get summary data from json file
+	klog.Infoln("Parsing Summary Data...")
+	sd, err := p.ParseSummaryData(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+	logSubstitutions(p.Normalizer)
+
+	// parse visual sys data per workload from summary data
+	klog.Infoln("Parsing Visual System Data per Workload...")
+	idx := ParseWorkloadSysData(sd, f)
+	vsds := idx.Workloads()
+	if len(vsds) == 0 {
+		return nil, fmt.Errorf("no workload selected")
+	}
+
+	stem := strings.TrimSuffix(output, filepath.Ext(output))
+	images := make(map[*VisualSysData]string, len(vsds))
+	var outputs []string
+	for _, vsd := range vsds {
+		err = vsd.AggregateFiles(files)
+		if err != nil {
+			return nil, err
+		}
+		sysPuml, err := convertVsdToPlantUML(vsd)
+		if err != nil {
+			return nil, err
+		}
+		image := stem + "-" + vsd.Workload().FileName() + ".png"
+		klog.Infof("Rendering %s...", vsd.Workload())
+		err = renderPlantUML("sys", sysPuml, image)
+		if err != nil {
+			return nil, err
+		}
+		images[vsd] = filepath.Base(image)
+		outputs = append(outputs, image)
+	}
+
+	klog.Infoln("Creating Index...")
+	fw := osi.NewFileWriter(common.GetWorkDir() + "/" + stem + "-index.md")
+	err = fw.WriteFile([]byte(idx.Markdown(images)))
+	if err != nil {
+		return nil, err
+	}
+	klog.Infoln("Images Created Successfully!")
+	return outputs, nil
+}
+
+// Workload returns the workload the system view belongs to
+func (vs *VisualSysData) Workload() WorkloadID {
+	return WorkloadID{Namespace: vs.Namespace, Deployment: vs.Deployment, Pod: vs.Pod, Container: vs.Container}
+}
+
+// Markdown renders the index as Markdown, with a section per namespace and deployment
+// linking the images of its workloads
+func (idx *VisualSysIndex) Markdown(images map[*VisualSysData]string) string {
+	var sb strings.Builder
+	sb.WriteString("# System Behaviors\n")
+	for _, ns := range idx.Namespaces {
+		sb.WriteString(fmt.Sprintf("\n## namespace: %s\n", ns.Name))
+		for _, d := range ns.Deployments {
+			sb.WriteString(fmt.Sprintf("\n### deployment: %s\n\n", d.Name))
+			sb.WriteString("| Pod | Container | Processes | Files | Network | Allowed | Audited | Blocked |\n")
+			sb.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+			for _, vs := range d.Workloads {
+				pod := vs.Pod
+				if image, ok := images[vs]; ok {
+					pod = fmt.Sprintf("[%s](%s)", vs.Pod, image)
+				}
+				processes := 0
+				for _, children := range vs.ProcessData {
+					processes += len(children)
+				}
+				connections := 0
+				for _, commands := range vs.NetworkData {
+					connections += len(commands)
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %d | %d | %d |\n", pod, vs.Container, processes, len(vs.FileData), connections,
+					vs.Statuses.Allow, vs.Statuses.Audit, vs.Statuses.Block))
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package visualisation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kubearmor/kubearmor-action/pkg/filter"
+)
+
+func TestWorkloadID(t *testing.T) {
+	tests := []struct {
+		w        WorkloadID
+		str      string
+		fileName string
+	}{
+		{
+			w:        WorkloadID{Namespace: "wordpress-mysql", Deployment: "wordpress", Pod: "wordpress-7c966b5d85-wvtln"},
+			str:      "wordpress-mysql/wordpress/wordpress-7c966b5d85-wvtln",
+			fileName: "wordpress-mysql-wordpress-wordpress-7c966b5d85-wvtln",
+		},
+		{
+			w:        WorkloadID{Namespace: "default", Deployment: "api", Pod: "api-0", Container: "sidecar"},
+			str:      "default/api/api-0/sidecar",
+			fileName: "default-api-api-0-sidecar",
+		},
+		{
+			w:        WorkloadID{Namespace: "default", Pod: "job/x", Container: "a b:c"},
+			str:      "default//job/x/a b:c",
+			fileName: "default--job_x-a_b_c",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.w.String(); got != tt.str {
+			t.Errorf("%+v.String() = %q, want %q", tt.w, got, tt.str)
+		}
+		if got := tt.w.FileName(); got != tt.fileName {
+			t.Errorf("%+v.FileName() = %q, want %q", tt.w, got, tt.fileName)
+		}
+	}
+}
+
+func TestWorkloadIDLess(t *testing.T) {
+	tests := []struct {
+		a, b WorkloadID
+		want bool
+	}{
+		{a: WorkloadID{Namespace: "a", Deployment: "z"}, b: WorkloadID{Namespace: "b", Deployment: "a"}, want: true},
+		{a: WorkloadID{Namespace: "a", Deployment: "a", Pod: "z"}, b: WorkloadID{Namespace: "a", Deployment: "b", Pod: "a"}, want: true},
+		{a: WorkloadID{Namespace: "a", Pod: "a", Container: "z"}, b: WorkloadID{Namespace: "a", Pod: "b", Container: "a"}, want: true},
+		{a: WorkloadID{Namespace: "a", Pod: "a", Container: "b"}, b: WorkloadID{Namespace: "a", Pod: "a", Container: "a"}, want: false},
+		{a: WorkloadID{Namespace: "a"}, b: WorkloadID{Namespace: "a"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.less(tt.b); got != tt.want {
+			t.Errorf("%v.less(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseWorkloadSysData(t *testing.T) {
+	entry := func(ns, deployment, pod, container string, processes ...[2]string) *SummaryData {
+		sd := &SummaryData{Namespace: ns, DeploymentName: deployment, PodName: pod, ContainerName: container, Label: "app=" + deployment}
+		for _, p := range processes {
+			sd.ProcessData = append(sd.ProcessData, ProcessData{Source: p[0], Destination: p[1], Count: 1, Status: StatusAllow})
+		}
+		return sd
+	}
+	sds := []*SummaryData{
+		entry("wordpress-mysql", "wordpress", "wordpress-1", "wordpress", [2]string{"/usr/sbin/apache2", "/bin/sh"}),
+		entry("default", "api", "api-1", "api", [2]string{"/bin/api", "/bin/api"}),
+		entry("wordpress-mysql", "mysql", "mysql-1", "mysql", [2]string{"/usr/sbin/mysqld", "/usr/sbin/mysqld"}),
+		// the same workload is merged into one view
+		entry("wordpress-mysql", "wordpress", "wordpress-1", "wordpress", [2]string{"/bin/sh", "/usr/bin/curl"}),
+		entry("wordpress-mysql", "wordpress", "wordpress-1", "sidecar"),
+	}
+
+	tests := []struct {
+		name  string
+		app   string
+		want  string
+		views int
+	}{
+		{
+			name:  "all workloads",
+			want:  "default/api: api-1/api 1; wordpress-mysql/mysql: mysql-1/mysql 1; wordpress-mysql/wordpress: wordpress-1/sidecar 0, wordpress-1/wordpress 2",
+			views: 4,
+		},
+		{
+			name:  "app",
+			app:   "wordpress",
+			want:  "wordpress-mysql/wordpress: wordpress-1/sidecar 0, wordpress-1/wordpress 2",
+			views: 2,
+		},
+		{
+			name: "no match",
+			app:  "redis",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := filter.New(filter.Options{App: tt.app})
+			if err != nil {
+				t.Fatal(err)
+			}
+			idx := ParseWorkloadSysData(sds, f)
+			var namespaces []string
+			for _, ns := range idx.Namespaces {
+				for _, d := range ns.Deployments {
+					var workloads []string
+					for _, vs := range d.Workloads {
+						processes := 0
+						for _, children := range vs.ProcessData {
+							processes += len(children)
+						}
+						workloads = append(workloads, fmt.Sprintf("%s/%s %d", vs.Pod, vs.Container, processes))
+						if vs.AppName != tt.app {
+							t.Errorf("%s AppName = %q, want %q", vs.Workload(), vs.AppName, tt.app)
+						}
+					}
+					namespaces = append(namespaces, ns.Name+"/"+d.Name+": "+strings.Join(workloads, ", "))
+				}
+			}
+			if got := strings.Join(namespaces, "; "); got != tt.want {
+				t.Errorf("ParseWorkloadSysData() = %q, want %q", got, tt.want)
+			}
+			if got := len(idx.Workloads()); got != tt.views {
+				t.Errorf("Workloads() = %d views, want %d", got, tt.views)
+			}
+		})
+	}
+}
+
+func TestVisualSysIndexMarkdown(t *testing.T) {
+	vs := &VisualSysData{
+		Namespace: "wordpress-mysql", Deployment: "wordpress", Pod: "wordpress-1", Container: "wordpress",
+		ProcessData: map[string]map[string]string{"/usr/sbin/apache2": {"/bin/sh": StatusAllow, "/usr/bin/curl": StatusBlock}},
+		FileData:    map[string]string{"/etc/passwd": StatusAudit},
+		NetworkData: map[string]map[string]string{"egress": {"10.0.0.1:3306": StatusAllow}},
+	}
+	vs.Statuses.Add(StatusAllow, 2)
+	vs.Statuses.Add(StatusBlock, 1)
+	other := &VisualSysData{Namespace: "wordpress-mysql", Deployment: "wordpress", Pod: "wordpress-2", Container: "wordpress"}
+	idx := &VisualSysIndex{Namespaces: []*VisualSysNamespace{{
+		Name:        "wordpress-mysql",
+		Deployments: []*VisualSysDeployment{{Name: "wordpress", Workloads: []*VisualSysData{vs, other}}},
+	}}}
+
+	got := idx.Markdown(map[*VisualSysData]string{vs: "sys-wordpress-1.png"})
+	want := `# System Behaviors
+
+## namespace: wordpress-mysql
+
+### deployment: wordpress
+
+| Pod | Container | Processes | Files | Network | Allowed | Audited | Blocked |
+| --- | --- | --- | --- | --- | --- | --- | --- |
+| [wordpress-1](sys-wordpress-1.png) | wordpress | 2 | 1 | 1 | 2 | 0 | 1 |
+| wordpress-2 | wordpress | 0 | 0 | 0 | 0 | 0 | 0 |
+`
+	if got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -18,6 +18,18 @@ type URLVisitor struct {
 	HTTPAttemptCount int
 }
 
+// StatusError is returned when the server answers with a non-200 status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unable to read URL %q, server reported %s, status code=%d", e.URL, e.Status, e.StatusCode)
+}
+
 // readHTTPWithRetries tries to http.Get the v.URL retries times before giving up.
 func readHTTPWithRetries(get httpget, duration time.Duration, u string, attempts int) (io.ReadCloser, error) {
 	var err error
@@ -45,12 +57,11 @@ func readHTTPWithRetries(get httpget, duration time.Duration, u string, attempts
 		if statusCode == http.StatusOK {
 			return body, nil
 		}
-		err := body.Close()
-		if err != nil {
+		if err := body.Close(); err != nil {
 			return nil, err
 		}
 		// Error - Set the error condition from the StatusCode
-		err = fmt.Errorf("unable to read URL %q, server reported %s, status code=%d", u, status, statusCode)
+		err = &StatusError{URL: u, StatusCode: statusCode, Status: status}
 
 		if statusCode >= 500 && statusCode < 600 {
 			// Retry 500's
@@ -76,9 +87,14 @@ func httpgetImpl(url string) (int, string, io.ReadCloser, error) {
 	return resp.StatusCode, resp.Status, resp.Body, nil
 }
 
+// OpenURL opens the given url and returns its body, the caller must close it.
+func OpenURL(url string) (io.ReadCloser, error) {
+	return readHTTPWithRetries(httpgetImpl, time.Second*5, url, 3)
+}
+
 // ReadJSONFromURL reads the json file from the given url and returns it as a []byte array.
 func ReadJSONFromURL(url string) ([]byte, error) {
-	body, err := readHTTPWithRetries(httpgetImpl, time.Second*5, url, 3)
+	body, err := OpenURL(url)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"sort"
@@ -25,7 +26,7 @@ func Retry(tryTimes int, trySleepTime time.Duration, action func() error) error
 
 		time.Sleep(trySleepTime * time.Duration(2*i+1))
 	}
-	return fmt.Errorf("retry action timeout: %v", err)
+	return fmt.Errorf("retry action timeout: %w", err)
 }
 
 // GetUUID returns a UUID string
@@ -89,6 +90,18 @@ func ReadFile(address string) ([]byte, error) {
 	return data, nil
 }
 
+// OpenFile opens the file from the given address and returns it as an io.ReadCloser.
+// It can handle remote urls, local paths and "-" for the standard input.
+func OpenFile(address string) (io.ReadCloser, error) {
+	if address == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	if CheckIsURL(address) {
+		return urlfile.OpenURL(address)
+	}
+	return os.Open(address) // #nosec
+}
+
 // CheckIsURL checks if the given address is a url
 func CheckIsURL(address string) bool {
 	// Parse the address as a url